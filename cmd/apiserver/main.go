@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -37,7 +38,7 @@ func main() {
 			os.Exit(1)
 		}
 		images := strings.Split(imagesStr, ",")
-		puller.Run(images, socketPath)
+		puller.Run(images, socketPath, puller.OptionsFromEnv())
 		return
 	}
 
@@ -112,6 +113,32 @@ func main() {
 	nodeFilter := service.NewNodeFilter(k8sClient)
 	batchScheduler := service.NewBatchScheduler(jobCreator, logger)
 	statusTracker := service.NewStatusTracker(repo, jobCreator, logger)
+	if grace, err := strconv.Atoi(os.Getenv("STUCK_POD_GRACE_PERIOD_SECONDS")); err == nil && grace > 0 {
+		statusTracker.SetStuckPodGracePeriod(time.Duration(grace) * time.Second)
+		batchScheduler.SetStuckPodGracePeriod(time.Duration(grace) * time.Second)
+	}
+	if wait, err := strconv.Atoi(os.Getenv("BATCH_WAIT_TIMEOUT_SECONDS")); err == nil && wait >= 0 {
+		batchScheduler.SetBatchWaitTimeout(time.Duration(wait) * time.Second)
+	}
+
+	// 共享 Job/Pod Informer：所有任务的状态跟踪与批次调度共用一份缓存，同步失败时退回逐任务 Watch 与 List
+	informerCtx, stopInformer := context.WithCancel(context.Background())
+	defer stopInformer()
+	if informer, err := k8sClient.NewPrewarmInformer(10 * time.Minute); err != nil {
+		logger.Errorf("Failed to create prewarm informer: %v", err)
+	} else {
+		informer.Start(informerCtx)
+		syncCtx, cancelSync := context.WithTimeout(informerCtx, time.Minute)
+		if err := informer.WaitForCacheSync(syncCtx); err != nil {
+			logger.Errorf("Failed to sync prewarm informer, falling back to per-task watches: %v", err)
+			stopInformer()
+		} else {
+			statusTracker.SetInformer(informer)
+			batchScheduler.SetInformer(informer)
+			logger.Info("Prewarm job/pod informer synced")
+		}
+		cancelSync()
+	}
 
 	logger.Info("Service components initialized")
 
@@ -125,6 +152,8 @@ func main() {
 
 	// 5. 初始化任务管理器
 	taskManager := service.NewTaskManager(
+		repo,
+		repo,
 		repo,
 		repo,
 		nodeFilter,
@@ -132,6 +161,9 @@ func main() {
 		statusTracker,
 		logger,
 	)
+	if days, err := strconv.Atoi(os.Getenv("TASK_EVENT_RETENTION_DAYS")); err == nil && days > 0 {
+		taskManager.SetEventRetention(time.Duration(days) * 24 * time.Hour)
+	}
 	logger.Info("Task manager initialized")
 
 	// 5.5. 初始化定时任务管理器
@@ -142,14 +174,90 @@ func main() {
 		logger,
 	)
 
-	// 启动定时任务管理器
-	if err := scheduledTaskManager.Start(); err != nil {
-		logger.Fatalf("Failed to start scheduled task manager: %v", err)
-	}
 	logger.Info("Scheduled task manager initialized")
 
+	// 5.7. 初始化镜像常驻策略与新节点自动预热控制器：节点 Informer 在所有副本上运行（合规报告读取缓存），协调与预热只在 Leader 上运行
+	imagePolicyController := service.NewImagePolicyController(repo, taskManager, k8sClient, logger)
+	if cooldown, err := strconv.Atoi(os.Getenv("IMAGE_POLICY_COOLDOWN_SECONDS")); err == nil && cooldown >= 0 {
+		imagePolicyController.SetCooldown(time.Duration(cooldown) * time.Second)
+	}
+	nodeWarmupController := service.NewNodeWarmupController(repo, repo, repo, taskManager, k8sClient, logger)
+	nodeInformer := k8sClient.NewNodeInformer(10 * time.Minute)
+	if err := imagePolicyController.SetNodeInformer(nodeInformer); err != nil {
+		logger.Errorf("Failed to watch nodes for image policies: %v", err)
+	} else if err := nodeWarmupController.SetNodeInformer(nodeInformer); err != nil {
+		logger.Errorf("Failed to watch nodes for new node warmup: %v", err)
+	} else {
+		nodeInformer.Start(informerCtx)
+		syncCtx, cancelSync := context.WithTimeout(informerCtx, time.Minute)
+		if err := nodeInformer.WaitForCacheSync(syncCtx); err != nil {
+			logger.Errorf("Failed to sync node informer, image policies will only be reconciled periodically: %v", err)
+		} else {
+			logger.Info("Node informer synced")
+		}
+		cancelSync()
+	}
+
+	// 5.6. 选主：仅 Leader 运行任务执行器与定时调度，Follower 只提供读接口并将写请求落库排队
+	startExecutors := func(leaderCtx context.Context) {
+		taskManager.Start(leaderCtx)
+		if err := scheduledTaskManager.Start(); err != nil {
+			logger.Errorf("Failed to start scheduled task manager: %v", err)
+		}
+		imagePolicyController.Start(leaderCtx)
+		nodeWarmupController.Start(leaderCtx)
+	}
+	stopExecutors := func() {
+		nodeWarmupController.Stop()
+		imagePolicyController.Stop()
+		scheduledTaskManager.Stop()
+		taskManager.Stop()
+	}
+
+	leaderCtx, stopLeaderElection := context.WithCancel(context.Background())
+	leaderDone := make(chan struct{})
+	if os.Getenv("LEADER_ELECTION_ENABLED") == "false" {
+		logger.Info("Leader election disabled, running as single instance")
+		startExecutors(leaderCtx)
+		close(leaderDone)
+	} else {
+		identity := os.Getenv("POD_NAME")
+		if identity == "" {
+			identity, _ = os.Hostname()
+		}
+		leaseName := os.Getenv("LEADER_ELECTION_LEASE_NAME")
+		if leaseName == "" {
+			leaseName = "ips-apiserver-leader"
+		}
+
+		go func() {
+			defer close(leaderDone)
+			err := k8sClient.RunLeaderElection(leaderCtx, k8s.LeaderElectionConfig{
+				LeaseName: leaseName,
+				Identity:  identity,
+			}, k8s.LeaderCallbacks{
+				OnStartedLeading: func(ctx context.Context) {
+					logger.WithField("identity", identity).Info("Became leader, starting task executors")
+					startExecutors(ctx)
+				},
+				OnStoppedLeading: func() {
+					logger.WithField("identity", identity).Info("Lost leadership, stopping task executors")
+					stopExecutors()
+				},
+				OnNewLeader: func(leader string) {
+					if leader != identity {
+						logger.WithField("leader", leader).Info("Running as follower")
+					}
+				},
+			})
+			if err != nil {
+				logger.Fatalf("Failed to run leader election: %v", err)
+			}
+		}()
+	}
+
 	// 6. 设置路由
-	router := api.SetupRouter(logger, taskManager, scheduledTaskManager, authService, repo, repo, repo, k8sClient)
+	router := api.SetupRouter(logger, taskManager, scheduledTaskManager, imagePolicyController, nodeWarmupController, authService, repo, repo, repo, k8sClient)
 
 	// 6. 创建HTTP服务器
 	port := os.Getenv("SERVER_PORT")
@@ -179,7 +287,14 @@ func main() {
 
 	logger.Info("Shutting down server...")
 
-	scheduledTaskManager.Stop()
+	// 释放 Lease 以便其他副本尽快接管
+	stopLeaderElection()
+	<-leaderDone
+	// 选主停用时不会触发 OnStoppedLeading，统一在此停止（重复调用无副作用）
+	stopExecutors()
+	stopInformer()
+	// 写入尚在队列中的任务事件
+	taskManager.Events().Flush()
 
 	// 优雅关闭，设置5秒超时
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
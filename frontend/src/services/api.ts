@@ -18,6 +18,11 @@ import type {
   ScheduledExecution,
   ListScheduledExecutionsRequest,
   ListScheduledExecutionsResponse,
+  ImagePolicy,
+  CreateImagePolicyRequest,
+  UpdateImagePolicyRequest,
+  ListImagePoliciesResponse,
+  ImagePolicyCompliance,
   SaveImageRequest,
   ListImagesResponse,
   Secret,
@@ -155,6 +160,37 @@ export const scheduledTaskApi = {
   },
 }
 
+export const imagePolicyApi = {
+  list: async (): Promise<ListImagePoliciesResponse> => {
+    const response = await apiClient.get<ListImagePoliciesResponse>('/image-policies')
+    return response.data
+  },
+
+  get: async (id: string): Promise<ImagePolicy> => {
+    const response = await apiClient.get<ImagePolicy>(`/image-policies/${id}`)
+    return response.data
+  },
+
+  create: async (data: CreateImagePolicyRequest): Promise<ImagePolicy> => {
+    const response = await apiClient.post<ImagePolicy>('/image-policies', data)
+    return response.data
+  },
+
+  update: async (id: string, data: UpdateImagePolicyRequest): Promise<ImagePolicy> => {
+    const response = await apiClient.put<ImagePolicy>(`/image-policies/${id}`, data)
+    return response.data
+  },
+
+  delete: async (id: string): Promise<void> => {
+    await apiClient.delete(`/image-policies/${id}`)
+  },
+
+  getCompliance: async (id: string): Promise<ImagePolicyCompliance> => {
+    const response = await apiClient.get<ImagePolicyCompliance>(`/image-policies/${id}/compliance`)
+    return response.data
+  },
+}
+
 export const libraryApi = {
   list: async (params?: { limit?: number; offset?: number }): Promise<ListImagesResponse> => {
     const response = await apiClient.get<ListImagesResponse>('/library', { params })
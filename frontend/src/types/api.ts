@@ -41,7 +41,7 @@ export interface UpdatePasswordRequest {
 }
 
 // Task Types
-export type TaskStatus = 'pending' | 'running' | 'completed' | 'failed' | 'cancelled'
+export type TaskStatus = 'pending' | 'running' | 'paused' | 'completed' | 'failed' | 'cancelled'
 
 export interface Progress {
   totalNodes: number
@@ -50,6 +50,7 @@ export interface Progress {
   currentBatch: number
   totalBatches: number
   percentage: number
+  skippedNodes?: number
 }
 
 export interface FailedNode {
@@ -57,16 +58,90 @@ export interface FailedNode {
   image: string
   reason: string
   message?: string
+  attempts?: number
   timestamp: string
 }
 
+export interface NodeSelectorRequirement {
+  key: string
+  operator: 'In' | 'NotIn' | 'Exists' | 'DoesNotExist'
+  values?: string[]
+}
+
+export interface TaintSelector {
+  key: string
+  value?: string
+  effect?: 'NoSchedule' | 'PreferNoSchedule' | 'NoExecute'
+}
+
+export interface NodeSelection {
+  matchExpressions?: NodeSelectorRequirement[]
+  includeNodes?: string[]
+  excludeNodes?: string[]
+  excludeTaints?: TaintSelector[]
+  includeNotReady?: boolean
+  includeCordoned?: boolean
+}
+
+export interface RolloutStrategy {
+  canaryNodes?: number
+  canaryPercent?: number
+  successThreshold?: number
+  onFailure?: 'abort' | 'pause'
+}
+
+export interface CanaryStatus {
+  phase: 'pending' | 'passed' | 'failed'
+  nodes: string[]
+  succeeded: number
+  failed: number
+  successRate: number
+  threshold: number
+  failedNodes?: string[]
+  evaluatedAt?: string
+}
+
+export type WorkloadKind = 'Deployment' | 'StatefulSet' | 'DaemonSet'
+
+export interface WorkloadRef {
+  kind: WorkloadKind
+  namespace?: string
+  name: string
+}
+
+export interface WorkloadSource {
+  workloads?: WorkloadRef[]
+  namespace?: string
+  labelSelector?: Record<string, string>
+  kinds?: WorkloadKind[]
+  matchNodes?: boolean
+}
+
+export interface SuccessPolicy {
+  minSuccessPercent?: number
+  requiredNodes?: string[]
+  requiredNodeSelector?: Record<string, string>
+  imageFailureFailsNode?: boolean
+}
+
 export interface Task {
   taskId: string
   status: TaskStatus
   priority: number
   images: string[]
+  imageDigests?: Record<string, string>
   batchSize: number
   nodeSelector?: Record<string, string>
+  nodeSelection?: NodeSelection
+  skipPresentNodes?: boolean
+  rollout?: RolloutStrategy
+  canary?: CanaryStatus
+  successPolicy?: SuccessPolicy
+  requiredNodes?: string[]
+  workloads?: WorkloadSource
+  pullParallelism?: number
+  imagePullTimeoutSeconds?: number
+  timeoutSeconds?: number
   progress?: Progress
   failedNodeDetails?: FailedNode[]
   maxRetries: number
@@ -83,7 +158,72 @@ export interface Task {
   finishedAt?: string
   estimatedCompletion?: string
   errorMessage?: string
+  nodeResults?: Record<string, NodeResult>
+  /** @deprecated 使用 nodeResults，下个版本移除 */
   nodeStatuses?: Record<string, Record<string, number>>
+  nodeAttempts?: Record<string, NodeAttempt[]>
+}
+
+export type ImagePullStatus = 'succeeded' | 'failed' | 'already_present' | 'timeout'
+
+export interface ImagePullResult {
+  image: string
+  status: ImagePullStatus
+  error?: string
+  durationMs: number
+  digest?: string
+  sizeBytes?: number
+}
+
+export interface NodeResult {
+  nodeName: string
+  status: 'pending' | 'succeeded' | 'failed' | 'skipped'
+  message?: string
+  images?: ImagePullResult[]
+  updatedAt: string
+}
+
+export interface NodeAttempt {
+  attempt: number
+  jobName: string
+  status: 'pending' | 'succeeded' | 'failed'
+  reason?: string
+  message?: string
+  startedAt: string
+  finishedAt?: string
+}
+
+export interface NodeLog {
+  nodeName: string
+  attempt: number
+  jobName: string
+  podName: string
+  logs: string
+  truncated: boolean
+  capturedAt: string
+}
+
+export type TaskEventType =
+  | 'status'
+  | 'batch_started'
+  | 'batch_submitted'
+  | 'job_create_failed'
+  | 'node_result'
+  | 'node_retry'
+
+export interface TaskEvent {
+  id: number
+  taskId: string
+  type: TaskEventType
+  timestamp: string
+  status?: TaskStatus
+  batch?: number
+  nodeName?: string
+  nodeStatus?: NodeResult['status']
+  attempt?: number
+  reason?: string
+  message?: string
+  progress?: Progress
 }
 
 export interface CreateTaskRequest {
@@ -91,6 +231,15 @@ export interface CreateTaskRequest {
   batchSize: number
   priority: number
   nodeSelector?: Record<string, string>
+  nodeSelection?: NodeSelection
+  skipPresentNodes?: boolean
+  resolveDigests?: boolean
+  rollout?: RolloutStrategy
+  successPolicy?: SuccessPolicy
+  workloads?: WorkloadSource
+  pullParallelism?: number
+  imagePullTimeoutSeconds?: number
+  timeoutSeconds?: number
   maxRetries: number
   retryStrategy: 'linear' | 'exponential'
   retryDelay?: number
@@ -101,6 +250,33 @@ export interface CreateTaskRequest {
   password?: string
 }
 
+export interface TaskPlan {
+  images?: string[]
+  matchedNodes: string[]
+  excludedNodes: { nodeName: string; reason: string }[]
+  presentNodes: string[]
+  targetNodes: string[]
+  totalNodes: number
+  skippedNodes?: number
+  batchMode: string
+  windowSize?: number
+  totalBatches: number
+  batches: { batch: number; nodes: string[]; canary?: boolean }[]
+  credentials: {
+    source: 'none' | 'manual' | 'secret'
+    secretId?: number
+    registry?: string
+    username?: string
+    error?: string
+  }
+  estimate?: {
+    durationSeconds: number
+    secondsPerBatch: number
+    sampleTasks: number
+  }
+  warnings?: string[]
+}
+
 export interface ListTasksRequest {
   limit?: number
   offset?: number
@@ -122,6 +298,14 @@ export interface TaskConfig {
   batchSize: number
   priority: number
   nodeSelector?: Record<string, string>
+  nodeSelection?: NodeSelection
+  skipPresentNodes?: boolean
+  resolveDigests?: boolean
+  rollout?: RolloutStrategy
+  successPolicy?: SuccessPolicy
+  workloads?: WorkloadSource
+  pullParallelism?: number
+  imagePullTimeoutSeconds?: number
   maxRetries: number
   retryStrategy: string
   retryDelay: number
@@ -208,6 +392,67 @@ export interface ListScheduledExecutionsResponse {
   offset: number
 }
 
+// Image Policy Types
+export interface ImagePolicyState {
+  activeTaskId?: string
+  lastTaskId?: string
+  lastReconcileAt?: string
+  remediatedAt?: Record<string, string>
+}
+
+export interface ImagePolicy {
+  id: string
+  name: string
+  description: string
+  enabled: boolean
+  taskConfig: TaskConfig
+  state: ImagePolicyState
+  createdBy: string
+  createdAt: string
+  updatedAt: string
+}
+
+export interface CreateImagePolicyRequest {
+  name: string
+  description?: string
+  enabled?: boolean
+  taskConfig: TaskConfig
+}
+
+export interface UpdateImagePolicyRequest {
+  name?: string
+  description?: string
+  enabled?: boolean
+  taskConfig?: TaskConfig
+}
+
+export interface ListImagePoliciesResponse {
+  policies: ImagePolicy[]
+  total: number
+}
+
+export interface NodeCompliance {
+  nodeName: string
+  compliant: boolean
+  missingImages?: string[]
+  unknown?: boolean
+  remediatedAt?: string
+}
+
+export interface ImagePolicyCompliance {
+  policyId: string
+  images: string[]
+  matchedNodes: number
+  compliantNodes: number
+  unknownNodes: number
+  compliancePercent: number
+  nodes: NodeCompliance[]
+  activeTaskId?: string
+  lastTaskId?: string
+  lastReconcileAt?: string
+  generatedAt: string
+}
+
 // Library Types
 export interface LibraryImage {
   id: number
@@ -300,6 +545,13 @@ export interface NodeStats {
   coverage: number
 }
 
+export interface ConcurrencySummary {
+  globalLimit: number
+  running: number
+  queued: number
+}
+
 export interface StatsResponse {
   nodes: NodeStats
+  concurrency?: ConcurrencySummary
 }
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kitsnail/ips/internal/service"
+	"github.com/kitsnail/ips/pkg/models"
+)
+
+// ConcurrencyHandler 并发限制处理器
+type ConcurrencyHandler struct {
+	taskManager *service.TaskManager
+}
+
+// NewConcurrencyHandler 创建并发限制处理器
+func NewConcurrencyHandler(taskManager *service.TaskManager) *ConcurrencyHandler {
+	return &ConcurrencyHandler{
+		taskManager: taskManager,
+	}
+}
+
+// GetLimits 获取并发限制及使用情况（包括按创建者、节点选择器分组的明细）
+// @Summary 获取任务并发限制及使用情况
+// @Router /api/v1/admin/concurrency [get]
+func (h *ConcurrencyHandler) GetLimits(c *gin.Context) {
+	stats, err := h.taskManager.ConcurrencyStats(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get concurrency stats",
+			"details": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// UpdateLimits 更新并发限制
+// @Summary 更新任务并发限制（立即生效并持久化）
+// @Router /api/v1/admin/concurrency [put]
+func (h *ConcurrencyHandler) UpdateLimits(c *gin.Context) {
+	var limits models.ConcurrencyLimits
+	if err := c.ShouldBindJSON(&limits); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := limits.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid concurrency limits",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.taskManager.UpdateConcurrencyLimits(c.Request.Context(), limits); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update concurrency limits",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.taskManager.GetConcurrencyLimits())
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kitsnail/ips/internal/repository"
+	"github.com/kitsnail/ips/pkg/models"
+)
+
+// sseHeartbeatInterval 心跳间隔，避免代理因连接空闲断开
+const sseHeartbeatInterval = 15 * time.Second
+
+// StreamTaskEvents 以 SSE 推送单个任务的事件
+// 首先推送任务当前状态（snapshot），然后回放任务的历史事件（携带 Last-Event-ID 时仅回放其后的事件），
+// 之后推送实时事件，任务结束后关闭连接
+// @Summary 订阅任务事件（Server-Sent Events）
+// @Router /api/v1/tasks/:id/events [get]
+func (h *TaskHandler) StreamTaskEvents(c *gin.Context) {
+	taskID := c.Param("id")
+
+	task, err := h.taskManager.GetTask(c.Request.Context(), taskID)
+	if err != nil {
+		if err == repository.ErrTaskNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":  "Task not found",
+				"taskId": taskID,
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get task",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	replay, events, unsubscribe, err := h.taskManager.Events().Subscribe(taskID, lastEventID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to subscribe task events",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer unsubscribe()
+
+	startEventStream(c)
+	writeEvent(c, "", "snapshot", task)
+	for i := range replay {
+		writeTaskEvent(c, &replay[i])
+		if replay[i].IsTerminal() {
+			return
+		}
+	}
+
+	// 任务已结束但没有对应的状态事件（如事件持久化失败）
+	if task.Status == models.TaskCompleted || task.Status == models.TaskFailed || task.Status == models.TaskCancelled {
+		return
+	}
+
+	streamEvents(c, events, true)
+}
+
+// StreamEvents 以 SSE 推送所有任务的事件
+// 携带 Last-Event-ID（请求头或 lastEventId 查询参数）时先回放其后的历史事件（最多 1000 条）
+// @Summary 订阅所有任务事件（Server-Sent Events）
+// @Router /api/v1/events [get]
+func (h *TaskHandler) StreamEvents(c *gin.Context) {
+	// 未携带 Last-Event-ID 时只推送实时事件
+	replay, events, unsubscribe, err := h.taskManager.Events().Subscribe("", lastEventID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to subscribe events",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer unsubscribe()
+
+	startEventStream(c)
+	for i := range replay {
+		writeTaskEvent(c, &replay[i])
+	}
+
+	streamEvents(c, events, false)
+}
+
+// lastEventID 读取客户端已收到的最后一个事件序号
+// EventSource 重连时通过 Last-Event-ID 请求头携带，首次连接可通过 lastEventId 查询参数指定
+func lastEventID(c *gin.Context) int64 {
+	value := c.GetHeader("Last-Event-ID")
+	if value == "" {
+		value = c.Query("lastEventId")
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0
+	}
+	return id
+}
+
+// startEventStream 写入 SSE 响应头
+// 取消服务器的写超时，长连接由客户端断开或任务结束时关闭
+func startEventStream(c *gin.Context) {
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 禁用 nginx 缓冲
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+}
+
+// streamEvents 推送实时事件直到客户端断开或订阅被断开
+// closeOnTerminal 为 true 时推送任务结束事件后关闭连接
+func streamEvents(c *gin.Context, events <-chan models.TaskEvent, closeOnTerminal bool) {
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				// 消费过慢被断开，客户端携带 Last-Event-ID 重连即可补齐
+				return
+			}
+			writeTaskEvent(c, &event)
+			if closeOnTerminal && event.IsTerminal() {
+				return
+			}
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": keepalive\n\n")
+			c.Writer.Flush()
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// writeTaskEvent 写入任务事件，事件名为事件类型
+func writeTaskEvent(c *gin.Context, event *models.TaskEvent) {
+	writeEvent(c, strconv.FormatInt(event.ID, 10), string(event.Type), event)
+}
+
+// writeEvent 按 SSE 格式写入一条事件
+func writeEvent(c *gin.Context, id, name string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	if id != "" {
+		fmt.Fprintf(c.Writer, "id: %s\n", id)
+	}
+	fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", name, payload)
+	c.Writer.Flush()
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kitsnail/ips/internal/repository"
+	"github.com/kitsnail/ips/internal/service"
+	"github.com/kitsnail/ips/pkg/models"
+)
+
+// ImagePolicyHandler 镜像常驻策略处理器
+type ImagePolicyHandler struct {
+	controller *service.ImagePolicyController
+}
+
+// NewImagePolicyHandler 创建镜像常驻策略处理器
+func NewImagePolicyHandler(controller *service.ImagePolicyController) *ImagePolicyHandler {
+	return &ImagePolicyHandler{
+		controller: controller,
+	}
+}
+
+// CreatePolicy 创建策略
+// POST /api/v1/image-policies
+func (h *ImagePolicyHandler) CreatePolicy(c *gin.Context) {
+	var req models.CreateImagePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	policy, err := h.controller.CreatePolicy(c.Request.Context(), &req, currentUsername(c))
+	if err != nil {
+		respondImagePolicyError(c, err, "Failed to create image policy")
+		return
+	}
+
+	c.JSON(http.StatusCreated, policy)
+}
+
+// ListPolicies 列出策略
+// GET /api/v1/image-policies
+func (h *ImagePolicyHandler) ListPolicies(c *gin.Context) {
+	policies, err := h.controller.ListPolicies(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list image policies",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policies": policies,
+		"total":    len(policies),
+	})
+}
+
+// GetPolicy 获取策略
+// GET /api/v1/image-policies/:id
+func (h *ImagePolicyHandler) GetPolicy(c *gin.Context) {
+	policy, err := h.controller.GetPolicy(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondImagePolicyError(c, err, "Failed to get image policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// UpdatePolicy 更新策略
+// PUT /api/v1/image-policies/:id
+func (h *ImagePolicyHandler) UpdatePolicy(c *gin.Context) {
+	var req models.UpdateImagePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	policy, err := h.controller.UpdatePolicy(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		respondImagePolicyError(c, err, "Failed to update image policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeletePolicy 删除策略
+// DELETE /api/v1/image-policies/:id
+func (h *ImagePolicyHandler) DeletePolicy(c *gin.Context) {
+	policyID := c.Param("id")
+	if err := h.controller.DeletePolicy(c.Request.Context(), policyID); err != nil {
+		respondImagePolicyError(c, err, "Failed to delete image policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policyId": policyID,
+		"status":   "success",
+		"message":  "Image policy deleted successfully",
+	})
+}
+
+// GetCompliance 获取策略的合规报告
+// GET /api/v1/image-policies/:id/compliance
+func (h *ImagePolicyHandler) GetCompliance(c *gin.Context) {
+	report, err := h.controller.GetCompliance(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondImagePolicyError(c, err, "Failed to get image policy compliance")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// respondImagePolicyError 将策略不存在映射为 404，策略无效映射为 400，其他错误为 500
+func respondImagePolicyError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrImagePolicyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":    "Image policy not found",
+			"policyId": c.Param("id"),
+		})
+	case errors.Is(err, service.ErrInvalidImagePolicy):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid image policy",
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
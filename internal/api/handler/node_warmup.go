@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kitsnail/ips/internal/service"
+	"github.com/kitsnail/ips/pkg/models"
+)
+
+// NodeWarmupHandler 新节点自动预热处理器
+type NodeWarmupHandler struct {
+	controller *service.NodeWarmupController
+}
+
+// NewNodeWarmupHandler 创建新节点自动预热处理器
+func NewNodeWarmupHandler(controller *service.NodeWarmupController) *NodeWarmupHandler {
+	return &NodeWarmupHandler{
+		controller: controller,
+	}
+}
+
+// GetConfig 获取新节点自动预热配置
+// @Summary 获取新节点自动预热配置及等待预热的节点
+// @Router /api/v1/admin/node-warmup [get]
+func (h *NodeWarmupHandler) GetConfig(c *gin.Context) {
+	status, err := h.controller.GetStatus(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get node warmup config",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// UpdateConfig 更新新节点自动预热配置
+// @Summary 更新新节点自动预热配置（持久化，Leader 在下次检查时生效）
+// @Router /api/v1/admin/node-warmup [put]
+func (h *NodeWarmupHandler) UpdateConfig(c *gin.Context) {
+	var config models.NodeWarmupConfig
+	if err := c.ShouldBindJSON(&config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.controller.UpdateConfig(c.Request.Context(), &config); err != nil {
+		if errors.Is(err, service.ErrInvalidNodeWarmupConfig) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid node warmup config",
+				"details": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update node warmup config",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	h.GetConfig(c)
+}
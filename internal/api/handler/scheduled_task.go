@@ -39,6 +39,10 @@ func (h *ScheduledTaskHandler) CreateScheduledTask(c *gin.Context) {
 		req.OverlapPolicy = models.OverlapPolicySkip
 	}
 
+	if !validateTaskConfig(c, &req.TaskConfig) {
+		return
+	}
+
 	// 生成 ScheduledTask ID（使用 sched- 前缀）
 	taskID := models.GenerateTaskID("sched")
 	task := &models.ScheduledTask{
@@ -50,7 +54,7 @@ func (h *ScheduledTaskHandler) CreateScheduledTask(c *gin.Context) {
 		TaskConfig:     req.TaskConfig,
 		OverlapPolicy:  req.OverlapPolicy,
 		TimeoutSeconds: req.TimeoutSeconds,
-		CreatedBy:      c.GetString("username"),
+		CreatedBy:      currentUsername(c),
 	}
 
 	if err := h.scheduledTaskManager.CreateScheduledTask(context.Background(), task); err != nil {
@@ -160,6 +164,9 @@ func (h *ScheduledTaskHandler) UpdateScheduledTask(c *gin.Context) {
 		task.Enabled = *req.Enabled
 	}
 	if req.TaskConfig != nil {
+		if !validateTaskConfig(c, req.TaskConfig) {
+			return
+		}
 		task.TaskConfig = *req.TaskConfig
 	}
 	if req.OverlapPolicy != nil {
@@ -190,6 +197,46 @@ func (h *ScheduledTaskHandler) UpdateScheduledTask(c *gin.Context) {
 	c.JSON(http.StatusOK, task)
 }
 
+// validateTaskConfig 校验定时任务的镜像、工作负载来源、节点选择条件、灰度策略与成功条件，无效时写入 400 响应并返回 false
+func validateTaskConfig(c *gin.Context, config *models.TaskConfig) bool {
+	if err := service.ValidateImages(config.Images); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid image",
+			"details": err.Error(),
+		})
+		return false
+	}
+	if err := service.ValidateNodeSelection(config.NodeSelector, config.NodeSelection); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid node selection",
+			"details": err.Error(),
+		})
+		return false
+	}
+	if err := service.ValidateRollout(config.Rollout); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid rollout strategy",
+			"details": err.Error(),
+		})
+		return false
+	}
+	if err := service.ValidateWorkloadSource(config.Workloads); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid workload source",
+			"details": err.Error(),
+		})
+		return false
+	}
+	if err := service.ValidateSuccessPolicy(config.SuccessPolicy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid success policy",
+			"details": err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
 func (h *ScheduledTaskHandler) DeleteScheduledTask(c *gin.Context) {
 	taskID := c.Param("id")
 
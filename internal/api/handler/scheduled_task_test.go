@@ -25,7 +25,7 @@ func setupScheduledTaskHandler(t *testing.T) (*ScheduledTaskHandler, *gin.Engine
 	logger := logrus.New()
 	logger.SetLevel(logrus.ErrorLevel)
 
-	taskManager := service.NewTaskManager(repo, repo, nil, nil, nil, logger)
+	taskManager := service.NewTaskManager(repo, repo, repo, repo, nil, nil, nil, logger)
 
 	scheduledTaskManager := service.NewScheduledTaskManager(repo, repo, taskManager, logger)
 
@@ -5,17 +5,20 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/kitsnail/ips/internal/k8s"
+	"github.com/kitsnail/ips/internal/service"
 )
 
 // StatsHandler 统计数据处理器
 type StatsHandler struct {
-	k8sClient *k8s.Client
+	k8sClient   *k8s.Client
+	taskManager *service.TaskManager
 }
 
 // NewStatsHandler 创建统计处理器
-func NewStatsHandler(k8sClient *k8s.Client) *StatsHandler {
+func NewStatsHandler(k8sClient *k8s.Client, taskManager *service.TaskManager) *StatsHandler {
 	return &StatsHandler{
-		k8sClient: k8sClient,
+		k8sClient:   k8sClient,
+		taskManager: taskManager,
 	}
 }
 
@@ -33,11 +36,18 @@ func (h *StatsHandler) GetStats(c *gin.Context) {
 	// 获取就绪节点
 	readyNodes := k8s.FilterReadyNodes(allNodes)
 
-	c.JSON(http.StatusOK, gin.H{
+	stats := gin.H{
 		"nodes": gin.H{
 			"total":    len(allNodes),
 			"ready":    len(readyNodes),
 			"coverage": len(readyNodes),
 		},
-	})
+	}
+
+	// 该接口无需认证，只返回并发汇总；按创建者的明细见管理接口
+	if concurrency := h.taskManager.ConcurrencySummary(c.Request.Context()); concurrency != nil {
+		stats["concurrency"] = concurrency
+	}
+
+	c.JSON(http.StatusOK, stats)
 }
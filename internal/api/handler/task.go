@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -37,6 +38,83 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 		return
 	}
 
+	if !validateCredentials(c, &req) {
+		return
+	}
+
+	// 记录创建者，用于按创建者限制并发
+	req.CreatedBy = currentUsername(c)
+
+	// 创建任务
+	task, err := h.taskManager.CreateTask(c.Request.Context(), &req)
+	if err != nil {
+		respondCreateError(c, "Failed to create task", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, task)
+}
+
+// PlanTask 生成任务执行计划（dry-run）
+// 返回匹配/排除的节点、批次划分、已存在镜像的节点、凭据解析结果及预计耗时，不创建 Job 与 Secret
+// @Summary 预览预热任务的执行计划
+// @Router /api/v1/tasks/plan [post]
+func (h *TaskHandler) PlanTask(c *gin.Context) {
+	var req models.CreateTaskRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if !validateCredentials(c, &req) {
+		return
+	}
+
+	plan, err := h.taskManager.PlanTask(c.Request.Context(), &req)
+	if err != nil {
+		respondCreateError(c, "Failed to plan task", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// createValidationErrors 创建或预览任务时请求校验失败的错误及对应的响应信息
+var createValidationErrors = []struct {
+	err     error
+	message string
+}{
+	{service.ErrInvalidImage, "Invalid image"},
+	{service.ErrInvalidNodeSelection, "Invalid node selection"},
+	{service.ErrInvalidRollout, "Invalid rollout strategy"},
+	{service.ErrInvalidSuccessPolicy, "Invalid success policy"},
+	{service.ErrInvalidWorkloadSource, "Invalid workload source"},
+}
+
+// respondCreateError 返回创建或预览任务失败的响应，请求校验失败时返回 400
+func respondCreateError(c *gin.Context, message string, err error) {
+	for _, validation := range createValidationErrors {
+		if errors.Is(err, validation.err) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   validation.message,
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   message,
+		"details": err.Error(),
+	})
+}
+
+// validateCredentials 校验私有仓库凭证，无效时写入 400 响应并返回 false
+func validateCredentials(c *gin.Context, req *models.CreateTaskRequest) bool {
 	// 验证私有仓库凭证：两种方式二选一
 	// 方式1：使用 secretId
 	// 方式2：手动输入 registry/username/password
@@ -47,7 +125,7 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 				"error":   "Authentication method conflict",
 				"details": "Cannot use both secretId and manual credentials",
 			})
-			return
+			return false
 		}
 	} else if req.Registry != "" || req.Username != "" || req.Password != "" {
 		// 手动输入方式
@@ -56,37 +134,131 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 				"error":   "Private registry credentials incomplete",
 				"details": "When registry is provided, both username and password are required",
 			})
-			return
+			return false
 		}
 		if (req.Username != "" || req.Password != "") && req.Registry == "" {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error":   "Private registry credentials incomplete",
 				"details": "When username or password is provided, registry is required",
 			})
+			return false
+		}
+	}
+
+	return true
+}
+
+// GetTask 获取任务详情
+// @Summary 获取任务详情
+// @Router /api/v1/tasks/:id [get]
+func (h *TaskHandler) GetTask(c *gin.Context) {
+	taskID := c.Param("id")
+
+	task, err := h.taskManager.GetTask(c.Request.Context(), taskID)
+	if err != nil {
+		if err == repository.ErrTaskNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":  "Task not found",
+				"taskId": taskID,
+			})
 			return
 		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get task",
+			"details": err.Error(),
+		})
+		return
 	}
 
-	// 创建任务
-	task, err := h.taskManager.CreateTask(c.Request.Context(), &req)
+	c.JSON(http.StatusOK, task)
+}
+
+// GetNodeResult 获取任务在指定节点上的预热结果
+// @Summary 获取节点预热结果（每个镜像的状态、错误、耗时、digest、大小）
+// @Router /api/v1/tasks/:id/nodes/:node [get]
+func (h *TaskHandler) GetNodeResult(c *gin.Context) {
+	taskID := c.Param("id")
+	nodeName := c.Param("node")
+
+	result, err := h.taskManager.GetNodeResult(c.Request.Context(), taskID, nodeName)
+	if err != nil {
+		if err == repository.ErrTaskNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":  "Task not found",
+				"taskId": taskID,
+			})
+			return
+		}
+		if err == service.ErrNodeNotInTask {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":    "Node not found in task",
+				"taskId":   taskID,
+				"nodeName": nodeName,
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get node result",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetNodeLogs 获取节点失败时采集的 puller 日志
+// @Summary 获取失败节点的 puller 容器日志（末尾部分）
+// @Router /api/v1/tasks/:id/nodes/:node/logs [get]
+func (h *TaskHandler) GetNodeLogs(c *gin.Context) {
+	taskID := c.Param("id")
+	nodeName := c.Param("node")
+
+	logs, err := h.taskManager.GetNodeLogs(c.Request.Context(), taskID, nodeName)
 	if err != nil {
+		if err == repository.ErrTaskNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":  "Task not found",
+				"taskId": taskID,
+			})
+			return
+		}
+		if err == service.ErrNodeNotInTask {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":    "Node not found in task",
+				"taskId":   taskID,
+				"nodeName": nodeName,
+			})
+			return
+		}
+		if err == service.ErrNodeLogsNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":    "No logs captured for node",
+				"taskId":   taskID,
+				"nodeName": nodeName,
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to create task",
+			"error":   "Failed to get node logs",
 			"details": err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusCreated, task)
+	c.JSON(http.StatusOK, logs)
 }
 
-// GetTask 获取任务详情
-// @Summary 获取任务详情
-// @Router /api/v1/tasks/:id [get]
-func (h *TaskHandler) GetTask(c *gin.Context) {
+// GetTaskTimeline 获取任务的事件时间线
+// @Summary 获取任务时间线（状态变更、批次提交、节点结果与重试，按发生顺序）
+// @Router /api/v1/tasks/:id/timeline [get]
+func (h *TaskHandler) GetTaskTimeline(c *gin.Context) {
 	taskID := c.Param("id")
 
-	task, err := h.taskManager.GetTask(c.Request.Context(), taskID)
+	events, err := h.taskManager.GetTaskTimeline(c.Request.Context(), taskID)
 	if err != nil {
 		if err == repository.ErrTaskNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -97,13 +269,16 @@ func (h *TaskHandler) GetTask(c *gin.Context) {
 		}
 
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get task",
+			"error":   "Failed to get task timeline",
 			"details": err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, task)
+	c.JSON(http.StatusOK, gin.H{
+		"taskId": taskID,
+		"events": events,
+	})
 }
 
 // ListTasks 列出任务
@@ -183,6 +358,62 @@ func (h *TaskHandler) DeleteTask(c *gin.Context) {
 	})
 }
 
+// PauseTask 暂停任务
+// 执行中的任务在当前批次提交完成后停止创建新的 Job，已创建的 Job 继续执行
+// @Summary 暂停排队中或执行中的任务
+// @Router /api/v1/tasks/:id/pause [post]
+func (h *TaskHandler) PauseTask(c *gin.Context) {
+	taskID := c.Param("id")
+
+	task, err := h.taskManager.PauseTask(c.Request.Context(), taskID)
+	if err != nil {
+		h.respondTaskStateError(c, taskID, "Failed to pause task", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// ResumeTask 恢复已暂停的任务
+// 任务重新排队，从 Progress.CurrentBatch 继续提交尚未创建 Job 的节点
+// @Summary 恢复已暂停的任务
+// @Router /api/v1/tasks/:id/resume [post]
+func (h *TaskHandler) ResumeTask(c *gin.Context) {
+	taskID := c.Param("id")
+
+	task, err := h.taskManager.ResumeTask(c.Request.Context(), taskID)
+	if err != nil {
+		h.respondTaskStateError(c, taskID, "Failed to resume task", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// respondTaskStateError 返回暂停/恢复任务失败的响应，任务状态不允许该操作时返回 409
+func (h *TaskHandler) respondTaskStateError(c *gin.Context, taskID, message string, err error) {
+	if err == repository.ErrTaskNotFound {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":  "Task not found",
+			"taskId": taskID,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrTaskNotPausable) || errors.Is(err, service.ErrTaskNotPaused) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   message,
+			"details": err.Error(),
+			"taskId":  taskID,
+		})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   message,
+		"details": err.Error(),
+	})
+}
+
 // parseIntParam 解析整数参数
 func parseIntParam(value string, defaultValue int) int {
 	if value == "" {
@@ -196,3 +427,15 @@ func parseIntParam(value string, defaultValue int) int {
 
 	return intValue
 }
+
+// currentUsername 获取当前登录用户名，未登录时返回空字符串
+func currentUsername(c *gin.Context) string {
+	val, exists := c.Get("user")
+	if !exists {
+		return ""
+	}
+	if user, ok := val.(*models.User); ok {
+		return user.Username
+	}
+	return ""
+}
@@ -2,6 +2,8 @@ package handler
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -10,7 +12,10 @@ import (
 	"github.com/kitsnail/ips/internal/k8s"
 	"github.com/kitsnail/ips/internal/repository"
 	"github.com/kitsnail/ips/internal/service"
+	"github.com/kitsnail/ips/pkg/models"
 	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
@@ -42,7 +47,7 @@ func setupTestHandler() (*TaskHandler, *gin.Engine) {
 	nodeFilter := service.NewNodeFilter(k8sClient)
 	batchScheduler := service.NewBatchScheduler(jobCreator, logger)
 	statusTracker := service.NewStatusTracker(repository.NewMemoryRepository(), jobCreator, logger)
-	taskManager := service.NewTaskManager(repository.NewMemoryRepository(), repository.NewMemoryRepository(), nodeFilter, batchScheduler, statusTracker, logger)
+	taskManager := service.NewTaskManager(repository.NewMemoryRepository(), repository.NewMemoryRepository(), repository.NewMemoryRepository(), repository.NewMemoryRepository(), nodeFilter, batchScheduler, statusTracker, logger)
 
 	handler := NewTaskHandler(taskManager)
 	gin.SetMode(gin.TestMode)
@@ -98,3 +103,214 @@ func TestTaskHandler_CreateTask_PrivateRegistry_MissingFields(t *testing.T) {
 		}
 	}
 }
+
+func TestTaskHandler_CreateTask_InvalidNodeSelection(t *testing.T) {
+	handler, router := setupTestHandler()
+	router.POST("/api/v1/tasks", handler.CreateTask)
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		wantStatus int
+	}{
+		{
+			name:       "In 表达式缺少 values",
+			reqBody:    `{"images":["nginx:latest"],"batchSize":10,"nodeSelection":{"matchExpressions":[{"key":"zone","operator":"In"}]}}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "不支持的污点效果",
+			reqBody:    `{"images":["nginx:latest"],"batchSize":10,"nodeSelection":{"excludeTaints":[{"key":"gpu","effect":"Never"}]}}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "有效的筛选条件",
+			reqBody:    `{"images":["nginx:latest"],"batchSize":10,"nodeSelection":{"matchExpressions":[{"key":"zone","operator":"Exists"}],"excludeNodes":["node-2"]}}`,
+			wantStatus: http.StatusCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/api/v1/tasks", bytes.NewBufferString(tt.reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("Test %s: Expected status %d, got %d", tt.name, tt.wantStatus, w.Code)
+		}
+	}
+}
+
+func TestTaskHandler_CreateAndPlanTask_ValidationErrors(t *testing.T) {
+	handler, router := setupTestHandler()
+	router.POST("/api/v1/tasks", handler.CreateTask)
+	router.POST("/api/v1/tasks/plan", handler.PlanTask)
+
+	tests := []struct {
+		name      string
+		reqBody   string
+		wantError string
+	}{
+		{"无效镜像", `{"images":["NGINX:latest"],"batchSize":10}`, "Invalid image"},
+		{"无效节点筛选", `{"images":["nginx:latest"],"batchSize":10,"nodeSelection":{"matchExpressions":[{"key":"zone","operator":"In"}]}}`, "Invalid node selection"},
+		{"无效灰度策略", `{"images":["nginx:latest"],"batchSize":10,"rollout":{"canaryNodes":-1}}`, "Invalid rollout strategy"},
+	}
+
+	// 创建与预览任务返回相同的校验错误
+	for _, path := range []string{"/api/v1/tasks", "/api/v1/tasks/plan"} {
+		for _, tt := range tests {
+			req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(tt.reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusBadRequest, w.Code, "%s %s: %s", path, tt.name, w.Body.String())
+			var resp map[string]string
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.wantError, resp["error"], "%s %s", path, tt.name)
+		}
+	}
+}
+
+func TestTaskHandler_CreateTask_WorkloadSource(t *testing.T) {
+	handler, router := setupTestHandler()
+	router.POST("/api/v1/tasks", handler.CreateTask)
+
+	tests := []struct {
+		name       string
+		reqBody    string
+		wantStatus int
+	}{
+		{
+			name:       "未指定镜像与工作负载",
+			reqBody:    `{"batchSize":10}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "镜像列表为空",
+			reqBody:    `{"images":[],"batchSize":10}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "不支持的工作负载类型",
+			reqBody:    `{"batchSize":10,"workloads":{"workloads":[{"kind":"Job","namespace":"ml","name":"train"}]}}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "工作负载不存在",
+			reqBody:    `{"batchSize":10,"workloads":{"workloads":[{"kind":"Deployment","namespace":"ml","name":"train"}]}}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/api/v1/tasks", bytes.NewBufferString(tt.reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("Test %s: Expected status %d, got %d", tt.name, tt.wantStatus, w.Code)
+		}
+	}
+}
+
+func TestTaskHandler_StreamTaskEvents(t *testing.T) {
+	handler, router := setupTestHandler()
+	router.GET("/api/v1/tasks/:id/events", handler.StreamTaskEvents)
+
+	ctx := context.Background()
+	task, err := handler.taskManager.CreateTask(ctx, &models.CreateTaskRequest{Images: []string{"nginx:latest"}, BatchSize: 1})
+	require.NoError(t, err)
+	_, err = handler.taskManager.DeleteTask(ctx, task.ID)
+	require.NoError(t, err)
+
+	// 已结束的任务：推送快照与历史事件后关闭连接
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/tasks/"+task.ID+"/events", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	assert.Contains(t, body, "event: snapshot\n")
+	assert.Contains(t, body, "id: 1\nevent: status\ndata: {\"id\":1,\"taskId\":\""+task.ID+"\",\"type\":\"status\"")
+	assert.Contains(t, body, `"status":"cancelled"`)
+
+	// 携带 Last-Event-ID 时只回放其后的事件
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks/"+task.ID+"/events", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.NotContains(t, w.Body.String(), "id: 1\n")
+	assert.Contains(t, w.Body.String(), "id: 2\n")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/tasks/missing/events", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestTaskHandler_GetTaskTimeline(t *testing.T) {
+	handler, router := setupTestHandler()
+	router.GET("/api/v1/tasks/:id/timeline", handler.GetTaskTimeline)
+
+	ctx := context.Background()
+	task, err := handler.taskManager.CreateTask(ctx, &models.CreateTaskRequest{Images: []string{"nginx:latest"}, BatchSize: 1})
+	require.NoError(t, err)
+	_, err = handler.taskManager.DeleteTask(ctx, task.ID)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/tasks/"+task.ID+"/timeline", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Events []models.TaskEvent `json:"events"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Events, 2)
+	assert.Equal(t, models.TaskPending, resp.Events[0].Status)
+	assert.Equal(t, models.TaskCancelled, resp.Events[1].Status)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/tasks/missing/timeline", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestTaskHandler_PauseResumeTask_Conflict(t *testing.T) {
+	handler, router := setupTestHandler()
+	router.POST("/api/v1/tasks", handler.CreateTask)
+	router.POST("/api/v1/tasks/:id/pause", handler.PauseTask)
+	router.POST("/api/v1/tasks/:id/resume", handler.ResumeTask)
+
+	req := httptest.NewRequest("POST", "/api/v1/tasks", bytes.NewBufferString(`{"images":["nginx:latest"],"batchSize":10}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var task models.Task
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &task))
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{name: "恢复未暂停的任务", path: "/api/v1/tasks/" + task.ID + "/resume", wantStatus: http.StatusConflict},
+		{name: "暂停排队中的任务", path: "/api/v1/tasks/" + task.ID + "/pause", wantStatus: http.StatusOK},
+		{name: "重复暂停", path: "/api/v1/tasks/" + task.ID + "/pause", wantStatus: http.StatusConflict},
+		{name: "恢复已暂停的任务", path: "/api/v1/tasks/" + task.ID + "/resume", wantStatus: http.StatusOK},
+		{name: "任务不存在", path: "/api/v1/tasks/unknown/pause", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", tt.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("Test %s: Expected status %d, got %d", tt.name, tt.wantStatus, w.Code)
+		}
+	}
+}
@@ -31,17 +31,36 @@ func AuthMiddleware(authService *service.AuthService) gin.HandlerFunc {
 			return
 		}
 
-		user, err := authService.ValidateToken(c.Request.Context(), parts[1])
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": fmt.Sprintf("Invalid token: %v", err)})
-			c.Abort()
+		authenticate(c, authService, parts[1])
+	}
+}
+
+// StreamAuthMiddleware 事件流（SSE）认证中间件
+// 浏览器的 EventSource 无法设置请求头，未携带 Authorization 时从 access_token 查询参数读取令牌
+func StreamAuthMiddleware(authService *service.AuthService) gin.HandlerFunc {
+	headerAuth := AuthMiddleware(authService)
+	return func(c *gin.Context) {
+		token := c.Query("access_token")
+		if c.GetHeader("Authorization") != "" || token == "" {
+			headerAuth(c)
 			return
 		}
+		authenticate(c, authService, token)
+	}
+}
 
-		// 将用户信息存入上下文
-		c.Set(ContextUserKey, user)
-		c.Next()
+// authenticate 校验令牌并将用户信息存入上下文
+func authenticate(c *gin.Context, authService *service.AuthService, token string) {
+	user, err := authService.ValidateToken(c.Request.Context(), token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": fmt.Sprintf("Invalid token: %v", err)})
+		c.Abort()
+		return
 	}
+
+	// 将用户信息存入上下文
+	c.Set(ContextUserKey, user)
+	c.Next()
 }
 
 // RBACMiddleware 角色权限控制中间件
@@ -12,7 +12,7 @@ import (
 )
 
 // SetupRouter 设置路由
-func SetupRouter(logger *logrus.Logger, taskManager *service.TaskManager, scheduledTaskManager *service.ScheduledTaskManager, authService *service.AuthService, userRepo repository.UserRepository, libraryRepo repository.LibraryRepository, secretRepo repository.SecretRegistryRepository, k8sClient *k8s.Client) *gin.Engine {
+func SetupRouter(logger *logrus.Logger, taskManager *service.TaskManager, scheduledTaskManager *service.ScheduledTaskManager, imagePolicyController *service.ImagePolicyController, nodeWarmupController *service.NodeWarmupController, authService *service.AuthService, userRepo repository.UserRepository, libraryRepo repository.LibraryRepository, secretRepo repository.SecretRegistryRepository, k8sClient *k8s.Client) *gin.Engine {
 	gin.SetMode(gin.ReleaseMode)
 
 	router := gin.New()
@@ -50,7 +50,7 @@ func SetupRouter(logger *logrus.Logger, taskManager *service.TaskManager, schedu
 	healthHandler := handler.NewHealthHandler()
 
 	// 统计数据处理器
-	statsHandler := handler.NewStatsHandler(k8sClient)
+	statsHandler := handler.NewStatsHandler(k8sClient, taskManager)
 
 	// 健康检查端点（不需要认证）
 	router.GET("/health", healthHandler.HealthCheck)
@@ -70,18 +70,35 @@ func SetupRouter(logger *logrus.Logger, taskManager *service.TaskManager, schedu
 	libraryHandler := handler.NewLibraryHandler(libraryRepo)
 	secretHandler := handler.NewSecretHandler(secretRepo)
 	scheduledTaskHandler := handler.NewScheduledTaskHandler(scheduledTaskManager)
+	concurrencyHandler := handler.NewConcurrencyHandler(taskManager)
+	imagePolicyHandler := handler.NewImagePolicyHandler(imagePolicyController)
+	nodeWarmupHandler := handler.NewNodeWarmupHandler(nodeWarmupController)
 
 	// 登录接口 (公开)
 	router.POST("/api/v1/login", authHandler.Login)
 
+	// 事件流 (受保护，支持通过 access_token 查询参数认证)
+	streams := router.Group("/api/v1")
+	streams.Use(middleware.StreamAuthMiddleware(authService))
+	{
+		streams.GET("/tasks/:id/events", taskHandler.StreamTaskEvents)
+		streams.GET("/events", taskHandler.StreamEvents)
+	}
+
 	// API v1 路由组 (受保护)
 	v1 := router.Group("/api/v1")
 	v1.Use(middleware.AuthMiddleware(authService))
 	{
 		v1.POST("/tasks", taskHandler.CreateTask)
+		v1.POST("/tasks/plan", taskHandler.PlanTask)
 		v1.GET("/tasks", taskHandler.ListTasks)
 		v1.GET("/tasks/:id", taskHandler.GetTask)
+		v1.GET("/tasks/:id/nodes/:node", taskHandler.GetNodeResult)
+		v1.GET("/tasks/:id/nodes/:node/logs", taskHandler.GetNodeLogs)
+		v1.GET("/tasks/:id/timeline", taskHandler.GetTaskTimeline)
 		v1.DELETE("/tasks/:id", taskHandler.DeleteTask)
+		v1.POST("/tasks/:id/pause", taskHandler.PauseTask)
+		v1.POST("/tasks/:id/resume", taskHandler.ResumeTask)
 
 		// 镜像库
 		v1.GET("/library", libraryHandler.ListImages)
@@ -123,7 +140,27 @@ func SetupRouter(logger *logrus.Logger, taskManager *service.TaskManager, schedu
 			scheduledTasks.GET("/:id/executions/:executionId", scheduledTaskHandler.GetExecution)
 		}
 
-		return router
+		// 镜像常驻策略 (仅限管理员)
+		imagePolicies := v1.Group("/image-policies")
+		imagePolicies.Use(middleware.AdminOnly())
+		{
+			imagePolicies.POST("", imagePolicyHandler.CreatePolicy)
+			imagePolicies.GET("", imagePolicyHandler.ListPolicies)
+			imagePolicies.GET("/:id", imagePolicyHandler.GetPolicy)
+			imagePolicies.PUT("/:id", imagePolicyHandler.UpdatePolicy)
+			imagePolicies.DELETE("/:id", imagePolicyHandler.DeletePolicy)
+			imagePolicies.GET("/:id/compliance", imagePolicyHandler.GetCompliance)
+		}
+
+		// 系统管理 (仅限管理员)
+		admin := v1.Group("/admin")
+		admin.Use(middleware.AdminOnly())
+		{
+			admin.GET("/concurrency", concurrencyHandler.GetLimits)
+			admin.PUT("/concurrency", concurrencyHandler.UpdateLimits)
+			admin.GET("/node-warmup", nodeWarmupHandler.GetConfig)
+			admin.PUT("/node-warmup", nodeWarmupHandler.UpdateConfig)
+		}
 	}
 
 	return router
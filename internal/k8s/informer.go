@@ -0,0 +1,202 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// PrewarmLabelSelector 预热 Job 及其 Pod 的公共标签
+	PrewarmLabelSelector = "app=image-prewarm"
+
+	taskIDIndex  = "task-id"
+	jobNameIndex = "job-name"
+)
+
+// PrewarmInformer 预热 Job 与 Pod 的共享 Informer
+// 所有任务的状态跟踪共用同一份缓存，按任务分发变更通知，避免每个任务单独 Watch/List
+type PrewarmInformer struct {
+	namespace   string
+	factory     informers.SharedInformerFactory
+	jobInformer cache.SharedIndexInformer
+	podInformer cache.SharedIndexInformer
+
+	mu          sync.Mutex
+	subscribers map[string]map[chan struct{}]struct{} // taskID -> 通知通道
+}
+
+// NewPrewarmInformer 创建共享 Informer，只缓存带有 app=image-prewarm 标签的 Job 与 Pod
+func (c *Client) NewPrewarmInformer(resync time.Duration) (*PrewarmInformer, error) {
+	factory := informers.NewSharedInformerFactoryWithOptions(c.Clientset, resync,
+		informers.WithNamespace(c.Namespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.LabelSelector = PrewarmLabelSelector
+		}),
+	)
+
+	i := &PrewarmInformer{
+		namespace:   c.Namespace,
+		factory:     factory,
+		jobInformer: factory.Batch().V1().Jobs().Informer(),
+		podInformer: factory.Core().V1().Pods().Informer(),
+		subscribers: make(map[string]map[chan struct{}]struct{}),
+	}
+
+	if err := i.jobInformer.AddIndexers(cache.Indexers{taskIDIndex: labelIndexFunc("task-id")}); err != nil {
+		return nil, fmt.Errorf("failed to add job indexer: %w", err)
+	}
+	if err := i.podInformer.AddIndexers(cache.Indexers{jobNameIndex: labelIndexFunc("job-name")}); err != nil {
+		return nil, fmt.Errorf("failed to add pod indexer: %w", err)
+	}
+
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    i.notify,
+		UpdateFunc: func(_, obj interface{}) { i.notify(obj) },
+		DeleteFunc: i.notify,
+	}
+	if _, err := i.jobInformer.AddEventHandler(handler); err != nil {
+		return nil, fmt.Errorf("failed to add job event handler: %w", err)
+	}
+	if _, err := i.podInformer.AddEventHandler(handler); err != nil {
+		return nil, fmt.Errorf("failed to add pod event handler: %w", err)
+	}
+
+	return i, nil
+}
+
+// labelIndexFunc 按指定标签建立索引
+func labelIndexFunc(label string) cache.IndexFunc {
+	return func(obj interface{}) ([]string, error) {
+		meta, err := metaAccessor(obj)
+		if err != nil {
+			return nil, nil
+		}
+		if value, ok := meta.GetLabels()[label]; ok {
+			return []string{value}, nil
+		}
+		return nil, nil
+	}
+}
+
+// metaAccessor 获取对象元数据，兼容删除事件中的 DeletedFinalStateUnknown
+func metaAccessor(obj interface{}) (metav1.Object, error) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	meta, ok := obj.(metav1.Object)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object type %T", obj)
+	}
+	return meta, nil
+}
+
+// Start 在后台启动 Informer，ctx 结束时停止
+func (i *PrewarmInformer) Start(ctx context.Context) {
+	i.factory.Start(ctx.Done())
+}
+
+// WaitForCacheSync 等待缓存完成首次同步，ctx 结束前未同步时返回错误
+func (i *PrewarmInformer) WaitForCacheSync(ctx context.Context) error {
+	for typ, synced := range i.factory.WaitForCacheSync(ctx.Done()) {
+		if !synced {
+			return fmt.Errorf("failed to sync informer cache for %v", typ)
+		}
+	}
+	return nil
+}
+
+// HasSynced 缓存是否已完成同步
+func (i *PrewarmInformer) HasSynced() bool {
+	return i.jobInformer.HasSynced() && i.podInformer.HasSynced()
+}
+
+// ListJobsByTaskID 从缓存中列出指定任务的 Job
+func (i *PrewarmInformer) ListJobsByTaskID(taskID string) ([]batchv1.Job, error) {
+	objs, err := i.jobInformer.GetIndexer().ByIndex(taskIDIndex, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs for task %s from cache: %w", taskID, err)
+	}
+
+	jobs := make([]batchv1.Job, 0, len(objs))
+	for _, obj := range objs {
+		if job, ok := obj.(*batchv1.Job); ok {
+			jobs = append(jobs, *job.DeepCopy())
+		}
+	}
+	return jobs, nil
+}
+
+// HasJob 缓存中是否已有指定名称的 Job
+func (i *PrewarmInformer) HasJob(name string) bool {
+	_, exists, err := i.jobInformer.GetIndexer().GetByKey(i.namespace + "/" + name)
+	return err == nil && exists
+}
+
+// ListPodsByJobName 从缓存中列出指定 Job 创建的 Pod
+func (i *PrewarmInformer) ListPodsByJobName(jobName string) ([]corev1.Pod, error) {
+	objs, err := i.podInformer.GetIndexer().ByIndex(jobNameIndex, jobName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods for job %s from cache: %w", jobName, err)
+	}
+
+	pods := make([]corev1.Pod, 0, len(objs))
+	for _, obj := range objs {
+		if pod, ok := obj.(*corev1.Pod); ok {
+			pods = append(pods, *pod.DeepCopy())
+		}
+	}
+	return pods, nil
+}
+
+// Subscribe 订阅指定任务的 Job/Pod 变更通知
+// 通知只表示有变化（多次变化可能合并为一次），返回的函数用于取消订阅
+func (i *PrewarmInformer) Subscribe(taskID string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	i.mu.Lock()
+	if i.subscribers[taskID] == nil {
+		i.subscribers[taskID] = make(map[chan struct{}]struct{})
+	}
+	i.subscribers[taskID][ch] = struct{}{}
+	i.mu.Unlock()
+
+	unsubscribe := func() {
+		i.mu.Lock()
+		defer i.mu.Unlock()
+		delete(i.subscribers[taskID], ch)
+		if len(i.subscribers[taskID]) == 0 {
+			delete(i.subscribers, taskID)
+		}
+	}
+	return ch, unsubscribe
+}
+
+// notify 通知对象所属任务的订阅者
+func (i *PrewarmInformer) notify(obj interface{}) {
+	meta, err := metaAccessor(obj)
+	if err != nil {
+		return
+	}
+	taskID := meta.GetLabels()["task-id"]
+	if taskID == "" {
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for ch := range i.subscribers[taskID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+			// 已有未处理的通知，合并
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestJob(name string, labels map[string]string) *batchv1.Job {
+	return &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels}}
+}
+
+func TestPrewarmInformer(t *testing.T) {
+	client := &Client{
+		Clientset: fake.NewSimpleClientset(
+			newTestJob("prewarm-task-1-node-1", map[string]string{"app": "image-prewarm", "task-id": "task-1"}),
+			newTestJob("other", map[string]string{"app": "other", "task-id": "task-1"}),
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Name:      "prewarm-task-1-node-1-abcde",
+				Namespace: "default",
+				Labels:    map[string]string{"app": "image-prewarm", "task-id": "task-1", "job-name": "prewarm-task-1-node-1"},
+			}},
+		),
+		Namespace: "default",
+	}
+
+	informer, err := client.NewPrewarmInformer(0)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	informer.Start(ctx)
+	require.NoError(t, informer.WaitForCacheSync(ctx))
+
+	// 只缓存预热 Job
+	jobs, err := informer.ListJobsByTaskID("task-1")
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "prewarm-task-1-node-1", jobs[0].Name)
+
+	pods, err := informer.ListPodsByJobName("prewarm-task-1-node-1")
+	require.NoError(t, err)
+	assert.Len(t, pods, 1)
+
+	// 变更只通知所属任务的订阅者
+	events, unsubscribe := informer.Subscribe("task-2")
+	defer unsubscribe()
+	others, unsubscribeOthers := informer.Subscribe("task-3")
+	defer unsubscribeOthers()
+
+	_, err = client.Clientset.BatchV1().Jobs("default").Create(ctx,
+		newTestJob("prewarm-task-2-node-1", map[string]string{"app": "image-prewarm", "task-id": "task-2"}), metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	select {
+	case <-events:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected notification for task-2")
+	}
+	select {
+	case <-others:
+		t.Fatal("unexpected notification for task-3")
+	default:
+	}
+
+	jobs, err = informer.ListJobsByTaskID("task-2")
+	require.NoError(t, err)
+	assert.Len(t, jobs, 1)
+}
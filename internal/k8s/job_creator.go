@@ -5,10 +5,15 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"math"
+	"strconv"
 	"strings"
+	"time"
 
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -40,13 +45,58 @@ func NewJobCreator(client *Client, workerImage, pullerImage, criSocketPath strin
 	}
 }
 
+// JobOptions 预热 Job 的可选配置
+type JobOptions struct {
+	SecretName       string        // 包含凭据的 Secret 名称，为空表示不需要认证
+	PullParallelism  int           // 单个节点上并行拉取的镜像数，0 表示逐个拉取
+	ImagePullTimeout time.Duration // 单个镜像的拉取超时，0 表示不限制
+	ActiveDeadline   time.Duration // Job 最长运行时间（ActiveDeadlineSeconds），0 表示不限制
+	Attempt          int           // 节点的第几次尝试（从 1 开始），大于 1 时 Job 名称带尝试序号后缀
+}
+
+// maxJobNameLength Job 名称的最大长度，Job 控制器会把名称写入 Pod 的 job-name 标签，标签值不能超过 63 个字符
+const maxJobNameLength = 63
+
+// JobName 返回预热 Job 的名称
+// 首次尝试为 prewarm-<taskID>-<node>，重试为 prewarm-<taskID>-<node>-a<attempt>
+// 超过 63 个字符时截断，并以完整名称的哈希结尾保证唯一
+func JobName(taskID, nodeName string, attempt int) string {
+	name := fmt.Sprintf("prewarm-%s-%s", taskID, nodeName)
+	if attempt > 1 {
+		name = fmt.Sprintf("%s-a%d", name, attempt)
+	}
+	if len(name) <= maxJobNameLength {
+		return name
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(name))
+	suffix := fmt.Sprintf("-%08x", hash.Sum32())
+	prefix := strings.TrimRight(name[:maxJobNameLength-len(suffix)], "-.")
+	return prefix + suffix
+}
+
+// JobAttempt 返回 Job 对应的尝试序号，旧版本创建的 Job 没有 attempt 标签，视为第 1 次
+func JobAttempt(job *batchv1.Job) int {
+	if attempt, err := strconv.Atoi(job.Labels["attempt"]); err == nil && attempt > 0 {
+		return attempt
+	}
+	return 1
+}
+
+// CredsSecretName 返回任务凭据 Secret 的名称
+func CredsSecretName(taskID string) string {
+	return fmt.Sprintf("registry-creds-%s", taskID)
+}
+
 // CreateJob 创建Job来预热镜像
 // taskID: 任务ID
 // nodeName: 目标节点名称
 // images: 要预热的镜像列表
-// secretName: 可选的包含凭据的 Secret 名称，为空表示不需要认证
-func (j *JobCreator) CreateJob(ctx context.Context, taskID, nodeName string, images []string, secretName string) error {
-	jobName := fmt.Sprintf("prewarm-%s-%s", taskID, nodeName)
+// opts: 凭据、并行度等可选配置
+func (j *JobCreator) CreateJob(ctx context.Context, taskID, nodeName string, images []string, opts JobOptions) error {
+	attempt := max(opts.Attempt, 1)
+	jobName := JobName(taskID, nodeName, attempt)
 
 	// TTL设置：Job完成后15分钟自动清理
 	ttl := int32(900)
@@ -64,15 +114,28 @@ func (j *JobCreator) CreateJob(ctx context.Context, taskID, nodeName string, ima
 		},
 	}
 
+	if opts.PullParallelism > 0 {
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  "PULL_PARALLELISM",
+			Value: strconv.Itoa(opts.PullParallelism),
+		})
+	}
+	if opts.ImagePullTimeout > 0 {
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  "IMAGE_PULL_TIMEOUT_SECONDS",
+			Value: strconv.Itoa(int(opts.ImagePullTimeout.Seconds())),
+		})
+	}
+
 	// 如果有 Secret，通过 secretKeyRef 引入 REGISTRY_CREDS 环境变量
 	// 这样密码不会在 kubectl describe pod 中以明文显示
-	if secretName != "" {
+	if opts.SecretName != "" {
 		envVars = append(envVars, corev1.EnvVar{
 			Name: "REGISTRY_CREDS",
 			ValueFrom: &corev1.EnvVarSource{
 				SecretKeyRef: &corev1.SecretKeySelector{
 					LocalObjectReference: corev1.LocalObjectReference{
-						Name: secretName,
+						Name: opts.SecretName,
 					},
 					Key: "credentials",
 				},
@@ -80,6 +143,13 @@ func (j *JobCreator) CreateJob(ctx context.Context, taskID, nodeName string, ima
 		})
 	}
 
+	// 超过截止时间后由 Kubernetes 终止 Job，避免 Pod 长时间 Pending
+	var activeDeadline *int64
+	if opts.ActiveDeadline > 0 {
+		seconds := int64(math.Ceil(opts.ActiveDeadline.Seconds()))
+		activeDeadline = &seconds
+	}
+
 	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      jobName,
@@ -88,11 +158,13 @@ func (j *JobCreator) CreateJob(ctx context.Context, taskID, nodeName string, ima
 				"app":     "image-prewarm",
 				"task-id": taskID,
 				"node":    nodeName,
+				"attempt": strconv.Itoa(attempt),
 			},
 		},
 		Spec: batchv1.JobSpec{
 			TTLSecondsAfterFinished: &ttl,
 			BackoffLimit:            &backoffLimit,
+			ActiveDeadlineSeconds:   activeDeadline,
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: map[string]string{
@@ -170,15 +242,15 @@ func (j *JobCreator) DeleteJob(ctx context.Context, jobName string) error {
 	return nil
 }
 
-// CreateCredsSecret 创建用于存储 crictl 凭据的 Opaque Secret
+// CreateCredsSecret 创建用于存储镜像仓库凭据的 Opaque Secret
 // taskID: 任务ID
 // username: 镜像仓库用户名
 // password: 镜像仓库密码
 // 返回创建的 Secret 名称
 func (j *JobCreator) CreateCredsSecret(ctx context.Context, taskID, username, password string) (string, error) {
-	secretName := fmt.Sprintf("registry-creds-%s", taskID)
+	secretName := CredsSecretName(taskID)
 
-	// 凭据格式：username:password，供 crictl --creds 参数使用
+	// 凭据格式：username:password，由 puller 解析为 CRI AuthConfig
 	credentials := fmt.Sprintf("%s:%s", username, password)
 
 	secret := &corev1.Secret{
@@ -198,6 +270,10 @@ func (j *JobCreator) CreateCredsSecret(ctx context.Context, taskID, username, pa
 
 	_, err := j.client.Clientset.CoreV1().Secrets(j.client.Namespace).Create(ctx, secret, metav1.CreateOptions{})
 	if err != nil {
+		// 任务恢复时 Secret 可能已由上一个进程创建，直接复用
+		if apierrors.IsAlreadyExists(err) {
+			return secretName, nil
+		}
 		return "", fmt.Errorf("failed to create credentials secret %s: %w", secretName, err)
 	}
 
@@ -267,7 +343,7 @@ func (j *JobCreator) DeleteSecret(ctx context.Context, secretName string) error
 	})
 
 	if err != nil {
-		return fmt.Errorf("failed to delete secret %s: %v", secretName, err.Error())
+		return fmt.Errorf("failed to delete secret %s: %w", secretName, err)
 	}
 
 	return nil
@@ -0,0 +1,28 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestJobName(t *testing.T) {
+	assert.Equal(t, "prewarm-task-1-node-1", JobName("task-1", "node-1", 1))
+	assert.Equal(t, "prewarm-task-1-node-1-a2", JobName("task-1", "node-1", 2))
+
+	// 节点名较长时截断并以哈希结尾，不同尝试的名称不同
+	taskID := "task-1700000000-abcdef12"
+	nodeName := "ip-10-0-123-45.ap-southeast-1.compute.internal"
+	first := JobName(taskID, nodeName, 1)
+	retry := JobName(taskID, nodeName, 2)
+	for _, name := range []string{first, retry} {
+		assert.LessOrEqual(t, len(name), maxJobNameLength)
+		assert.True(t, strings.HasPrefix(name, "prewarm-"+taskID))
+		assert.Empty(t, validation.IsDNS1123Subdomain(name))
+		assert.Empty(t, validation.IsValidLabelValue(name))
+	}
+	assert.NotEqual(t, first, retry)
+	assert.Equal(t, first, JobName(taskID, nodeName, 1))
+}
@@ -0,0 +1,97 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// LeaderElectionConfig 选主配置
+type LeaderElectionConfig struct {
+	LeaseName     string        // Lease 对象名称
+	Identity      string        // 当前副本标识（通常为 Pod 名称）
+	LeaseDuration time.Duration // 租约有效期
+	RenewDeadline time.Duration // Leader 续约超时时间
+	RetryPeriod   time.Duration // 获取/续约重试间隔
+}
+
+// LeaderCallbacks 选主回调
+type LeaderCallbacks struct {
+	// OnStartedLeading 成为 Leader 时调用，ctx 在失去 Leader 身份时取消
+	OnStartedLeading func(ctx context.Context)
+	// OnStoppedLeading 失去 Leader 身份时调用
+	OnStoppedLeading func()
+	// OnNewLeader 观察到新的 Leader 时调用
+	OnNewLeader func(identity string)
+}
+
+// RunLeaderElection 基于 Lease 运行选主，直到 ctx 结束
+// 失去 Leader 身份后重新参与选主，当前副本降级为 Follower 继续提供服务
+func (c *Client) RunLeaderElection(ctx context.Context, cfg LeaderElectionConfig, callbacks LeaderCallbacks) error {
+	if cfg.LeaseName == "" {
+		return fmt.Errorf("lease name is required")
+	}
+	if cfg.Identity == "" {
+		return fmt.Errorf("leader election identity is required")
+	}
+	if cfg.LeaseDuration == 0 {
+		cfg.LeaseDuration = 15 * time.Second
+	}
+	if cfg.RenewDeadline == 0 {
+		cfg.RenewDeadline = 10 * time.Second
+	}
+	if cfg.RetryPeriod == 0 {
+		cfg.RetryPeriod = 2 * time.Second
+	}
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      cfg.LeaseName,
+			Namespace: c.Namespace,
+		},
+		Client: c.Clientset.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: cfg.Identity,
+		},
+	}
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   cfg.LeaseDuration,
+		RenewDeadline:   cfg.RenewDeadline,
+		RetryPeriod:     cfg.RetryPeriod,
+		ReleaseOnCancel: true,
+		Name:            cfg.LeaseName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				if callbacks.OnStartedLeading != nil {
+					callbacks.OnStartedLeading(ctx)
+				}
+			},
+			OnStoppedLeading: func() {
+				if callbacks.OnStoppedLeading != nil {
+					callbacks.OnStoppedLeading()
+				}
+			},
+			OnNewLeader: func(identity string) {
+				if callbacks.OnNewLeader != nil {
+					callbacks.OnNewLeader(identity)
+				}
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create leader elector: %w", err)
+	}
+
+	// Run 在失去 Leader 身份或 ctx 结束时返回，未结束时重新参与选主
+	for ctx.Err() == nil {
+		elector.Run(ctx)
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/distribution/reference"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -20,12 +21,9 @@ func (c *Client) GetNodes(ctx context.Context) ([]corev1.Node, error) {
 }
 
 // GetNodesBySelector 根据标签选择器获取节点
-func (c *Client) GetNodesBySelector(ctx context.Context, selector map[string]string) ([]corev1.Node, error) {
-	// 构建标签选择器
-	labelSelector := labels.SelectorFromSet(selector)
-
+func (c *Client) GetNodesBySelector(ctx context.Context, selector labels.Selector) ([]corev1.Node, error) {
 	nodeList, err := c.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{
-		LabelSelector: labelSelector.String(),
+		LabelSelector: selector.String(),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list nodes by selector: %w", err)
@@ -62,3 +60,77 @@ func FilterReadyNodes(nodes []corev1.Node) []string {
 
 	return readyNodes
 }
+
+// NodeHasTaint 检查节点是否带有匹配的污点，value 与 effect 为空时匹配任意值
+func NodeHasTaint(node *corev1.Node, key, value, effect string) bool {
+	for _, taint := range node.Spec.Taints {
+		if taint.Key != key {
+			continue
+		}
+		if value != "" && taint.Value != value {
+			continue
+		}
+		if effect != "" && string(taint.Effect) != effect {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// MaxReportedNodeImages kubelet 在 Node.Status.Images 中最多上报的镜像数（--node-status-max-images 的默认值）
+const MaxReportedNodeImages = 50
+
+// NodeImagesTruncated 节点上报的镜像列表是否可能被截断，此时未上报的镜像无法确认是否存在
+func NodeImagesTruncated(node *corev1.Node) bool {
+	return len(node.Status.Images) >= MaxReportedNodeImages
+}
+
+// NodeHasImages 检查节点上是否已存在全部镜像（根据 Node.Status.Images 判断）
+// kubelet 上报的镜像列表可能被截断（见 NodeImagesTruncated），未上报的镜像视为不存在
+func NodeHasImages(node *corev1.Node, images []string) bool {
+	present := make(map[string]bool)
+	for _, img := range node.Status.Images {
+		for _, name := range img.Names {
+			present[NormalizeImageRef(name)] = true
+		}
+	}
+
+	for _, image := range images {
+		if !present[NormalizeImageRef(image)] {
+			return false
+		}
+	}
+	return true
+}
+
+// ImageRefsPinned 镜像引用是否都固定为 digest
+// tag 可能已指向新的镜像，节点上存在同名 tag 不代表已是最新内容，不能据此跳过拉取
+func ImageRefsPinned(refs []string) bool {
+	for _, ref := range refs {
+		named, err := reference.ParseNormalizedNamed(ref)
+		if err != nil {
+			return false
+		}
+		if _, ok := named.(reference.Digested); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeImageRef 将镜像引用规范化为完整形式，便于与 kubelet 上报的镜像名比较
+// 例如 nginx -> docker.io/library/nginx:latest，team/app:v1 -> docker.io/team/app:v1
+// 同时带有 tag 与 digest 时只保留 digest（kubelet 分别上报 tag 与 digest 形式），无法解析时原样返回
+func NormalizeImageRef(ref string) string {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return ref
+	}
+	if canonical, ok := named.(reference.Canonical); ok {
+		if digested, err := reference.WithDigest(reference.TrimNamed(named), canonical.Digest()); err == nil {
+			return digested.String()
+		}
+	}
+	return reference.TagNameOnly(named).String()
+}
@@ -0,0 +1,89 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// NodeInformer 节点的共享 Informer，供需要感知节点新增与状态变化的组件共用
+type NodeInformer struct {
+	factory  informers.SharedInformerFactory
+	informer cache.SharedIndexInformer
+}
+
+// NodeEventHandler 节点变更回调，新增节点时 old 为 nil，删除节点时 node 为 nil
+type NodeEventHandler func(old, node *corev1.Node)
+
+// NewNodeInformer 创建节点的共享 Informer
+func (c *Client) NewNodeInformer(resync time.Duration) *NodeInformer {
+	factory := informers.NewSharedInformerFactory(c.Clientset, resync)
+	return &NodeInformer{
+		factory:  factory,
+		informer: factory.Core().V1().Nodes().Informer(),
+	}
+}
+
+// AddEventHandler 注册节点变更回调，需在 Start 之前调用
+func (i *NodeInformer) AddEventHandler(handler NodeEventHandler) error {
+	_, err := i.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if node, ok := obj.(*corev1.Node); ok {
+				handler(nil, node)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			old, ok1 := oldObj.(*corev1.Node)
+			node, ok2 := newObj.(*corev1.Node)
+			if ok1 && ok2 {
+				handler(old, node)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if node, ok := obj.(*corev1.Node); ok {
+				handler(node, nil)
+			}
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add node event handler: %w", err)
+	}
+	return nil
+}
+
+// Start 在后台启动 Informer，ctx 结束时停止
+func (i *NodeInformer) Start(ctx context.Context) {
+	i.factory.Start(ctx.Done())
+}
+
+// WaitForCacheSync 等待缓存完成首次同步，ctx 结束前未同步时返回错误
+func (i *NodeInformer) WaitForCacheSync(ctx context.Context) error {
+	if !cache.WaitForCacheSync(ctx.Done(), i.informer.HasSynced) {
+		return fmt.Errorf("failed to sync node informer cache")
+	}
+	return nil
+}
+
+// HasSynced 缓存是否已完成同步
+func (i *NodeInformer) HasSynced() bool {
+	return i.informer.HasSynced()
+}
+
+// ListNodes 从缓存中列出所有节点
+func (i *NodeInformer) ListNodes() []corev1.Node {
+	objs := i.informer.GetStore().List()
+	nodes := make([]corev1.Node, 0, len(objs))
+	for _, obj := range objs {
+		if node, ok := obj.(*corev1.Node); ok {
+			nodes = append(nodes, *node.DeepCopy())
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,42 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestNormalizeImageRef(t *testing.T) {
+	digest := "sha256:" + "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"nginx", "docker.io/library/nginx:latest"},
+		{"nginx:1.25", "docker.io/library/nginx:1.25"},
+		{"team/app:v1", "docker.io/team/app:v1"},
+		{"docker.io/library/nginx:latest", "docker.io/library/nginx:latest"},
+		{"index.docker.io/library/nginx", "docker.io/library/nginx:latest"},
+		{"localhost:5000/app", "localhost:5000/app:latest"},
+		{"registry.example.com:5000/team/app:v2", "registry.example.com:5000/team/app:v2"},
+		{"nginx@" + digest, "docker.io/library/nginx@" + digest},
+		{"nginx:1.25@" + digest, "docker.io/library/nginx@" + digest},
+		{"Invalid Image", "Invalid Image"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, NormalizeImageRef(tt.ref), tt.ref)
+	}
+}
+
+func TestNodeHasImages(t *testing.T) {
+	node := &corev1.Node{Status: corev1.NodeStatus{Images: []corev1.ContainerImage{
+		{Names: []string{"docker.io/library/nginx:1.25", "docker.io/library/nginx@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"}},
+		{Names: []string{"registry.example.com/team/app:v1"}},
+	}}}
+
+	assert.True(t, NodeHasImages(node, []string{"nginx:1.25", "registry.example.com/team/app:v1"}))
+	assert.False(t, NodeHasImages(node, []string{"nginx:1.25", "redis:7"}))
+	assert.False(t, NodeHasImages(node, []string{"nginx"}))
+}
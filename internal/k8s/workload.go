@@ -0,0 +1,183 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// 支持的工作负载类型
+const (
+	KindDeployment  = "Deployment"
+	KindStatefulSet = "StatefulSet"
+	KindDaemonSet   = "DaemonSet"
+)
+
+// Workload 工作负载及其 Pod 模板
+type Workload struct {
+	Kind      string
+	Namespace string
+	Name      string
+	PodSpec   corev1.PodSpec
+}
+
+// GetWorkload 获取指定的工作负载
+func (c *Client) GetWorkload(ctx context.Context, kind, namespace, name string) (*Workload, error) {
+	apps := c.Clientset.AppsV1()
+	var spec corev1.PodSpec
+	switch kind {
+	case KindDeployment:
+		obj, err := apps.Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get deployment %s/%s: %w", namespace, name, err)
+		}
+		spec = obj.Spec.Template.Spec
+	case KindStatefulSet:
+		obj, err := apps.StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get statefulset %s/%s: %w", namespace, name, err)
+		}
+		spec = obj.Spec.Template.Spec
+	case KindDaemonSet:
+		obj, err := apps.DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get daemonset %s/%s: %w", namespace, name, err)
+		}
+		spec = obj.Spec.Template.Spec
+	default:
+		return nil, fmt.Errorf("unsupported workload kind %q", kind)
+	}
+
+	return &Workload{Kind: kind, Namespace: namespace, Name: name, PodSpec: spec}, nil
+}
+
+// ListWorkloads 列出命名空间中匹配标签选择器的指定类型工作负载
+func (c *Client) ListWorkloads(ctx context.Context, kind, namespace string, selector labels.Selector) ([]Workload, error) {
+	apps := c.Clientset.AppsV1()
+	opts := metav1.ListOptions{LabelSelector: selector.String()}
+	var workloads []Workload
+	switch kind {
+	case KindDeployment:
+		list, err := apps.Deployments(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list deployments: %w", err)
+		}
+		for _, obj := range list.Items {
+			workloads = append(workloads, Workload{Kind: kind, Namespace: obj.Namespace, Name: obj.Name, PodSpec: obj.Spec.Template.Spec})
+		}
+	case KindStatefulSet:
+		list, err := apps.StatefulSets(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+		}
+		for _, obj := range list.Items {
+			workloads = append(workloads, Workload{Kind: kind, Namespace: obj.Namespace, Name: obj.Name, PodSpec: obj.Spec.Template.Spec})
+		}
+	case KindDaemonSet:
+		list, err := apps.DaemonSets(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list daemonsets: %w", err)
+		}
+		for _, obj := range list.Items {
+			workloads = append(workloads, Workload{Kind: kind, Namespace: obj.Namespace, Name: obj.Name, PodSpec: obj.Spec.Template.Spec})
+		}
+	default:
+		return nil, fmt.Errorf("unsupported workload kind %q", kind)
+	}
+	return workloads, nil
+}
+
+// PodImages 返回 Pod 模板中 initContainers 与 containers 使用的镜像（去重，保持顺序）
+func PodImages(spec *corev1.PodSpec) []string {
+	var images []string
+	seen := make(map[string]bool)
+	for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+		for _, container := range containers {
+			if container.Image != "" && !seen[container.Image] {
+				seen[container.Image] = true
+				images = append(images, container.Image)
+			}
+		}
+	}
+	return images
+}
+
+// PodFitsNode 检查 Pod 模板的 nodeSelector、必需的节点亲和性与容忍度是否允许调度到节点
+// 不考虑资源余量与 Pod 间亲和性
+func PodFitsNode(spec *corev1.PodSpec, node *corev1.Node) bool {
+	if !labels.SelectorFromSet(spec.NodeSelector).Matches(labels.Set(node.Labels)) {
+		return false
+	}
+
+	if affinity := spec.Affinity; affinity != nil && affinity.NodeAffinity != nil {
+		if required := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution; required != nil {
+			if !matchNodeSelectorTerms(required.NodeSelectorTerms, node) {
+				return false
+			}
+		}
+	}
+
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		if !toleratesTaint(spec.Tolerations, taint) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchNodeSelectorTerms 节点满足任一条件项即匹配，条件项内的表达式需全部满足
+func matchNodeSelectorTerms(terms []corev1.NodeSelectorTerm, node *corev1.Node) bool {
+	for _, term := range terms {
+		if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+			continue
+		}
+		if matchRequirements(term.MatchExpressions, labels.Set(node.Labels)) &&
+			matchRequirements(term.MatchFields, labels.Set{"metadata.name": node.Name}) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRequirements 节点选择表达式是否全部满足，无法解析的表达式视为不满足
+func matchRequirements(requirements []corev1.NodeSelectorRequirement, set labels.Set) bool {
+	for _, r := range requirements {
+		op, ok := nodeSelectorOperators[r.Operator]
+		if !ok {
+			return false
+		}
+		req, err := labels.NewRequirement(r.Key, op, r.Values)
+		if err != nil || !req.Matches(set) {
+			return false
+		}
+	}
+	return true
+}
+
+// nodeSelectorOperators 节点亲和性运算符 -> 标签选择器运算符
+var nodeSelectorOperators = map[corev1.NodeSelectorOperator]selection.Operator{
+	corev1.NodeSelectorOpIn:           selection.In,
+	corev1.NodeSelectorOpNotIn:        selection.NotIn,
+	corev1.NodeSelectorOpExists:       selection.Exists,
+	corev1.NodeSelectorOpDoesNotExist: selection.DoesNotExist,
+	corev1.NodeSelectorOpGt:           selection.GreaterThan,
+	corev1.NodeSelectorOpLt:           selection.LessThan,
+}
+
+// toleratesTaint 是否有容忍度匹配污点
+func toleratesTaint(tolerations []corev1.Toleration, taint *corev1.Taint) bool {
+	for i := range tolerations {
+		if tolerations[i].ToleratesTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodImages(t *testing.T) {
+	spec := &corev1.PodSpec{
+		InitContainers: []corev1.Container{{Image: "busybox:1.36"}},
+		Containers:     []corev1.Container{{Image: "nginx:1.25"}, {Image: "busybox:1.36"}, {Image: "envoy:v1.29"}},
+	}
+	assert.Equal(t, []string{"busybox:1.36", "nginx:1.25", "envoy:v1.29"}, PodImages(spec))
+}
+
+func TestPodFitsNode(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpu-1", Labels: map[string]string{"pool": "gpu", "zone": "a", "gpus": "8"}},
+		Spec: corev1.NodeSpec{Taints: []corev1.Taint{
+			{Key: "nvidia.com/gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule},
+			{Key: "spot", Effect: corev1.TaintEffectPreferNoSchedule},
+		}},
+	}
+	gpuToleration := []corev1.Toleration{{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists}}
+	affinity := func(terms ...corev1.NodeSelectorTerm) *corev1.Affinity {
+		return &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: terms},
+		}}
+	}
+
+	tests := []struct {
+		name string
+		spec corev1.PodSpec
+		want bool
+	}{
+		{"untolerated taint", corev1.PodSpec{}, false},
+		{"tolerated taint", corev1.PodSpec{Tolerations: gpuToleration}, true},
+		{"nodeSelector mismatch", corev1.PodSpec{Tolerations: gpuToleration, NodeSelector: map[string]string{"pool": "cpu"}}, false},
+		{"affinity matches second term", corev1.PodSpec{Tolerations: gpuToleration, Affinity: affinity(
+			corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"b"}}}},
+			corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "gpus", Operator: corev1.NodeSelectorOpGt, Values: []string{"4"}}}},
+		)}, true},
+		{"affinity matchFields", corev1.PodSpec{Tolerations: gpuToleration, Affinity: affinity(
+			corev1.NodeSelectorTerm{MatchFields: []corev1.NodeSelectorRequirement{{Key: "metadata.name", Operator: corev1.NodeSelectorOpNotIn, Values: []string{"gpu-1"}}}},
+		)}, false},
+	}
+	for _, tt := range tests {
+		if got := PodFitsNode(&tt.spec, node); got != tt.want {
+			t.Errorf("%s: PodFitsNode() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package puller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/distribution/reference"
+	"github.com/kitsnail/ips/pkg/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// maxCRIMessageSize CRI 响应的最大长度（与 kubelet 保持一致）
+const maxCRIMessageSize = 16 * 1024 * 1024
+
+// Dial 通过 unix socket 连接容器运行时的 CRI 服务
+func Dial(ctx context.Context, socketPath string) (*grpc.ClientConn, error) {
+	socketPath = strings.TrimPrefix(socketPath, "unix://")
+
+	conn, err := grpc.DialContext(ctx, socketPath,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", addr)
+		}),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxCRIMessageSize)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to CRI socket %s: %w", socketPath, err)
+	}
+	return conn, nil
+}
+
+// ImagePuller 通过 CRI ImageService 拉取镜像
+type ImagePuller struct {
+	client runtimeapi.ImageServiceClient
+	auth   *runtimeapi.AuthConfig
+}
+
+// NewImagePuller 创建镜像拉取器，auth 为空表示匿名拉取
+func NewImagePuller(client runtimeapi.ImageServiceClient, auth *runtimeapi.AuthConfig) *ImagePuller {
+	return &ImagePuller{
+		client: client,
+		auth:   auth,
+	}
+}
+
+// ParseCredentials 解析 username:password 格式的凭据，为空时返回 nil
+func ParseCredentials(creds string) *runtimeapi.AuthConfig {
+	if creds == "" {
+		return nil
+	}
+	username, password, _ := strings.Cut(creds, ":")
+	return &runtimeapi.AuthConfig{
+		Username: username,
+		Password: password,
+	}
+}
+
+// Pull 拉取单个镜像并记录耗时、digest 和大小
+// 固定为 digest 的镜像已存在于节点上时不重新拉取，记录为 already_present；
+// tag 引用的镜像可能已更新，始终向仓库拉取（内容未变化时运行时不会重新下载镜像层）
+func (p *ImagePuller) Pull(ctx context.Context, image string) models.ImagePullResult {
+	result := models.ImagePullResult{Image: image}
+
+	// 只对固定为 digest 的镜像做预检查，预检查失败时不影响拉取，直接继续
+	if digestPinned(image) {
+		if img, err := p.status(ctx, image); err == nil && img != nil {
+			result.Status = models.ImagePullAlreadyPresent
+			result.SizeBytes = img.Size_
+			result.Digest = repoDigest(img)
+			if result.Digest == "" {
+				result.Digest = img.Id
+			}
+			return result
+		}
+	}
+
+	start := time.Now()
+	resp, err := p.client.PullImage(ctx, &runtimeapi.PullImageRequest{
+		Image: &runtimeapi.ImageSpec{Image: image},
+		Auth:  p.auth,
+	})
+	result.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Status = models.ImagePullFailed
+		if ctx.Err() == context.DeadlineExceeded || status.Code(err) == codes.DeadlineExceeded {
+			result.Status = models.ImagePullTimeout
+		}
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Status = models.ImagePullSucceeded
+	result.Digest = resp.ImageRef
+
+	// 查询镜像详情，补充 repo digest 与大小
+	if img, err := p.status(ctx, image); err == nil && img != nil {
+		result.SizeBytes = img.Size_
+		if digest := repoDigest(img); digest != "" {
+			result.Digest = digest
+		}
+	}
+
+	return result
+}
+
+// digestPinned 镜像引用是否固定为 digest
+func digestPinned(image string) bool {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return false
+	}
+	_, ok := named.(reference.Digested)
+	return ok
+}
+
+// status 查询镜像状态，镜像不存在时返回 nil
+func (p *ImagePuller) status(ctx context.Context, image string) (*runtimeapi.Image, error) {
+	resp, err := p.client.ImageStatus(ctx, &runtimeapi.ImageStatusRequest{
+		Image: &runtimeapi.ImageSpec{Image: image},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Image, nil
+}
+
+// repoDigest 返回镜像的 digest（去掉仓库前缀）
+func repoDigest(img *runtimeapi.Image) string {
+	if len(img.RepoDigests) == 0 {
+		return ""
+	}
+	digest := img.RepoDigests[0]
+	if i := strings.LastIndex(digest, "@"); i >= 0 {
+		digest = digest[i+1:]
+	}
+	return digest
+}
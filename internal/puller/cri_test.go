@@ -0,0 +1,245 @@
+package puller
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kitsnail/ips/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// fakeImageService 进程内的 CRI ImageService 实现
+type fakeImageService struct {
+	runtimeapi.UnimplementedImageServiceServer
+
+	mu       sync.Mutex
+	images   map[string]*runtimeapi.Image // 已存在的镜像
+	failures map[string]error             // 拉取时返回错误的镜像
+	delays   map[string]time.Duration     // 拉取耗时
+	auths    []*runtimeapi.AuthConfig     // 每次拉取收到的凭据
+
+	inflight    int // 正在拉取的镜像数
+	maxInflight int // 同时拉取的最大镜像数
+}
+
+func newFakeImageService() *fakeImageService {
+	return &fakeImageService{
+		images:   make(map[string]*runtimeapi.Image),
+		failures: make(map[string]error),
+		delays:   make(map[string]time.Duration),
+	}
+}
+
+func (f *fakeImageService) PullImage(ctx context.Context, req *runtimeapi.PullImageRequest) (*runtimeapi.PullImageResponse, error) {
+	image := req.Image.Image
+
+	f.mu.Lock()
+	f.inflight++
+	f.maxInflight = max(f.maxInflight, f.inflight)
+	delay := f.delays[image]
+	f.mu.Unlock()
+
+	defer func() {
+		f.mu.Lock()
+		f.inflight--
+		f.mu.Unlock()
+	}()
+
+	select {
+	case <-time.After(delay):
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.auths = append(f.auths, req.Auth)
+	if err, ok := f.failures[image]; ok {
+		return nil, err
+	}
+
+	f.images[image] = &runtimeapi.Image{
+		Id:          "sha256:0123",
+		RepoTags:    []string{image},
+		RepoDigests: []string{"registry.example.com/app@sha256:abcd"},
+		Size_:       1024,
+	}
+	return &runtimeapi.PullImageResponse{ImageRef: "sha256:0123"}, nil
+}
+
+func (f *fakeImageService) ImageStatus(ctx context.Context, req *runtimeapi.ImageStatusRequest) (*runtimeapi.ImageStatusResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return &runtimeapi.ImageStatusResponse{Image: f.images[req.Image.Image]}, nil
+}
+
+// startFakeCRI 在临时 unix socket 上启动 fake CRI 服务，返回 socket 路径
+func startFakeCRI(t *testing.T, svc *fakeImageService) string {
+	t.Helper()
+
+	// unix socket 路径长度有限，不使用 t.TempDir()
+	dir, err := os.MkdirTemp("", "cri")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	socketPath := filepath.Join(dir, "cri.sock")
+	lis, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	runtimeapi.RegisterImageServiceServer(server, svc)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	return socketPath
+}
+
+func TestPullImages_Succeeded(t *testing.T) {
+	svc := newFakeImageService()
+	socketPath := startFakeCRI(t, svc)
+
+	results := pullImages(context.Background(), []string{"registry.example.com/app:v1"}, "unix://"+socketPath, nil, Options{})
+	require.Len(t, results, 1)
+
+	result := results[0]
+	assert.Equal(t, models.ImagePullSucceeded, result.Status)
+	assert.Equal(t, "sha256:abcd", result.Digest)
+	assert.Equal(t, uint64(1024), result.SizeBytes)
+	assert.Empty(t, result.Error)
+
+	require.Len(t, svc.auths, 1)
+	assert.Nil(t, svc.auths[0])
+}
+
+func TestPullImages_PassesAuthConfig(t *testing.T) {
+	svc := newFakeImageService()
+	socketPath := startFakeCRI(t, svc)
+
+	auth := ParseCredentials("robot$ci:p@ss:word")
+	results := pullImages(context.Background(), []string{"a:v1", "b:v1"}, socketPath, auth, Options{})
+	require.Len(t, results, 2)
+
+	require.Len(t, svc.auths, 2)
+	for _, got := range svc.auths {
+		require.NotNil(t, got)
+		assert.Equal(t, "robot$ci", got.Username)
+		assert.Equal(t, "p@ss:word", got.Password)
+	}
+}
+
+func TestPullImages_CapturesError(t *testing.T) {
+	svc := newFakeImageService()
+	svc.failures["missing:v1"] = status.Error(codes.NotFound, "manifest unknown")
+	socketPath := startFakeCRI(t, svc)
+
+	results := pullImages(context.Background(), []string{"missing:v1", "ok:v1"}, socketPath, nil, Options{})
+	require.Len(t, results, 2)
+
+	assert.Equal(t, models.ImagePullFailed, results[0].Status)
+	assert.Contains(t, results[0].Error, "manifest unknown")
+	assert.Empty(t, results[0].Digest)
+
+	assert.Equal(t, models.ImagePullSucceeded, results[1].Status)
+}
+
+func TestPullImages_SocketUnavailable(t *testing.T) {
+	dir, err := os.MkdirTemp("", "cri")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := pullImages(ctx, []string{"a:v1", "b:v1"}, filepath.Join(dir, "missing.sock"), nil, Options{})
+	require.Len(t, results, 2)
+	for _, result := range results {
+		assert.Equal(t, models.ImagePullFailed, result.Status)
+		assert.NotEmpty(t, result.Error)
+	}
+}
+
+func TestParseCredentials(t *testing.T) {
+	assert.Nil(t, ParseCredentials(""))
+
+	auth := ParseCredentials("user:pass")
+	require.NotNil(t, auth)
+	assert.Equal(t, "user", auth.Username)
+	assert.Equal(t, "pass", auth.Password)
+}
+
+func TestPullImages_SkipsPresentImage(t *testing.T) {
+	pinned := "nginx@sha256:" + strings.Repeat("b", 64)
+	svc := newFakeImageService()
+	svc.images[pinned] = &runtimeapi.Image{
+		Id:          "sha256:0123",
+		RepoDigests: []string{"docker.io/library/nginx@sha256:beef"},
+		Size_:       2048,
+	}
+	svc.images["nginx:latest"] = &runtimeapi.Image{Id: "sha256:0456"}
+	socketPath := startFakeCRI(t, svc)
+
+	results := pullImages(context.Background(), []string{pinned, "nginx:latest", "app:v1"}, socketPath, nil, Options{})
+	require.Len(t, results, 3)
+
+	assert.Equal(t, models.ImagePullAlreadyPresent, results[0].Status)
+	assert.Equal(t, "sha256:beef", results[0].Digest)
+	assert.Equal(t, uint64(2048), results[0].SizeBytes)
+	// tag 可能已指向新的镜像，已存在时仍重新拉取
+	assert.Equal(t, models.ImagePullSucceeded, results[1].Status)
+	assert.Equal(t, models.ImagePullSucceeded, results[2].Status)
+
+	// 只有已存在的 digest 引用不触发拉取
+	assert.Len(t, svc.auths, 2)
+}
+
+func TestPullImages_Parallel(t *testing.T) {
+	svc := newFakeImageService()
+	images := []string{"a:v1", "b:v1", "c:v1", "d:v1"}
+	for _, img := range images {
+		svc.delays[img] = 100 * time.Millisecond
+	}
+	socketPath := startFakeCRI(t, svc)
+
+	results := pullImages(context.Background(), images, socketPath, nil, Options{Parallelism: 2})
+	require.Len(t, results, 4)
+
+	// 结果顺序与请求一致
+	for i, result := range results {
+		assert.Equal(t, images[i], result.Image)
+		assert.Equal(t, models.ImagePullSucceeded, result.Status)
+	}
+	assert.Equal(t, 2, svc.maxInflight)
+}
+
+func TestPullImages_ImageTimeout(t *testing.T) {
+	svc := newFakeImageService()
+	svc.delays["slow:v1"] = 5 * time.Second
+	socketPath := startFakeCRI(t, svc)
+
+	start := time.Now()
+	results := pullImages(context.Background(), []string{"slow:v1", "fast:v1"}, socketPath, nil, Options{
+		Parallelism:  1,
+		ImageTimeout: 200 * time.Millisecond,
+	})
+	require.Len(t, results, 2)
+	assert.Less(t, time.Since(start), 2*time.Second)
+
+	// 超时单独标记，不阻塞后续镜像
+	assert.Equal(t, models.ImagePullTimeout, results[0].Status)
+	assert.Contains(t, results[0].Error, "timed out after 200ms")
+	assert.False(t, results[0].Succeeded())
+	assert.Equal(t, models.ImagePullSucceeded, results[1].Status)
+}
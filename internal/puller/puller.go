@@ -1,55 +1,68 @@
 package puller
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kitsnail/ips/pkg/models"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
 )
 
-// Run 运行拉取逻辑
-func Run(images []string, criSocketPath string) {
-	results := make(map[string]int)
+// terminationLogPath 结果写入 termination log，由 StatusTracker 从 Pod 状态中读取
+var terminationLogPath = "/dev/termination-log"
+
+const (
+	// maxTerminationMessageBytes Kubernetes 对终止消息的长度限制
+	maxTerminationMessageBytes = 4096
+	// maxErrorLength 单个镜像错误信息的最大长度
+	maxErrorLength = 256
+)
+
+// Options 拉取选项
+type Options struct {
+	Parallelism  int           // 并行拉取的镜像数，小于 1 时逐个拉取
+	ImageTimeout time.Duration // 单个镜像的拉取超时，0 表示不限制
+}
 
+// OptionsFromEnv 从环境变量（由 JobCreator 注入）读取拉取选项
+func OptionsFromEnv() Options {
+	var opts Options
+	if v, err := strconv.Atoi(os.Getenv("PULL_PARALLELISM")); err == nil && v > 0 {
+		opts.Parallelism = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("IMAGE_PULL_TIMEOUT_SECONDS")); err == nil && v > 0 {
+		opts.ImageTimeout = time.Duration(v) * time.Second
+	}
+	return opts
+}
+
+// Run 运行拉取逻辑
+func Run(images []string, criSocketPath string, opts Options) {
 	// 读取凭据（格式：username:password）
 	registryCreds := os.Getenv("REGISTRY_CREDS")
 
-	fmt.Printf("Starting pre-warm for %d images using socket %s\n", len(images), criSocketPath)
+	fmt.Printf("Starting pre-warm for %d images using socket %s (parallelism %d, timeout %s)\n",
+		len(images), criSocketPath, max(opts.Parallelism, 1), opts.ImageTimeout)
 	if registryCreds != "" {
 		fmt.Println("Using registry credentials for authentication")
 	}
 
-	for _, img := range images {
-		fmt.Printf("Pulling %s...\n", img)
-
-		// 构造 crictl 命令
-		// 注意：我们需要通过环境变量或参数指定 socket
-		var cmd *exec.Cmd
-		if registryCreds != "" {
-			// 使用 --creds 参数进行认证
-			cmd = exec.Command("crictl", "--image-endpoint", "unix://"+criSocketPath, "pull", "--creds", registryCreds, img)
-		} else {
-			cmd = exec.Command("crictl", "--image-endpoint", "unix://"+criSocketPath, "pull", img)
-		}
-
-		output, err := cmd.CombinedOutput()
-		if err != nil {
-			fmt.Printf("Failed to pull %s: %v\nOutput: %s\n", img, err, string(output))
-			results[img] = 0
-		} else {
-			fmt.Printf("Successfully pulled %s\n", img)
-			results[img] = 1
-		}
-	}
+	results := pullImages(context.Background(), images, criSocketPath, ParseCredentials(registryCreds), opts)
 
 	// 写入 termination log
-	data, _ := json.Marshal(results)
-	err := os.WriteFile("/dev/termination-log", data, 0644)
+	data := encodeResults(results)
+	err := os.WriteFile(terminationLogPath, data, 0644)
 	if err != nil {
 		fmt.Printf("Failed to write termination log: %v\n", err)
-		// 如果无法写入，至少打印出来
-		fmt.Printf("FINAL_RESULT: %s\n", string(data))
 	}
+	// 同时打印到日志，便于排查
+	fmt.Printf("FINAL_RESULT: %s\n", string(data))
 
 	// 如果有失败的，以非零状态退出？
 	// 其实没必要，因为我们已经把结果写到了 termination log，
@@ -57,3 +70,92 @@ func Run(images []string, criSocketPath string) {
 	// 但通常如果有失败，退出码非零更符合 K8s 习惯。
 	// 这里我们选择让 Job 成功，因为拉取逻辑已经执行完毕。
 }
+
+// pullImages 连接 CRI 并按并行度拉取镜像，结果顺序与 images 一致
+// 无法连接 CRI 时所有镜像均记为失败
+func pullImages(ctx context.Context, images []string, criSocketPath string, auth *runtimeapi.AuthConfig, opts Options) []models.ImagePullResult {
+	results := make([]models.ImagePullResult, len(images))
+
+	conn, err := Dial(ctx, criSocketPath)
+	if err != nil {
+		for i, img := range images {
+			results[i] = models.ImagePullResult{
+				Image:  img,
+				Status: models.ImagePullFailed,
+				Error:  err.Error(),
+			}
+		}
+		return results
+	}
+	defer conn.Close()
+
+	puller := NewImagePuller(runtimeapi.NewImageServiceClient(conn), auth)
+
+	sem := make(chan struct{}, max(opts.Parallelism, 1))
+	var wg sync.WaitGroup
+	for i, img := range images {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, img string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = pullOne(ctx, puller, img, opts.ImageTimeout)
+		}(i, img)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// pullOne 在超时限制内拉取单个镜像
+func pullOne(ctx context.Context, puller *ImagePuller, img string, timeout time.Duration) models.ImagePullResult {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	fmt.Printf("Pulling %s...\n", img)
+	result := puller.Pull(ctx, img)
+	switch result.Status {
+	case models.ImagePullTimeout:
+		result.Error = fmt.Sprintf("pull timed out after %s: %s", timeout, result.Error)
+		fmt.Printf("Timed out pulling %s after %s\n", img, timeout)
+	case models.ImagePullFailed:
+		fmt.Printf("Failed to pull %s: %s\n", img, result.Error)
+	case models.ImagePullAlreadyPresent:
+		fmt.Printf("Image %s already present, skipped\n", img)
+	default:
+		fmt.Printf("Successfully pulled %s in %dms\n", img, result.DurationMs)
+	}
+	return result
+}
+
+// encodeResults 编码拉取结果，保证尽量不超过终止消息长度限制
+// 超出时依次丢弃错误信息、digest、耗时和大小，镜像及状态始终保留
+func encodeResults(results []models.ImagePullResult) []byte {
+	for i := range results {
+		if len(results[i].Error) > maxErrorLength {
+			results[i].Error = strings.ToValidUTF8(results[i].Error[:maxErrorLength], "")
+		}
+	}
+
+	trimmers := []func(r *models.ImagePullResult){
+		func(r *models.ImagePullResult) { r.Error = "" },
+		func(r *models.ImagePullResult) { r.Digest = "" },
+		func(r *models.ImagePullResult) { r.DurationMs, r.SizeBytes = 0, 0 },
+	}
+
+	data, _ := json.Marshal(results)
+	for _, trim := range trimmers {
+		if len(data) <= maxTerminationMessageBytes {
+			break
+		}
+		for i := range results {
+			trim(&results[i])
+		}
+		data, _ = json.Marshal(results)
+	}
+
+	return data
+}
@@ -0,0 +1,44 @@
+package puller
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/kitsnail/ips/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeResults_FitsTerminationMessage(t *testing.T) {
+	var results []models.ImagePullResult
+	for i := 0; i < 30; i++ {
+		results = append(results, models.ImagePullResult{
+			Image:      "registry.example.com/team/app-" + strings.Repeat("x", 20) + ":v1",
+			Status:     models.ImagePullFailed,
+			Error:      strings.Repeat("e", 1000),
+			DurationMs: 1234,
+			Digest:     "sha256:" + strings.Repeat("a", 64),
+		})
+	}
+
+	data := encodeResults(results)
+	assert.LessOrEqual(t, len(data), maxTerminationMessageBytes)
+
+	var decoded []models.ImagePullResult
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Len(t, decoded, 30)
+	assert.Equal(t, models.ImagePullFailed, decoded[0].Status)
+}
+
+func TestEncodeResults_KeepsDetailsWhenSmall(t *testing.T) {
+	data := encodeResults([]models.ImagePullResult{{
+		Image:  "nginx:latest",
+		Status: models.ImagePullFailed,
+		Error:  strings.Repeat("e", 1000),
+	}})
+
+	var decoded []models.ImagePullResult
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Len(t, decoded[0].Error, maxErrorLength)
+}
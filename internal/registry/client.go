@@ -0,0 +1,218 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/distribution/reference"
+	"github.com/opencontainers/go-digest"
+)
+
+// ErrManifestNotFound 仓库中不存在该镜像 tag
+var ErrManifestNotFound = errors.New("manifest not found")
+
+// manifestMediaTypes 查询 manifest 时接受的类型，多架构镜像返回 index 的 digest
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Credentials 镜像仓库认证信息
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// Client 镜像仓库 v2 API 客户端
+type Client struct {
+	httpClient *http.Client
+}
+
+// NewClient 创建仓库客户端，httpClient 为空时使用 30 秒超时的默认客户端
+func NewClient(httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Client{httpClient: httpClient}
+}
+
+// ResolveDigest 查询镜像 tag 当前指向的 manifest digest
+// 引用已包含 digest 时直接返回；creds 为空时匿名访问
+func (c *Client) ResolveDigest(ctx context.Context, ref reference.Named, creds *Credentials) (digest.Digest, error) {
+	if canonical, ok := ref.(reference.Canonical); ok {
+		return canonical.Digest(), nil
+	}
+
+	tag := "latest"
+	if tagged, ok := ref.(reference.Tagged); ok {
+		tag = tagged.Tag()
+	}
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", apiHost(reference.Domain(ref)), reference.Path(ref), tag)
+
+	resp, err := c.headManifest(ctx, manifestURL, "")
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		authorization, err := c.authorize(ctx, resp.Header.Get("WWW-Authenticate"), reference.Path(ref), creds)
+		if err != nil {
+			return "", fmt.Errorf("failed to authenticate to %s: %w", reference.Domain(ref), err)
+		}
+		if resp, err = c.headManifest(ctx, manifestURL, authorization); err != nil {
+			return "", err
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		dgst, err := digest.Parse(resp.Header.Get("Docker-Content-Digest"))
+		if err != nil {
+			return "", fmt.Errorf("registry returned invalid digest for %s: %w", reference.FamiliarString(ref), err)
+		}
+		return dgst, nil
+	case http.StatusNotFound:
+		return "", fmt.Errorf("%w: %s", ErrManifestNotFound, reference.FamiliarString(ref))
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return "", fmt.Errorf("access to %s denied by registry", reference.FamiliarString(ref))
+	default:
+		return "", fmt.Errorf("unexpected status %d from registry for %s", resp.StatusCode, reference.FamiliarString(ref))
+	}
+}
+
+// headManifest 发送 manifest HEAD 请求，只需要响应头
+func (c *Client) headManifest(ctx context.Context, manifestURL, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query registry: %w", err)
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// authorize 根据 WWW-Authenticate 质询返回 Authorization 请求头
+// 支持 Basic 认证与 Bearer token 服务（Docker Hub、Harbor 等）
+func (c *Client) authorize(ctx context.Context, challenge, repository string, creds *Credentials) (string, error) {
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if creds == nil {
+			return "", fmt.Errorf("registry requires credentials")
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(creds.Username+":"+creds.Password)), nil
+
+	case "bearer":
+		realm := params["realm"]
+		if realm == "" {
+			return "", fmt.Errorf("bearer challenge without realm")
+		}
+		query := url.Values{}
+		if service := params["service"]; service != "" {
+			query.Set("service", service)
+		}
+		scope := params["scope"]
+		if scope == "" {
+			scope = fmt.Sprintf("repository:%s:pull", repository)
+		}
+		query.Set("scope", scope)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm+"?"+query.Encode(), nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to build token request: %w", err)
+		}
+		if creds != nil {
+			req.SetBasicAuth(creds.Username, creds.Password)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("failed to request token: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("token service returned status %d", resp.StatusCode)
+		}
+
+		var token struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+			return "", fmt.Errorf("failed to decode token response: %w", err)
+		}
+		if token.Token == "" {
+			token.Token = token.AccessToken
+		}
+		return "Bearer " + token.Token, nil
+
+	default:
+		return "", fmt.Errorf("unsupported authentication scheme %q", scheme)
+	}
+}
+
+// parseChallenge 解析 WWW-Authenticate 请求头，如 Bearer realm="...",service="..."
+// 引号内的值可能包含逗号（如 scope="repository:app:pull,push"）
+func parseChallenge(header string) (scheme string, params map[string]string) {
+	params = make(map[string]string)
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+
+	for rest = strings.TrimSpace(rest); rest != ""; {
+		key, after, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		var value string
+		if strings.HasPrefix(after, `"`) {
+			end := strings.Index(after[1:], `"`)
+			if end < 0 {
+				value, rest = after[1:], ""
+			} else {
+				value, rest = after[1:end+1], after[end+2:]
+			}
+		} else {
+			value, rest, _ = strings.Cut(after, ",")
+		}
+		params[key] = strings.TrimSpace(value)
+		rest = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(rest), ","))
+	}
+	return scheme, params
+}
+
+// apiHost 返回仓库 API 的地址，Docker Hub 的 API 不在 docker.io 上
+func apiHost(domain string) string {
+	if domain == "docker.io" {
+		return "registry-1.docker.io"
+	}
+	return domain
+}
+
+// MatchesRegistry 判断镜像所在仓库是否为 registry（可带协议前缀）
+// 用于选择适用于该镜像的凭据
+func MatchesRegistry(ref reference.Named, registry string) bool {
+	registry = strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://")
+	registry = strings.TrimSuffix(registry, "/")
+	switch registry {
+	case "index.docker.io", "registry-1.docker.io":
+		registry = "docker.io"
+	}
+	return registry != "" && registry == reference.Domain(ref)
+}
@@ -0,0 +1,109 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/distribution/reference"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDigest = "sha256:4c0fdaa8b6341bfdeca5f18f7837462c80cff90527ee35ef185571e1c327beac"
+
+// newTestRegistry 模拟需要 Bearer token 认证的仓库，只存在 team/app:v1
+func newTestRegistry(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "robot" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "repository:team/app:pull", r.URL.Query().Get("scope"))
+		json.NewEncoder(w).Encode(map[string]string{"token": "test-token"})
+	})
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test-registry"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/v2/team/app/manifests/v1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.Contains(t, r.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json")
+		w.Header().Set("Docker-Content-Digest", testDigest)
+	})
+
+	server = httptest.NewTLSServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient_ResolveDigest(t *testing.T) {
+	server := newTestRegistry(t)
+	client := NewClient(server.Client())
+	host := strings.TrimPrefix(server.URL, "https://")
+	creds := &Credentials{Username: "robot", Password: "secret"}
+	ctx := context.Background()
+
+	ref, err := reference.ParseNormalizedNamed(host + "/team/app:v1")
+	require.NoError(t, err)
+	dgst, err := client.ResolveDigest(ctx, ref, creds)
+	require.NoError(t, err)
+	assert.Equal(t, testDigest, dgst.String())
+
+	// tag 不存在
+	ref, err = reference.ParseNormalizedNamed(host + "/team/app:v2")
+	require.NoError(t, err)
+	_, err = client.ResolveDigest(ctx, ref, creds)
+	assert.ErrorIs(t, err, ErrManifestNotFound)
+
+	// 凭据错误
+	_, err = client.ResolveDigest(ctx, ref, &Credentials{Username: "robot", Password: "wrong"})
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrManifestNotFound)
+
+	// 已固定 digest 的引用不访问仓库
+	ref, err = reference.ParseNormalizedNamed("nginx@" + testDigest)
+	require.NoError(t, err)
+	dgst, err = NewClient(nil).ResolveDigest(ctx, ref, nil)
+	require.NoError(t, err)
+	assert.Equal(t, testDigest, dgst.String())
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.example.com/token",service="registry",scope="repository:app:pull,push"`)
+	assert.Equal(t, "Bearer", scheme)
+	assert.Equal(t, map[string]string{
+		"realm":   "https://auth.example.com/token",
+		"service": "registry",
+		"scope":   "repository:app:pull,push",
+	}, params)
+
+	scheme, params = parseChallenge(`Basic realm="Registry"`)
+	assert.Equal(t, "Basic", scheme)
+	assert.Equal(t, "Registry", params["realm"])
+}
+
+func TestMatchesRegistry(t *testing.T) {
+	ref, err := reference.ParseNormalizedNamed("harbor.example.com/team/app:v1")
+	require.NoError(t, err)
+	assert.True(t, MatchesRegistry(ref, "harbor.example.com"))
+	assert.True(t, MatchesRegistry(ref, "https://harbor.example.com/"))
+	assert.False(t, MatchesRegistry(ref, "docker.io"))
+
+	ref, err = reference.ParseNormalizedNamed("nginx")
+	require.NoError(t, err)
+	assert.True(t, MatchesRegistry(ref, "index.docker.io"))
+}
@@ -14,10 +14,15 @@ type MemoryRepository struct {
 	mu             sync.RWMutex
 	tasks          map[string]*models.Task
 	scheduledTasks map[string]*models.ScheduledTask
+	imagePolicies  map[string]*models.ImagePolicy
 	libraryImages  map[int64]*models.LibraryImage
 	secrets        map[int64]*models.RegistrySecret
+	settings       map[string]string
+	taskEvents     map[string][]*models.TaskEvent
+	nodeLogs       map[string]map[string]*models.NodeLog
 	nextLibraryID  int64
 	nextSecretID   int64
+	nextEventID    int64
 }
 
 // NewMemoryRepository 创建内存存储
@@ -25,8 +30,12 @@ func NewMemoryRepository() *MemoryRepository {
 	return &MemoryRepository{
 		tasks:          make(map[string]*models.Task),
 		scheduledTasks: make(map[string]*models.ScheduledTask),
+		imagePolicies:  make(map[string]*models.ImagePolicy),
 		libraryImages:  make(map[int64]*models.LibraryImage),
 		secrets:        make(map[int64]*models.RegistrySecret),
+		settings:       make(map[string]string),
+		taskEvents:     make(map[string][]*models.TaskEvent),
+		nodeLogs:       make(map[string]map[string]*models.NodeLog),
 		nextLibraryID:  1,
 		nextSecretID:   1,
 	}
@@ -41,7 +50,8 @@ func (r *MemoryRepository) CreateTask(ctx context.Context, task *models.Task) er
 		return ErrTaskAlreadyExists
 	}
 
-	r.tasks[task.ID] = task
+	// 保存副本，与 SQLite 实现一样不与调用方共享任务对象
+	r.tasks[task.ID] = task.Clone()
 	return nil
 }
 
@@ -55,7 +65,7 @@ func (r *MemoryRepository) GetTask(ctx context.Context, id string) (*models.Task
 		return nil, ErrTaskNotFound
 	}
 
-	return task, nil
+	return task.Clone(), nil
 }
 
 // ListTasks 列出任务
@@ -65,7 +75,7 @@ func (r *MemoryRepository) ListTasks(ctx context.Context, offset, limit int) ([]
 
 	var allTasks []*models.Task
 	for _, task := range r.tasks {
-		allTasks = append(allTasks, task)
+		allTasks = append(allTasks, task.Clone())
 	}
 
 	sort.Slice(allTasks, func(i, j int) bool {
@@ -94,19 +104,65 @@ func (r *MemoryRepository) ListTasks(ctx context.Context, offset, limit int) ([]
 	return allTasks[offset:end], total, nil
 }
 
+// ListTasksByStatus 按状态列出任务
+func (r *MemoryRepository) ListTasksByStatus(ctx context.Context, statuses ...models.TaskStatus) ([]*models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tasks []*models.Task
+	for _, task := range r.tasks {
+		for _, status := range statuses {
+			if task.Status == status {
+				tasks = append(tasks, task.Clone())
+				break
+			}
+		}
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+	})
+
+	return tasks, nil
+}
+
 // UpdateTask 更新任务
 func (r *MemoryRepository) UpdateTask(ctx context.Context, task *models.Task) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.tasks[task.ID]; !exists {
+	current, exists := r.tasks[task.ID]
+	if !exists {
 		return ErrTaskNotFound
 	}
 
-	r.tasks[task.ID] = task
+	updated := task.Clone()
+	// 执行器持有的 pending/running 状态不覆盖 paused 与终态
+	if (current.Status == models.TaskPaused || current.Status.IsTerminal()) && (task.Status == models.TaskPending || task.Status == models.TaskRunning) {
+		updated.Status = current.Status
+	}
+	r.tasks[task.ID] = updated
 	return nil
 }
 
+// UpdateTaskStatus 任务当前状态属于 from 时更新状态
+func (r *MemoryRepository) UpdateTaskStatus(ctx context.Context, id string, status models.TaskStatus, from ...models.TaskStatus) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, exists := r.tasks[id]
+	if !exists {
+		return false, ErrTaskNotFound
+	}
+	for _, s := range from {
+		if task.Status == s {
+			task.Status = status
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // DeleteTask 删除任务
 func (r *MemoryRepository) DeleteTask(ctx context.Context, id string) error {
 	r.mu.Lock()
@@ -117,9 +173,125 @@ func (r *MemoryRepository) DeleteTask(ctx context.Context, id string) error {
 	}
 
 	delete(r.tasks, id)
+	delete(r.taskEvents, id)
+	delete(r.nodeLogs, id)
 	return nil
 }
 
+// SaveNodeLog 保存节点日志，覆盖该节点之前的日志
+func (r *MemoryRepository) SaveNodeLog(ctx context.Context, taskID string, nodeLog *models.NodeLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.nodeLogs[taskID] == nil {
+		r.nodeLogs[taskID] = make(map[string]*models.NodeLog)
+	}
+	stored := *nodeLog
+	r.nodeLogs[taskID][nodeLog.NodeName] = &stored
+	return nil
+}
+
+// GetNodeLog 获取节点日志
+func (r *MemoryRepository) GetNodeLog(ctx context.Context, taskID, nodeName string) (*models.NodeLog, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	nodeLog, ok := r.nodeLogs[taskID][nodeName]
+	if !ok {
+		return nil, ErrNodeLogNotFound
+	}
+	copied := *nodeLog
+	return &copied, nil
+}
+
+// CreateTaskEvent 保存任务事件
+func (r *MemoryRepository) CreateTaskEvent(ctx context.Context, event *models.TaskEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextEventID++
+	stored := *event
+	stored.ID = r.nextEventID
+	event.ID = stored.ID
+	r.taskEvents[event.TaskID] = append(r.taskEvents[event.TaskID], &stored)
+	return nil
+}
+
+// ListTaskEvents 按发生顺序列出任务的所有事件
+func (r *MemoryRepository) ListTaskEvents(ctx context.Context, taskID string) ([]*models.TaskEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := make([]*models.TaskEvent, 0, len(r.taskEvents[taskID]))
+	for _, event := range r.taskEvents[taskID] {
+		copied := *event
+		events = append(events, &copied)
+	}
+	return events, nil
+}
+
+// ListTaskEventsAfter 按序号顺序列出序号大于 afterID 的事件，taskID 为空时包括所有任务
+func (r *MemoryRepository) ListTaskEventsAfter(ctx context.Context, taskID string, afterID int64, limit int) ([]*models.TaskEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var events []*models.TaskEvent
+	for id, taskEvents := range r.taskEvents {
+		if taskID != "" && id != taskID {
+			continue
+		}
+		for _, event := range taskEvents {
+			if event.ID > afterID {
+				copied := *event
+				events = append(events, &copied)
+			}
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+// LatestTaskEventID 返回最大的事件序号
+func (r *MemoryRepository) LatestTaskEventID(ctx context.Context) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var latest int64
+	for _, taskEvents := range r.taskEvents {
+		if n := len(taskEvents); n > 0 && taskEvents[n-1].ID > latest {
+			latest = taskEvents[n-1].ID
+		}
+	}
+	return latest, nil
+}
+
+// DeleteOldTaskEvents 删除指定时间之前的事件
+func (r *MemoryRepository) DeleteOldTaskEvents(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for taskID, events := range r.taskEvents {
+		kept := events[:0]
+		for _, event := range events {
+			if event.Timestamp.Before(before) {
+				deleted++
+				continue
+			}
+			kept = append(kept, event)
+		}
+		if len(kept) == 0 {
+			delete(r.taskEvents, taskID)
+		} else {
+			r.taskEvents[taskID] = kept
+		}
+	}
+	return deleted, nil
+}
+
 // Dummy User methods to satisfy interface
 
 func (r *MemoryRepository) CreateUser(ctx context.Context, user *models.User) error { return nil }
@@ -206,6 +378,21 @@ func (r *MemoryRepository) ListScheduledTasks(ctx context.Context, offset, limit
 
 	return allTasks[offset:end], total, nil
 }
+
+// ListEnabledScheduledTasks 列出所有启用的定时任务
+func (r *MemoryRepository) ListEnabledScheduledTasks(ctx context.Context) ([]*models.ScheduledTask, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tasks []*models.ScheduledTask
+	for _, task := range r.scheduledTasks {
+		if task.Enabled {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, nil
+}
+
 func (r *MemoryRepository) ListSecrets(ctx context.Context, offset, limit int) ([]*models.SecretListItem, int, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -331,6 +518,22 @@ func (r *MemoryRepository) ListLibraryImages(ctx context.Context, offset, limit
 
 	return allImages[offset:end], total, nil
 }
+
+// SaveImage 保存镜像到库
+func (r *MemoryRepository) SaveImage(ctx context.Context, img *models.LibraryImage) error {
+	return r.CreateLibraryImage(ctx, img)
+}
+
+// ListImages 列出库中的镜像 (分页)
+func (r *MemoryRepository) ListImages(ctx context.Context, offset, limit int) ([]*models.LibraryImage, int, error) {
+	return r.ListLibraryImages(ctx, offset, limit)
+}
+
+// DeleteImage 从库中删除镜像
+func (r *MemoryRepository) DeleteImage(ctx context.Context, id int64) error {
+	return r.DeleteLibraryImage(ctx, id)
+}
+
 func (r *MemoryRepository) DeleteLibraryImage(ctx context.Context, id int64) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -361,7 +564,7 @@ func (r *MemoryRepository) GetScheduledTask(ctx context.Context, id string) (*mo
 
 	task, exists := r.scheduledTasks[id]
 	if !exists {
-		return nil, ErrTaskNotFound
+		return nil, ErrScheduledTaskNotFound
 	}
 	return task, nil
 }
@@ -388,3 +591,108 @@ func (r *MemoryRepository) DeleteScheduledTask(ctx context.Context, id string) e
 	delete(r.scheduledTasks, id)
 	return nil
 }
+
+// GetSetting 获取配置项
+func (r *MemoryRepository) GetSetting(ctx context.Context, key string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	value, exists := r.settings[key]
+	if !exists {
+		return "", ErrSettingNotFound
+	}
+	return value, nil
+}
+
+// SetSetting 保存配置项
+func (r *MemoryRepository) SetSetting(ctx context.Context, key, value string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.settings[key] = value
+	return nil
+}
+
+// 镜像常驻策略保存副本，使策略定义与协调状态的更新互不覆盖
+
+func (r *MemoryRepository) CreateImagePolicy(ctx context.Context, policy *models.ImagePolicy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.imagePolicies[policy.ID]; exists {
+		return ErrTaskAlreadyExists
+	}
+	policy.CreatedAt = time.Now()
+	policy.UpdatedAt = policy.CreatedAt
+	stored := *policy
+	r.imagePolicies[policy.ID] = &stored
+	return nil
+}
+
+func (r *MemoryRepository) GetImagePolicy(ctx context.Context, id string) (*models.ImagePolicy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	policy, exists := r.imagePolicies[id]
+	if !exists {
+		return nil, ErrImagePolicyNotFound
+	}
+	result := *policy
+	return &result, nil
+}
+
+func (r *MemoryRepository) ListImagePolicies(ctx context.Context) ([]*models.ImagePolicy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	policies := make([]*models.ImagePolicy, 0, len(r.imagePolicies))
+	for _, policy := range r.imagePolicies {
+		result := *policy
+		policies = append(policies, &result)
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].CreatedAt.After(policies[j].CreatedAt)
+	})
+	return policies, nil
+}
+
+func (r *MemoryRepository) UpdateImagePolicy(ctx context.Context, policy *models.ImagePolicy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, exists := r.imagePolicies[policy.ID]
+	if !exists {
+		return ErrImagePolicyNotFound
+	}
+	policy.UpdatedAt = time.Now()
+	updated := *policy
+	updated.State = stored.State
+	updated.CreatedAt = stored.CreatedAt
+	r.imagePolicies[policy.ID] = &updated
+	return nil
+}
+
+func (r *MemoryRepository) UpdateImagePolicyState(ctx context.Context, id string, state *models.ImagePolicyState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, exists := r.imagePolicies[id]
+	if !exists {
+		return ErrImagePolicyNotFound
+	}
+	updated := *stored
+	updated.State = *state
+	r.imagePolicies[id] = &updated
+	return nil
+}
+
+func (r *MemoryRepository) DeleteImagePolicy(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.imagePolicies[id]; !exists {
+		return ErrImagePolicyNotFound
+	}
+	delete(r.imagePolicies, id)
+	return nil
+}
@@ -125,6 +125,54 @@ func TestMemoryRepository_UpdateTask(t *testing.T) {
 	}
 }
 
+func TestMemoryRepository_UpdateTaskStatus(t *testing.T) {
+	repo := NewMemoryRepository()
+	ctx := context.Background()
+
+	task := &models.Task{
+		ID:        "test-task-pause",
+		Status:    models.TaskRunning,
+		Images:    []string{"nginx:latest"},
+		BatchSize: 10,
+		CreatedAt: time.Now(),
+	}
+	repo.CreateTask(ctx, task)
+
+	// 只有状态匹配时更新
+	updated, err := repo.UpdateTaskStatus(ctx, task.ID, models.TaskPaused, models.TaskPending, models.TaskRunning)
+	if err != nil || !updated {
+		t.Fatalf("UpdateTaskStatus() = %v, %v, want true", updated, err)
+	}
+	updated, err = repo.UpdateTaskStatus(ctx, task.ID, models.TaskPaused, models.TaskPending, models.TaskRunning)
+	if err != nil || updated {
+		t.Errorf("UpdateTaskStatus() on paused task = %v, %v, want false", updated, err)
+	}
+	if _, err := repo.UpdateTaskStatus(ctx, "missing", models.TaskPaused, models.TaskRunning); err != ErrTaskNotFound {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+
+	// 执行器持有的 running 不覆盖 paused，其他字段正常保存
+	task.Progress = &models.Progress{CurrentBatch: 2}
+	if err := repo.UpdateTask(ctx, task); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	retrieved, _ := repo.GetTask(ctx, task.ID)
+	if retrieved.Status != models.TaskPaused {
+		t.Errorf("Expected Status %s, got %s", models.TaskPaused, retrieved.Status)
+	}
+	if retrieved.Progress == nil || retrieved.Progress.CurrentBatch != 2 {
+		t.Errorf("Expected progress to be saved, got %+v", retrieved.Progress)
+	}
+
+	// 终态可以覆盖 paused
+	task.Status = models.TaskCancelled
+	repo.UpdateTask(ctx, task)
+	retrieved, _ = repo.GetTask(ctx, task.ID)
+	if retrieved.Status != models.TaskCancelled {
+		t.Errorf("Expected Status %s, got %s", models.TaskCancelled, retrieved.Status)
+	}
+}
+
 func TestMemoryRepository_DeleteTask(t *testing.T) {
 	repo := NewMemoryRepository()
 	ctx := context.Background()
@@ -198,3 +246,93 @@ func TestMemoryRepository_ListTasks(t *testing.T) {
 		t.Errorf("Expected total 3, got %d", total)
 	}
 }
+
+func TestMemoryRepository_ListTasksByStatus(t *testing.T) {
+	repo := NewMemoryRepository()
+	ctx := context.Background()
+
+	now := time.Now()
+	tasks := []*models.Task{
+		{ID: "pending-task", Status: models.TaskPending, CreatedAt: now.Add(2 * time.Second)},
+		{ID: "running-task", Status: models.TaskRunning, CreatedAt: now.Add(time.Second)},
+		{ID: "completed-task", Status: models.TaskCompleted, CreatedAt: now},
+	}
+	for _, task := range tasks {
+		if err := repo.CreateTask(ctx, task); err != nil {
+			t.Fatalf("CreateTask failed: %v", err)
+		}
+	}
+
+	result, err := repo.ListTasksByStatus(ctx, models.TaskPending, models.TaskRunning)
+	if err != nil {
+		t.Fatalf("ListTasksByStatus failed: %v", err)
+	}
+
+	if len(result) != 2 {
+		t.Fatalf("Expected 2 tasks, got %d", len(result))
+	}
+
+	// 按创建时间升序返回
+	if result[0].ID != "running-task" || result[1].ID != "pending-task" {
+		t.Errorf("Unexpected order: %s, %s", result[0].ID, result[1].ID)
+	}
+}
+
+func TestMemoryRepository_Settings(t *testing.T) {
+	repo := NewMemoryRepository()
+	ctx := context.Background()
+
+	if _, err := repo.GetSetting(ctx, "missing"); err != ErrSettingNotFound {
+		t.Errorf("Expected ErrSettingNotFound, got %v", err)
+	}
+
+	if err := repo.SetSetting(ctx, "key", "v1"); err != nil {
+		t.Fatalf("Failed to set setting: %v", err)
+	}
+	if err := repo.SetSetting(ctx, "key", "v2"); err != nil {
+		t.Fatalf("Failed to overwrite setting: %v", err)
+	}
+
+	value, err := repo.GetSetting(ctx, "key")
+	if err != nil {
+		t.Fatalf("Failed to get setting: %v", err)
+	}
+	if value != "v2" {
+		t.Errorf("Expected v2, got %s", value)
+	}
+}
+
+func TestMemoryRepository_TaskEvents(t *testing.T) {
+	repo := NewMemoryRepository()
+	ctx := context.Background()
+
+	repo.CreateTask(ctx, &models.Task{ID: "task-1", Status: models.TaskCompleted, CreatedAt: time.Now()})
+	for _, eventType := range []models.TaskEventType{models.TaskEventStatus, models.TaskEventBatchStarted, models.TaskEventNodeResult} {
+		event := &models.TaskEvent{TaskID: "task-1", Type: eventType, Timestamp: time.Now()}
+		if err := repo.CreateTaskEvent(ctx, event); err != nil {
+			t.Fatalf("CreateTaskEvent failed: %v", err)
+		}
+		if event.ID == 0 {
+			t.Errorf("Expected event ID to be set")
+		}
+	}
+	repo.CreateTaskEvent(ctx, &models.TaskEvent{TaskID: "task-2", Type: models.TaskEventStatus})
+
+	events, err := repo.ListTaskEvents(ctx, "task-1")
+	if err != nil {
+		t.Fatalf("ListTaskEvents failed: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(events))
+	}
+	if events[1].Type != models.TaskEventBatchStarted {
+		t.Errorf("Expected events in order, got %s at index 1", events[1].Type)
+	}
+
+	// 删除任务时一并删除事件
+	repo.DeleteTask(ctx, "task-1")
+	events, _ = repo.ListTaskEvents(ctx, "task-1")
+	if len(events) != 0 {
+		t.Errorf("Expected events to be deleted with task, got %d", len(events))
+	}
+}
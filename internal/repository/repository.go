@@ -15,6 +15,12 @@ var (
 	ErrTaskAlreadyExists = errors.New("task already exists")
 	// ErrScheduledTaskNotFound 定时任务不存在
 	ErrScheduledTaskNotFound = errors.New("scheduled task not found")
+	// ErrImagePolicyNotFound 镜像常驻策略不存在
+	ErrImagePolicyNotFound = errors.New("image policy not found")
+	// ErrNodeLogNotFound 节点没有保存日志
+	ErrNodeLogNotFound = errors.New("node log not found")
+	// ErrSettingNotFound 配置项不存在
+	ErrSettingNotFound = errors.New("setting not found")
 	// ErrCronExpressionInvalid Cron 表达式无效
 	ErrCronExpressionInvalid = errors.New("invalid cron expression")
 )
@@ -30,11 +36,54 @@ type TaskRepository interface {
 	// ListTasks 列出任务
 	ListTasks(ctx context.Context, offset, limit int) ([]*models.Task, int, error)
 
+	// ListTasksByStatus 按状态列出任务（按创建时间升序，用于启动时恢复未结束的任务）
+	ListTasksByStatus(ctx context.Context, statuses ...models.TaskStatus) ([]*models.Task, error)
+
 	// UpdateTask 更新任务
+	// 数据库中已暂停或已结束（completed/failed/cancelled）的任务不会被写回 pending/running（执行器持有的旧状态），
+	// 暂停与恢复只能通过 UpdateTaskStatus
 	UpdateTask(ctx context.Context, task *models.Task) error
 
-	// DeleteTask 删除任务
+	// UpdateTaskStatus 任务当前状态属于 from 时将其更新为 status，返回是否更新
+	// 不存在的任务返回 ErrTaskNotFound
+	UpdateTaskStatus(ctx context.Context, id string, status models.TaskStatus, from ...models.TaskStatus) (bool, error)
+
+	// DeleteTask 删除任务及其事件与节点日志
 	DeleteTask(ctx context.Context, id string) error
+
+	// SaveNodeLog 保存节点失败时采集的日志，每个节点只保留最近一次
+	SaveNodeLog(ctx context.Context, taskID string, nodeLog *models.NodeLog) error
+
+	// GetNodeLog 获取节点的日志，不存在时返回 ErrNodeLogNotFound
+	GetNodeLog(ctx context.Context, taskID, nodeName string) (*models.NodeLog, error)
+}
+
+// TaskEventRepository 任务事件（时间线）存储接口
+type TaskEventRepository interface {
+	// CreateTaskEvent 保存任务事件，设置事件 ID
+	CreateTaskEvent(ctx context.Context, event *models.TaskEvent) error
+
+	// ListTaskEvents 按发生顺序列出任务的所有事件
+	ListTaskEvents(ctx context.Context, taskID string) ([]*models.TaskEvent, error)
+
+	// ListTaskEventsAfter 按序号顺序列出序号大于 afterID 的事件，最多 limit 条
+	// taskID 为空时包括所有任务
+	ListTaskEventsAfter(ctx context.Context, taskID string, afterID int64, limit int) ([]*models.TaskEvent, error)
+
+	// LatestTaskEventID 返回最大的事件序号，没有事件时返回 0
+	LatestTaskEventID(ctx context.Context) (int64, error)
+
+	// DeleteOldTaskEvents 删除指定时间之前的事件，返回删除的数量
+	DeleteOldTaskEvents(ctx context.Context, before time.Time) (int64, error)
+}
+
+// SettingsRepository 系统配置存储接口
+type SettingsRepository interface {
+	// GetSetting 获取配置项，不存在时返回 ErrSettingNotFound
+	GetSetting(ctx context.Context, key string) (string, error)
+
+	// SetSetting 保存配置项
+	SetSetting(ctx context.Context, key, value string) error
 }
 
 // UserRepository 用户存储接口
@@ -122,6 +171,27 @@ type ScheduledTaskRepository interface {
 	DeleteScheduledTask(ctx context.Context, id string) error
 }
 
+// ImagePolicyRepository 镜像常驻策略存储接口
+type ImagePolicyRepository interface {
+	// CreateImagePolicy 创建策略
+	CreateImagePolicy(ctx context.Context, policy *models.ImagePolicy) error
+
+	// GetImagePolicy 获取策略，不存在时返回 ErrImagePolicyNotFound
+	GetImagePolicy(ctx context.Context, id string) (*models.ImagePolicy, error)
+
+	// ListImagePolicies 列出所有策略（按创建时间降序）
+	ListImagePolicies(ctx context.Context) ([]*models.ImagePolicy, error)
+
+	// UpdateImagePolicy 更新策略定义，不修改协调状态
+	UpdateImagePolicy(ctx context.Context, policy *models.ImagePolicy) error
+
+	// UpdateImagePolicyState 更新策略的协调状态
+	UpdateImagePolicyState(ctx context.Context, id string, state *models.ImagePolicyState) error
+
+	// DeleteImagePolicy 删除策略
+	DeleteImagePolicy(ctx context.Context, id string) error
+}
+
 // ScheduledExecutionRepository 定时任务执行历史存储接口
 type ScheduledExecutionRepository interface {
 	// CreateExecution 创建执行记录
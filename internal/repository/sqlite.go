@@ -150,8 +150,61 @@ func (r *SQLiteRepository) initSchema() error {
 		FOREIGN KEY (scheduled_task_id) REFERENCES scheduled_tasks(id) ON DELETE CASCADE
 	);`
 
+	// 镜像常驻策略表
+	imagePolicySchema := `
+	CREATE TABLE IF NOT EXISTS image_policies (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		description TEXT,
+		enabled INTEGER NOT NULL DEFAULT 1,
+		task_config TEXT NOT NULL,
+		state TEXT,
+		created_by TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);`
+
+	// 系统配置表
+	settingsSchema := `
+	CREATE TABLE IF NOT EXISTS settings (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL,
+		updated_at DATETIME NOT NULL
+	);`
+
+	// 任务事件表（时间线）
+	taskEventSchema := `
+	CREATE TABLE IF NOT EXISTS task_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id TEXT NOT NULL,
+		type TEXT NOT NULL,
+		timestamp DATETIME NOT NULL,
+		status TEXT,
+		batch INTEGER,
+		node_name TEXT,
+		node_status TEXT,
+		attempt INTEGER,
+		reason TEXT,
+		message TEXT,
+		progress TEXT
+	);`
+
+	// 节点失败日志表，每个节点只保留最近一次失败的日志
+	nodeLogSchema := `
+	CREATE TABLE IF NOT EXISTS task_node_logs (
+		task_id TEXT NOT NULL,
+		node_name TEXT NOT NULL,
+		attempt INTEGER,
+		job_name TEXT,
+		pod_name TEXT,
+		logs TEXT,
+		truncated INTEGER NOT NULL DEFAULT 0,
+		captured_at DATETIME NOT NULL,
+		PRIMARY KEY (task_id, node_name)
+	);`
+
 	// 创建基础表
-	for _, schema := range []string{taskSchema, userSchema, tokenSchema, librarySchema, secretSchema, scheduledTaskSchema, scheduledExecutionSchema} {
+	for _, schema := range []string{taskSchema, userSchema, tokenSchema, librarySchema, secretSchema, scheduledTaskSchema, scheduledExecutionSchema, imagePolicySchema, settingsSchema, taskEventSchema, nodeLogSchema} {
 		if _, err := r.db.Exec(schema); err != nil {
 			return err
 		}
@@ -163,6 +216,25 @@ func (r *SQLiteRepository) initSchema() error {
 		"ALTER TABLE tasks ADD COLUMN registry TEXT",
 		"ALTER TABLE tasks ADD COLUMN username TEXT",
 		"ALTER TABLE tasks ADD COLUMN password TEXT",
+		"ALTER TABLE tasks ADD COLUMN node_selector TEXT",
+		"ALTER TABLE tasks ADD COLUMN target_nodes TEXT",
+		"ALTER TABLE tasks ADD COLUMN retry_count INTEGER DEFAULT 0",
+		"ALTER TABLE tasks ADD COLUMN created_by TEXT",
+		"ALTER TABLE tasks ADD COLUMN batch_mode TEXT",
+		"ALTER TABLE tasks ADD COLUMN batch_ratio REAL",
+		"ALTER TABLE tasks ADD COLUMN window_size INTEGER",
+		"ALTER TABLE tasks ADD COLUMN skip_present INTEGER DEFAULT 0",
+		"ALTER TABLE tasks ADD COLUMN pull_parallelism INTEGER DEFAULT 0",
+		"ALTER TABLE tasks ADD COLUMN image_pull_timeout INTEGER DEFAULT 0",
+		"ALTER TABLE tasks ADD COLUMN timeout_seconds INTEGER DEFAULT 0",
+		"ALTER TABLE tasks ADD COLUMN node_attempts TEXT",
+		"ALTER TABLE tasks ADD COLUMN node_selection TEXT",
+		"ALTER TABLE tasks ADD COLUMN image_digests TEXT",
+		"ALTER TABLE tasks ADD COLUMN rollout TEXT",
+		"ALTER TABLE tasks ADD COLUMN canary TEXT",
+		"ALTER TABLE tasks ADD COLUMN success_policy TEXT",
+		"ALTER TABLE tasks ADD COLUMN required_nodes TEXT",
+		"ALTER TABLE tasks ADD COLUMN workloads TEXT",
 	}
 
 	for _, migration := range migrations {
@@ -171,6 +243,9 @@ func (r *SQLiteRepository) initSchema() error {
 	}
 
 	indexes := []string{
+		"CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks(status)",
+		"CREATE INDEX IF NOT EXISTS idx_task_events_task_id ON task_events(task_id, id)",
+		"CREATE INDEX IF NOT EXISTS idx_task_events_timestamp ON task_events(timestamp)",
 		"CREATE INDEX IF NOT EXISTS idx_scheduled_tasks_enabled ON scheduled_tasks(enabled)",
 		"CREATE INDEX IF NOT EXISTS idx_scheduled_tasks_next_execution ON scheduled_tasks(next_execution_at)",
 		"CREATE INDEX IF NOT EXISTS idx_scheduled_executions_task_id ON scheduled_executions(scheduled_task_id)",
@@ -186,20 +261,130 @@ func (r *SQLiteRepository) initSchema() error {
 
 // TaskRepository Implementation
 
+// taskColumns tasks 表查询列（与 scanTask 的字段顺序保持一致）
+const taskColumns = `id, images, batch_size, priority, max_retries, retry_count, retry_delay, retry_strategy,
+	webhook_url, status, progress, node_statuses, failed_nodes, error_message, secret_id, registry, username, password,
+	node_selector, target_nodes, created_by, batch_mode, batch_ratio, window_size, skip_present, pull_parallelism, image_pull_timeout, timeout_seconds, node_attempts, node_selection, image_digests, rollout, canary, success_policy, required_nodes, workloads, created_at, started_at, finished_at`
+
+// rowScanner 兼容 *sql.Row 与 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTask 从查询结果中解析任务
+func scanTask(row rowScanner) (*models.Task, error) {
+	var task models.Task
+	var imagesJSON, progressJSON, nodeStatsJSON, failedNodesJSON, nodeSelectorJSON, targetNodesJSON, nodeAttemptsJSON, nodeSelectionJSON, imageDigestsJSON, rolloutJSON, canaryJSON, successPolicyJSON, requiredNodesJSON, workloadsJSON []byte
+	var retryCount sql.NullInt64
+	var secretID sql.NullInt64
+	var registry, username, password, createdBy, batchMode sql.NullString
+	var batchRatio sql.NullFloat64
+	var windowSize sql.NullInt64
+	var skipPresent sql.NullBool
+	var pullParallelism, imagePullTimeout, timeoutSeconds sql.NullInt64
+
+	err := row.Scan(&task.ID, &imagesJSON, &task.BatchSize, &task.Priority, &task.MaxRetries, &retryCount, &task.RetryDelay, &task.RetryStrategy,
+		&task.WebhookURL, &task.Status, &progressJSON, &nodeStatsJSON, &failedNodesJSON, &task.ErrorMessage,
+		&secretID, &registry, &username, &password,
+		&nodeSelectorJSON, &targetNodesJSON, &createdBy, &batchMode, &batchRatio, &windowSize, &skipPresent, &pullParallelism, &imagePullTimeout, &timeoutSeconds, &nodeAttemptsJSON, &nodeSelectionJSON, &imageDigestsJSON, &rolloutJSON, &canaryJSON, &successPolicyJSON, &requiredNodesJSON, &workloadsJSON,
+		&task.CreatedAt, &task.StartedAt, &task.FinishedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	task.RetryCount = int(retryCount.Int64)
+	task.SecretID = secretID.Int64
+	task.Registry = registry.String
+	task.Username = username.String
+	task.Password = password.String
+	task.CreatedBy = createdBy.String
+	task.BatchMode = batchMode.String
+	task.BatchRatio = batchRatio.Float64
+	task.WindowSize = int(windowSize.Int64)
+	task.SkipPresent = skipPresent.Bool
+	task.PullParallelism = int(pullParallelism.Int64)
+	task.ImagePullTimeout = int(imagePullTimeout.Int64)
+	task.TimeoutSeconds = int(timeoutSeconds.Int64)
+
+	json.Unmarshal(imagesJSON, &task.Images)
+	json.Unmarshal(progressJSON, &task.Progress)
+	task.NodeResults = decodeNodeResults(nodeStatsJSON)
+	json.Unmarshal(failedNodesJSON, &task.FailedNodes)
+	json.Unmarshal(nodeSelectorJSON, &task.NodeSelector)
+	json.Unmarshal(targetNodesJSON, &task.TargetNodes)
+	json.Unmarshal(nodeAttemptsJSON, &task.NodeAttempts)
+	json.Unmarshal(nodeSelectionJSON, &task.NodeSelection)
+	json.Unmarshal(imageDigestsJSON, &task.ImageDigests)
+	json.Unmarshal(rolloutJSON, &task.Rollout)
+	json.Unmarshal(canaryJSON, &task.Canary)
+	json.Unmarshal(successPolicyJSON, &task.SuccessPolicy)
+	json.Unmarshal(requiredNodesJSON, &task.RequiredNodes)
+	json.Unmarshal(workloadsJSON, &task.Workloads)
+
+	return &task, nil
+}
+
+// decodeNodeResults 解析 node_statuses 列
+// 兼容旧版本保存的 nodeName -> imageName -> 1/0 格式
+func decodeNodeResults(data []byte) map[string]*models.NodeResult {
+	if len(data) == 0 {
+		return nil
+	}
+
+	var legacy map[string]map[string]int
+	if err := json.Unmarshal(data, &legacy); err == nil {
+		if legacy == nil {
+			return nil
+		}
+		results := make(map[string]*models.NodeResult, len(legacy))
+		for nodeName, images := range legacy {
+			var pulls []models.ImagePullResult
+			for image, status := range images {
+				pull := models.ImagePullResult{Image: image, Status: models.ImagePullSucceeded}
+				if status != 1 {
+					pull.Status = models.ImagePullFailed
+				}
+				pulls = append(pulls, pull)
+			}
+			results[nodeName] = models.NewNodeResult(nodeName, pulls)
+			if len(pulls) == 0 {
+				// 旧格式中空结果表示 Job 失败
+				results[nodeName].Status = models.NodeResultFailed
+			}
+		}
+		return results
+	}
+
+	var results map[string]*models.NodeResult
+	json.Unmarshal(data, &results)
+	return results
+}
+
 func (r *SQLiteRepository) CreateTask(ctx context.Context, task *models.Task) error {
 	imagesJSON, _ := json.Marshal(task.Images)
 	progressJSON, _ := json.Marshal(task.Progress)
-	nodeStatsJSON, _ := json.Marshal(task.NodeStatuses)
+	nodeStatsJSON, _ := json.Marshal(task.NodeResults)
 	failedNodesJSON, _ := json.Marshal(task.FailedNodes)
-
-	query := `INSERT INTO tasks (id, images, batch_size, priority, max_retries, retry_delay, retry_strategy,
-		webhook_url, status, progress, node_statuses, failed_nodes, error_message, secret_id, registry, username, password, created_at, started_at, finished_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	nodeSelectorJSON, _ := json.Marshal(task.NodeSelector)
+	targetNodesJSON, _ := json.Marshal(task.TargetNodes)
+	nodeAttemptsJSON, _ := json.Marshal(task.NodeAttempts)
+	nodeSelectionJSON, _ := json.Marshal(task.NodeSelection)
+	imageDigestsJSON, _ := json.Marshal(task.ImageDigests)
+	rolloutJSON, _ := json.Marshal(task.Rollout)
+	canaryJSON, _ := json.Marshal(task.Canary)
+	successPolicyJSON, _ := json.Marshal(task.SuccessPolicy)
+	requiredNodesJSON, _ := json.Marshal(task.RequiredNodes)
+	workloadsJSON, _ := json.Marshal(task.Workloads)
+
+	query := `INSERT INTO tasks (` + taskColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	_, err := r.db.ExecContext(ctx, query,
-		task.ID, imagesJSON, task.BatchSize, task.Priority, task.MaxRetries, task.RetryDelay, task.RetryStrategy,
+		task.ID, imagesJSON, task.BatchSize, task.Priority, task.MaxRetries, task.RetryCount, task.RetryDelay, task.RetryStrategy,
 		task.WebhookURL, task.Status, progressJSON, nodeStatsJSON, failedNodesJSON, task.ErrorMessage,
-		task.SecretID, task.Registry, task.Username, task.Password, task.CreatedAt, task.StartedAt, task.FinishedAt)
+		task.SecretID, task.Registry, task.Username, task.Password,
+		nodeSelectorJSON, targetNodesJSON, task.CreatedBy, task.BatchMode, task.BatchRatio, task.WindowSize, task.SkipPresent, task.PullParallelism, task.ImagePullTimeout, task.TimeoutSeconds, nodeAttemptsJSON, nodeSelectionJSON, imageDigestsJSON, rolloutJSON, canaryJSON, successPolicyJSON, requiredNodesJSON, workloadsJSON,
+		task.CreatedAt, task.StartedAt, task.FinishedAt)
 	return err
 }
 
@@ -236,35 +421,69 @@ func (r *SQLiteRepository) execWithRetry(ctx context.Context, execFunc func() (s
 
 func (r *SQLiteRepository) UpdateTask(ctx context.Context, task *models.Task) error {
 	progressJSON, _ := json.Marshal(task.Progress)
-	nodeStatsJSON, _ := json.Marshal(task.NodeStatuses)
+	nodeStatsJSON, _ := json.Marshal(task.NodeResults)
 	failedNodesJSON, _ := json.Marshal(task.FailedNodes)
+	targetNodesJSON, _ := json.Marshal(task.TargetNodes)
+	nodeAttemptsJSON, _ := json.Marshal(task.NodeAttempts)
+	canaryJSON, _ := json.Marshal(task.Canary)
+	requiredNodesJSON, _ := json.Marshal(task.RequiredNodes)
 
-	query := `UPDATE tasks SET status=?, progress=?, node_statuses=?, failed_nodes=?, error_message=?, 
-		started_at=?, finished_at=? WHERE id=?`
+	// 执行器持有的 pending/running 状态不覆盖期间（可能由其他副本）写入的 paused 与终态
+	query := `UPDATE tasks SET status=CASE WHEN status IN ('paused','completed','failed','cancelled') AND ? IN ('pending','running') THEN status ELSE ? END,
+		progress=?, node_statuses=?, failed_nodes=?, error_message=?, 
+		retry_count=?, target_nodes=?, node_attempts=?, canary=?, required_nodes=?, started_at=?, finished_at=? WHERE id=?`
 
 	_, err := r.execWithRetry(ctx, func() (sql.Result, error) {
 		return r.db.ExecContext(ctx, query,
-			task.Status, progressJSON, nodeStatsJSON, failedNodesJSON, task.ErrorMessage,
-			task.StartedAt, task.FinishedAt, task.ID)
+			task.Status, task.Status, progressJSON, nodeStatsJSON, failedNodesJSON, task.ErrorMessage,
+			task.RetryCount, targetNodesJSON, nodeAttemptsJSON, canaryJSON, requiredNodesJSON, task.StartedAt, task.FinishedAt, task.ID)
 	})
 
 	return err
 }
 
-func (r *SQLiteRepository) GetTask(ctx context.Context, id string) (*models.Task, error) {
-	query := `SELECT id, images, batch_size, priority, max_retries, retry_delay, retry_strategy,
-		webhook_url, status, progress, node_statuses, failed_nodes, error_message, secret_id, registry, username, password, created_at, started_at, finished_at
-		FROM tasks WHERE id = ?`
+func (r *SQLiteRepository) UpdateTaskStatus(ctx context.Context, id string, status models.TaskStatus, from ...models.TaskStatus) (bool, error) {
+	if len(from) == 0 {
+		return false, nil
+	}
 
-	row := r.db.QueryRowContext(ctx, query, id)
-	var task models.Task
-	var imagesJSON, progressJSON, nodeStatsJSON, failedNodesJSON []byte
+	args := []interface{}{status, id}
+	placeholders := make([]string, len(from))
+	for i, s := range from {
+		placeholders[i] = "?"
+		args = append(args, s)
+	}
+	query := `UPDATE tasks SET status=? WHERE id=? AND status IN (` + strings.Join(placeholders, ",") + `)`
 
-	err := row.Scan(&task.ID, &imagesJSON, &task.BatchSize, &task.Priority, &task.MaxRetries, &task.RetryDelay, &task.RetryStrategy,
-		&task.WebhookURL, &task.Status, &progressJSON, &nodeStatsJSON, &failedNodesJSON, &task.ErrorMessage,
-		&task.SecretID, &task.Registry, &task.Username, &task.Password,
-		&task.CreatedAt, &task.StartedAt, &task.FinishedAt)
+	result, err := r.execWithRetry(ctx, func() (sql.Result, error) {
+		return r.db.ExecContext(ctx, query, args...)
+	})
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rows > 0 {
+		return true, nil
+	}
+
+	// 区分任务不存在与状态不匹配
+	var exists int
+	if err := r.db.QueryRowContext(ctx, "SELECT 1 FROM tasks WHERE id = ?", id).Scan(&exists); err != nil {
+		if err == sql.ErrNoRows {
+			return false, ErrTaskNotFound
+		}
+		return false, err
+	}
+	return false, nil
+}
+
+func (r *SQLiteRepository) GetTask(ctx context.Context, id string) (*models.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE id = ?`
 
+	task, err := scanTask(r.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
 		return nil, ErrTaskNotFound
 	}
@@ -272,12 +491,7 @@ func (r *SQLiteRepository) GetTask(ctx context.Context, id string) (*models.Task
 		return nil, err
 	}
 
-	json.Unmarshal(imagesJSON, &task.Images)
-	json.Unmarshal(progressJSON, &task.Progress)
-	json.Unmarshal(nodeStatsJSON, &task.NodeStatuses)
-	json.Unmarshal(failedNodesJSON, &task.FailedNodes)
-
-	return &task, nil
+	return task, nil
 }
 
 func (r *SQLiteRepository) ListTasks(ctx context.Context, offset, limit int) ([]*models.Task, int, error) {
@@ -288,9 +502,7 @@ func (r *SQLiteRepository) ListTasks(ctx context.Context, offset, limit int) ([]
 		return nil, 0, err
 	}
 
-	query := `SELECT id, images, batch_size, priority, max_retries, retry_delay, retry_strategy,
-		webhook_url, status, progress, node_statuses, failed_nodes, error_message, secret_id, registry, username, password, created_at, started_at, finished_at
-		FROM tasks ORDER BY created_at DESC LIMIT ? OFFSET ?`
+	query := `SELECT ` + taskColumns + ` FROM tasks ORDER BY created_at DESC LIMIT ? OFFSET ?`
 
 	rows, err := r.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
@@ -300,25 +512,44 @@ func (r *SQLiteRepository) ListTasks(ctx context.Context, offset, limit int) ([]
 
 	var tasks []*models.Task
 	for rows.Next() {
-		var task models.Task
-		var imagesJSON, progressJSON, nodeStatsJSON, failedNodesJSON []byte
-
-		err := rows.Scan(&task.ID, &imagesJSON, &task.BatchSize, &task.Priority, &task.MaxRetries, &task.RetryDelay, &task.RetryStrategy,
-			&task.WebhookURL, &task.Status, &progressJSON, &nodeStatsJSON, &failedNodesJSON, &task.ErrorMessage,
-			&task.SecretID, &task.Registry, &task.Username, &task.Password,
-			&task.CreatedAt, &task.StartedAt, &task.FinishedAt)
+		task, err := scanTask(rows)
 		if err != nil {
 			return nil, 0, err
 		}
+		tasks = append(tasks, task)
+	}
+	return tasks, total, nil
+}
 
-		json.Unmarshal(imagesJSON, &task.Images)
-		json.Unmarshal(progressJSON, &task.Progress)
-		json.Unmarshal(nodeStatsJSON, &task.NodeStatuses)
-		json.Unmarshal(failedNodesJSON, &task.FailedNodes)
+func (r *SQLiteRepository) ListTasksByStatus(ctx context.Context, statuses ...models.TaskStatus) ([]*models.Task, error) {
+	if len(statuses) == 0 {
+		return nil, nil
+	}
 
-		tasks = append(tasks, &task)
+	placeholders := make([]string, len(statuses))
+	args := make([]interface{}, len(statuses))
+	for i, status := range statuses {
+		placeholders[i] = "?"
+		args[i] = status
 	}
-	return tasks, total, nil
+
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE status IN (` + strings.Join(placeholders, ", ") + `) ORDER BY created_at ASC`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []*models.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
 }
 
 func (r *SQLiteRepository) DeleteTask(ctx context.Context, id string) error {
@@ -328,9 +559,161 @@ func (r *SQLiteRepository) DeleteTask(ctx context.Context, id string) error {
 	_, err := r.execWithRetry(ctx, func() (sql.Result, error) {
 		return r.db.ExecContext(ctx, "DELETE FROM tasks WHERE id = ?", id)
 	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.execWithRetry(ctx, func() (sql.Result, error) {
+		return r.db.ExecContext(ctx, "DELETE FROM task_events WHERE task_id = ?", id)
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.execWithRetry(ctx, func() (sql.Result, error) {
+		return r.db.ExecContext(ctx, "DELETE FROM task_node_logs WHERE task_id = ?", id)
+	})
+	return err
+}
+
+func (r *SQLiteRepository) SaveNodeLog(ctx context.Context, taskID string, nodeLog *models.NodeLog) error {
+	query := `INSERT INTO task_node_logs (task_id, node_name, attempt, job_name, pod_name, logs, truncated, captured_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(task_id, node_name) DO UPDATE SET attempt = excluded.attempt, job_name = excluded.job_name,
+		pod_name = excluded.pod_name, logs = excluded.logs, truncated = excluded.truncated, captured_at = excluded.captured_at`
+
+	_, err := r.execWithRetry(ctx, func() (sql.Result, error) {
+		return r.db.ExecContext(ctx, query, taskID, nodeLog.NodeName, nodeLog.Attempt, nodeLog.JobName, nodeLog.PodName,
+			nodeLog.Logs, nodeLog.Truncated, nodeLog.CapturedAt)
+	})
 	return err
 }
 
+func (r *SQLiteRepository) GetNodeLog(ctx context.Context, taskID, nodeName string) (*models.NodeLog, error) {
+	query := `SELECT node_name, attempt, job_name, pod_name, logs, truncated, captured_at
+		FROM task_node_logs WHERE task_id = ? AND node_name = ?`
+
+	var nodeLog models.NodeLog
+	var attempt sql.NullInt64
+	var jobName, podName, logs sql.NullString
+	err := r.db.QueryRowContext(ctx, query, taskID, nodeName).Scan(&nodeLog.NodeName, &attempt, &jobName, &podName,
+		&logs, &nodeLog.Truncated, &nodeLog.CapturedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNodeLogNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	nodeLog.Attempt = int(attempt.Int64)
+	nodeLog.JobName = jobName.String
+	nodeLog.PodName = podName.String
+	nodeLog.Logs = logs.String
+	return &nodeLog, nil
+}
+
+// TaskEventRepository Implementation
+
+func (r *SQLiteRepository) CreateTaskEvent(ctx context.Context, event *models.TaskEvent) error {
+	var progressJSON []byte
+	if event.Progress != nil {
+		progressJSON, _ = json.Marshal(event.Progress)
+	}
+
+	query := `INSERT INTO task_events (task_id, type, timestamp, status, batch, node_name, node_status, attempt, reason, message, progress)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	res, err := r.execWithRetry(ctx, func() (sql.Result, error) {
+		return r.db.ExecContext(ctx, query,
+			event.TaskID, event.Type, event.Timestamp, event.Status, event.Batch, event.NodeName,
+			event.Result, event.Attempt, event.Reason, event.Message, progressJSON)
+	})
+	if err != nil {
+		return err
+	}
+	id, _ := res.LastInsertId()
+	event.ID = id
+	return nil
+}
+
+func (r *SQLiteRepository) ListTaskEvents(ctx context.Context, taskID string) ([]*models.TaskEvent, error) {
+	query := `SELECT ` + taskEventColumns + ` FROM task_events WHERE task_id = ? ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanTaskEvents(rows)
+}
+
+func (r *SQLiteRepository) ListTaskEventsAfter(ctx context.Context, taskID string, afterID int64, limit int) ([]*models.TaskEvent, error) {
+	query := `SELECT ` + taskEventColumns + ` FROM task_events WHERE id > ?`
+	args := []interface{}{afterID}
+	if taskID != "" {
+		query += " AND task_id = ?"
+		args = append(args, taskID)
+	}
+	query += " ORDER BY id LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanTaskEvents(rows)
+}
+
+func (r *SQLiteRepository) LatestTaskEventID(ctx context.Context) (int64, error) {
+	var id sql.NullInt64
+	if err := r.db.QueryRowContext(ctx, "SELECT MAX(id) FROM task_events").Scan(&id); err != nil {
+		return 0, err
+	}
+	return id.Int64, nil
+}
+
+func (r *SQLiteRepository) DeleteOldTaskEvents(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.execWithRetry(ctx, func() (sql.Result, error) {
+		return r.db.ExecContext(ctx, "DELETE FROM task_events WHERE timestamp < ?", before)
+	})
+	if err != nil {
+		return 0, err
+	}
+	rows, _ := result.RowsAffected()
+	return rows, nil
+}
+
+const taskEventColumns = `id, task_id, type, timestamp, status, batch, node_name, node_status, attempt, reason, message, progress`
+
+func scanTaskEvents(rows *sql.Rows) ([]*models.TaskEvent, error) {
+	var events []*models.TaskEvent
+	for rows.Next() {
+		var event models.TaskEvent
+		var status, nodeName, nodeStatus, reason, message sql.NullString
+		var batch, attempt sql.NullInt64
+		var progressJSON []byte
+		err := rows.Scan(&event.ID, &event.TaskID, &event.Type, &event.Timestamp, &status, &batch,
+			&nodeName, &nodeStatus, &attempt, &reason, &message, &progressJSON)
+		if err != nil {
+			return nil, err
+		}
+
+		event.Status = models.TaskStatus(status.String)
+		event.Batch = int(batch.Int64)
+		event.NodeName = nodeName.String
+		event.Result = models.NodeResultStatus(nodeStatus.String)
+		event.Attempt = int(attempt.Int64)
+		event.Reason = reason.String
+		event.Message = message.String
+		if len(progressJSON) > 0 {
+			json.Unmarshal(progressJSON, &event.Progress)
+		}
+		events = append(events, &event)
+	}
+	return events, rows.Err()
+}
+
 // UserRepository Implementation
 
 func (r *SQLiteRepository) CreateUser(ctx context.Context, user *models.User) error {
@@ -720,6 +1103,116 @@ func (r *SQLiteRepository) DeleteScheduledTask(ctx context.Context, id string) e
 	return err
 }
 
+// ImagePolicyRepository Implementation
+
+// imagePolicyColumns image_policies 表查询列（与 scanImagePolicy 的字段顺序保持一致）
+const imagePolicyColumns = `id, name, description, enabled, task_config, state, created_by, created_at, updated_at`
+
+func scanImagePolicy(row rowScanner) (*models.ImagePolicy, error) {
+	var policy models.ImagePolicy
+	var description sql.NullString
+	var taskConfigJSON, stateJSON []byte
+
+	err := row.Scan(&policy.ID, &policy.Name, &description, &policy.Enabled, &taskConfigJSON, &stateJSON,
+		&policy.CreatedBy, &policy.CreatedAt, &policy.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	policy.Description = description.String
+	json.Unmarshal(taskConfigJSON, &policy.TaskConfig)
+	json.Unmarshal(stateJSON, &policy.State)
+	return &policy, nil
+}
+
+func (r *SQLiteRepository) CreateImagePolicy(ctx context.Context, policy *models.ImagePolicy) error {
+	now := time.Now()
+	policy.CreatedAt = now
+	policy.UpdatedAt = now
+	taskConfigJSON, _ := json.Marshal(policy.TaskConfig)
+	stateJSON, _ := json.Marshal(policy.State)
+
+	query := `INSERT INTO image_policies (` + imagePolicyColumns + `) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.ExecContext(ctx, query,
+		policy.ID, policy.Name, policy.Description, policy.Enabled, taskConfigJSON, stateJSON,
+		policy.CreatedBy, policy.CreatedAt, policy.UpdatedAt)
+	return err
+}
+
+func (r *SQLiteRepository) GetImagePolicy(ctx context.Context, id string) (*models.ImagePolicy, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+imagePolicyColumns+` FROM image_policies WHERE id = ?`, id)
+	policy, err := scanImagePolicy(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrImagePolicyNotFound
+	}
+	return policy, err
+}
+
+func (r *SQLiteRepository) ListImagePolicies(ctx context.Context) ([]*models.ImagePolicy, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+imagePolicyColumns+` FROM image_policies ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := []*models.ImagePolicy{}
+	for rows.Next() {
+		policy, err := scanImagePolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, rows.Err()
+}
+
+func (r *SQLiteRepository) UpdateImagePolicy(ctx context.Context, policy *models.ImagePolicy) error {
+	policy.UpdatedAt = time.Now()
+	taskConfigJSON, _ := json.Marshal(policy.TaskConfig)
+
+	query := `UPDATE image_policies SET name=?, description=?, enabled=?, task_config=?, updated_at=? WHERE id=?`
+	result, err := r.execWithRetry(ctx, func() (sql.Result, error) {
+		return r.db.ExecContext(ctx, query,
+			policy.Name, policy.Description, policy.Enabled, taskConfigJSON, policy.UpdatedAt, policy.ID)
+	})
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrImagePolicyNotFound
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) UpdateImagePolicyState(ctx context.Context, id string, state *models.ImagePolicyState) error {
+	stateJSON, _ := json.Marshal(state)
+
+	result, err := r.execWithRetry(ctx, func() (sql.Result, error) {
+		return r.db.ExecContext(ctx, `UPDATE image_policies SET state=? WHERE id=?`, stateJSON, id)
+	})
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrImagePolicyNotFound
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) DeleteImagePolicy(ctx context.Context, id string) error {
+	r.deleteMutex.Lock()
+	defer r.deleteMutex.Unlock()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM image_policies WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrImagePolicyNotFound
+	}
+	return nil
+}
+
 // ScheduledExecutionRepository Implementation
 
 func (r *SQLiteRepository) CreateExecution(ctx context.Context, execution *models.ScheduledExecution) error {
@@ -853,3 +1346,27 @@ func (r *SQLiteRepository) DeleteOldExecutions(ctx context.Context, before time.
 	rows, _ := result.RowsAffected()
 	return rows, nil
 }
+
+// SettingsRepository Implementation
+
+func (r *SQLiteRepository) GetSetting(ctx context.Context, key string) (string, error) {
+	var value string
+	err := r.db.QueryRowContext(ctx, "SELECT value FROM settings WHERE key = ?", key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", ErrSettingNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+func (r *SQLiteRepository) SetSetting(ctx context.Context, key, value string) error {
+	query := `INSERT INTO settings (key, value, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`
+
+	_, err := r.execWithRetry(ctx, func() (sql.Result, error) {
+		return r.db.ExecContext(ctx, query, key, value, time.Now())
+	})
+	return err
+}
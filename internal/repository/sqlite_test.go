@@ -0,0 +1,352 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kitsnail/ips/pkg/models"
+)
+
+// newTestSQLiteRepository 在临时目录中创建 SQLite 存储（:memory: 的每个连接是独立的数据库）
+func newTestSQLiteRepository(t *testing.T) *SQLiteRepository {
+	t.Helper()
+
+	repo, err := NewSQLiteRepository(filepath.Join(t.TempDir(), "ips.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	t.Cleanup(func() { repo.db.Close() })
+	return repo
+}
+
+func TestSQLiteRepository_UpdateTaskStatus(t *testing.T) {
+	repo := newTestSQLiteRepository(t)
+	ctx := context.Background()
+
+	task := &models.Task{
+		ID:        "test-task-pause",
+		Status:    models.TaskRunning,
+		Images:    []string{"nginx:latest"},
+		BatchSize: 10,
+		CreatedAt: time.Now(),
+	}
+	if err := repo.CreateTask(ctx, task); err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+
+	// 只有状态匹配时更新
+	updated, err := repo.UpdateTaskStatus(ctx, task.ID, models.TaskPaused, models.TaskPending, models.TaskRunning)
+	if err != nil || !updated {
+		t.Fatalf("UpdateTaskStatus() = %v, %v, want true", updated, err)
+	}
+	updated, err = repo.UpdateTaskStatus(ctx, task.ID, models.TaskPaused, models.TaskPending, models.TaskRunning)
+	if err != nil || updated {
+		t.Errorf("UpdateTaskStatus() on paused task = %v, %v, want false", updated, err)
+	}
+	if _, err := repo.UpdateTaskStatus(ctx, "missing", models.TaskPaused, models.TaskRunning); err != ErrTaskNotFound {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+
+	// 执行器持有的 running 不覆盖 paused，其他字段正常保存
+	task.Progress = &models.Progress{CurrentBatch: 2}
+	if err := repo.UpdateTask(ctx, task); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	retrieved, err := repo.GetTask(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if retrieved.Status != models.TaskPaused {
+		t.Errorf("Expected Status %s, got %s", models.TaskPaused, retrieved.Status)
+	}
+	if retrieved.Progress == nil || retrieved.Progress.CurrentBatch != 2 {
+		t.Errorf("Expected progress to be saved, got %+v", retrieved.Progress)
+	}
+
+	// 终态可以覆盖 paused
+	task.Status = models.TaskCancelled
+	if err := repo.UpdateTask(ctx, task); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	retrieved, _ = repo.GetTask(ctx, task.ID)
+	if retrieved.Status != models.TaskCancelled {
+		t.Errorf("Expected Status %s, got %s", models.TaskCancelled, retrieved.Status)
+	}
+}
+
+func TestSQLiteRepository_UpdateTask_KeepsTerminalStatus(t *testing.T) {
+	repo := newTestSQLiteRepository(t)
+	ctx := context.Background()
+
+	for _, status := range []models.TaskStatus{models.TaskCancelled, models.TaskCompleted, models.TaskFailed} {
+		// 执行器持有 running 的副本，期间其他副本将任务写为终态
+		task := &models.Task{ID: "task-" + string(status), Status: models.TaskRunning, CreatedAt: time.Now()}
+		if err := repo.CreateTask(ctx, task); err != nil {
+			t.Fatalf("CreateTask failed: %v", err)
+		}
+		stale := *task
+		if updated, err := repo.UpdateTaskStatus(ctx, task.ID, status, models.TaskRunning); err != nil || !updated {
+			t.Fatalf("UpdateTaskStatus() = %v, %v", updated, err)
+		}
+
+		// 之后写入的 running 不覆盖终态，其他字段正常保存
+		stale.Progress = &models.Progress{CompletedNodes: 3}
+		if err := repo.UpdateTask(ctx, &stale); err != nil {
+			t.Fatalf("UpdateTask failed: %v", err)
+		}
+		retrieved, err := repo.GetTask(ctx, task.ID)
+		if err != nil {
+			t.Fatalf("GetTask failed: %v", err)
+		}
+		if retrieved.Status != status {
+			t.Errorf("Expected Status %s, got %s", status, retrieved.Status)
+		}
+		if retrieved.Progress == nil || retrieved.Progress.CompletedNodes != 3 {
+			t.Errorf("Expected progress to be saved, got %+v", retrieved.Progress)
+		}
+
+		stale.Status = models.TaskPending
+		repo.UpdateTask(ctx, &stale)
+		if retrieved, _ = repo.GetTask(ctx, task.ID); retrieved.Status != status {
+			t.Errorf("Expected Status %s after pending write, got %s", status, retrieved.Status)
+		}
+	}
+}
+
+func TestSQLiteRepository_MigratesBaselineDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ips.db")
+	ctx := context.Background()
+
+	// 旧版本只有基础列，node_statuses 为 nodeName -> imageName -> 1/0 格式
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("sql.Open failed: %v", err)
+	}
+	_, err = db.Exec(`CREATE TABLE tasks (
+		id TEXT PRIMARY KEY,
+		images TEXT,
+		batch_size INTEGER,
+		priority INTEGER,
+		max_retries INTEGER,
+		retry_delay INTEGER,
+		retry_strategy TEXT,
+		webhook_url TEXT,
+		status TEXT,
+		progress TEXT,
+		node_statuses TEXT,
+		failed_nodes TEXT,
+		error_message TEXT,
+		created_at DATETIME,
+		started_at DATETIME,
+		finished_at DATETIME
+	)`)
+	if err != nil {
+		t.Fatalf("create baseline schema failed: %v", err)
+	}
+	_, err = db.Exec(`INSERT INTO tasks (id, images, batch_size, priority, max_retries, retry_delay, retry_strategy, webhook_url, status, progress, node_statuses, failed_nodes, error_message, created_at, started_at, finished_at)
+		VALUES (?, ?, 10, 5, 0, 30, 'linear', '', 'completed', ?, ?, '[]', '', ?, NULL, NULL)`,
+		"legacy-task", `["nginx:latest"]`, `{"totalNodes":2,"completedNodes":1}`,
+		`{"node-1":{"nginx:latest":1},"node-2":{}}`, time.Now())
+	if err != nil {
+		t.Fatalf("insert baseline task failed: %v", err)
+	}
+	db.Close()
+
+	repo, err := NewSQLiteRepository(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository on baseline database failed: %v", err)
+	}
+	defer repo.db.Close()
+
+	// 迁移新增的列均为 NULL，读取时使用零值
+	task, err := repo.GetTask(ctx, "legacy-task")
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if task.Status != models.TaskCompleted || len(task.Images) != 1 || task.Images[0] != "nginx:latest" {
+		t.Errorf("Unexpected task: %+v", task)
+	}
+	if task.SecretID != 0 || task.Registry != "" || task.CreatedBy != "" || task.SkipPresent || task.NodeSelection != nil || task.Rollout != nil || task.Canary != nil {
+		t.Errorf("Expected zero values for migrated columns, got %+v", task)
+	}
+	if got := task.NodeResults["node-1"]; got == nil || got.Status != models.NodeResultSucceeded {
+		t.Errorf("Expected node-1 to succeed, got %+v", got)
+	}
+	if got := task.NodeResults["node-2"]; got == nil || got.Status != models.NodeResultFailed {
+		t.Errorf("Expected node-2 to fail, got %+v", got)
+	}
+
+	tasks, total, err := repo.ListTasks(ctx, 0, 10)
+	if err != nil || total != 1 || len(tasks) != 1 {
+		t.Fatalf("ListTasks() = %d tasks, total %d, err %v", len(tasks), total, err)
+	}
+
+	// 迁移后的表可以写入新列
+	task.TargetNodes = []string{"node-1", "node-2"}
+	task.RequiredNodes = []string{"node-1"}
+	if err := repo.UpdateTask(ctx, task); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	retrieved, _ := repo.GetTask(ctx, task.ID)
+	if len(retrieved.TargetNodes) != 2 || len(retrieved.RequiredNodes) != 1 {
+		t.Errorf("Expected migrated columns to be saved, got %+v", retrieved)
+	}
+
+	// 重复打开时迁移不报错
+	reopened, err := NewSQLiteRepository(path)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	reopened.db.Close()
+}
+
+func TestSQLiteRepository_ScanTaskWithNullColumns(t *testing.T) {
+	repo := newTestSQLiteRepository(t)
+	ctx := context.Background()
+
+	_, err := repo.db.Exec(`INSERT INTO tasks (id, images, batch_size, priority, max_retries, retry_delay, retry_strategy, webhook_url, status, progress, node_statuses, failed_nodes, error_message, created_at)
+		VALUES ('null-task', '["redis:7"]', 1, 5, 0, 30, 'linear', '', 'pending', NULL, NULL, NULL, '', ?)`, time.Now())
+	if err != nil {
+		t.Fatalf("insert task failed: %v", err)
+	}
+
+	task, err := repo.GetTask(ctx, "null-task")
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if task.Progress != nil || task.NodeResults != nil || task.FailedNodes != nil || task.StartedAt != nil || task.FinishedAt != nil {
+		t.Errorf("Expected nil fields for NULL columns, got %+v", task)
+	}
+	if task.RetryCount != 0 || task.WindowSize != 0 || task.BatchRatio != 0 || task.TimeoutSeconds != 0 {
+		t.Errorf("Expected zero values for NULL columns, got %+v", task)
+	}
+}
+
+func TestSQLiteRepository_TaskEvents(t *testing.T) {
+	repo := newTestSQLiteRepository(t)
+	ctx := context.Background()
+
+	latest, err := repo.LatestTaskEventID(ctx)
+	if err != nil || latest != 0 {
+		t.Fatalf("LatestTaskEventID() on empty table = %d, %v", latest, err)
+	}
+
+	for _, taskID := range []string{"task-a", "task-b"} {
+		if err := repo.CreateTask(ctx, &models.Task{ID: taskID, Status: models.TaskRunning, CreatedAt: time.Now()}); err != nil {
+			t.Fatalf("CreateTask failed: %v", err)
+		}
+	}
+
+	old := time.Now().Add(-48 * time.Hour)
+	events := []*models.TaskEvent{
+		{TaskID: "task-a", Type: models.TaskEventStatus, Timestamp: old, Status: models.TaskPending},
+		{TaskID: "task-b", Type: models.TaskEventStatus, Timestamp: old},
+		{TaskID: "task-a", Type: models.TaskEventBatchStarted, Timestamp: time.Now(), Batch: 1, Progress: &models.Progress{CurrentBatch: 1, TotalBatches: 2}},
+		{TaskID: "task-a", Type: models.TaskEventNodeResult, Timestamp: time.Now(), NodeName: "node-1", Result: models.NodeResultSucceeded, Attempt: 2, Message: "ok"},
+	}
+	for _, event := range events {
+		if err := repo.CreateTaskEvent(ctx, event); err != nil {
+			t.Fatalf("CreateTaskEvent failed: %v", err)
+		}
+		if event.ID == 0 {
+			t.Fatalf("Expected CreateTaskEvent to assign an ID")
+		}
+	}
+
+	latest, err = repo.LatestTaskEventID(ctx)
+	if err != nil || latest != events[3].ID {
+		t.Errorf("LatestTaskEventID() = %d, %v, want %d", latest, err, events[3].ID)
+	}
+
+	// 按任务过滤，只返回指定 ID 之后的事件
+	after, err := repo.ListTaskEventsAfter(ctx, "task-a", events[0].ID, 10)
+	if err != nil {
+		t.Fatalf("ListTaskEventsAfter failed: %v", err)
+	}
+	if len(after) != 2 || after[0].ID != events[2].ID || after[1].ID != events[3].ID {
+		t.Fatalf("Expected events after %d for task-a, got %+v", events[0].ID, after)
+	}
+	if after[0].Batch != 1 || after[0].Progress == nil || after[0].Progress.TotalBatches != 2 {
+		t.Errorf("Expected batch event fields to round-trip, got %+v", after[0])
+	}
+	if after[1].NodeName != "node-1" || after[1].Result != models.NodeResultSucceeded || after[1].Attempt != 2 || after[1].Message != "ok" {
+		t.Errorf("Expected node event fields to round-trip, got %+v", after[1])
+	}
+
+	// 不指定任务时返回所有任务的事件，并受 limit 限制
+	global, err := repo.ListTaskEventsAfter(ctx, "", 0, 3)
+	if err != nil {
+		t.Fatalf("ListTaskEventsAfter failed: %v", err)
+	}
+	if len(global) != 3 || global[1].TaskID != "task-b" {
+		t.Errorf("Expected first 3 events of all tasks, got %+v", global)
+	}
+
+	// 清理过期事件
+	deleted, err := repo.DeleteOldTaskEvents(ctx, time.Now().Add(-24*time.Hour))
+	if err != nil || deleted != 2 {
+		t.Errorf("DeleteOldTaskEvents() = %d, %v, want 2", deleted, err)
+	}
+
+	// 删除任务时一并删除其事件
+	if err := repo.DeleteTask(ctx, "task-a"); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+	remaining, err := repo.ListTaskEventsAfter(ctx, "", 0, 10)
+	if err != nil || len(remaining) != 0 {
+		t.Errorf("Expected no events after deleting task, got %d, %v", len(remaining), err)
+	}
+}
+
+func TestSQLiteRepository_NodeLogs(t *testing.T) {
+	repo := newTestSQLiteRepository(t)
+	ctx := context.Background()
+
+	if err := repo.CreateTask(ctx, &models.Task{ID: "task-logs", Status: models.TaskRunning, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	if _, err := repo.GetNodeLog(ctx, "task-logs", "node-1"); err != ErrNodeLogNotFound {
+		t.Fatalf("Expected ErrNodeLogNotFound, got %v", err)
+	}
+
+	// 同一节点只保留最近一次的日志
+	for attempt := 1; attempt <= 2; attempt++ {
+		err := repo.SaveNodeLog(ctx, "task-logs", &models.NodeLog{
+			NodeName:   "node-1",
+			Attempt:    attempt,
+			JobName:    "prewarm-task-logs-node-1",
+			PodName:    "prewarm-task-logs-node-1-abcde",
+			Logs:       "pull failed\n",
+			Truncated:  attempt == 2,
+			CapturedAt: time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("SaveNodeLog failed: %v", err)
+		}
+	}
+
+	nodeLog, err := repo.GetNodeLog(ctx, "task-logs", "node-1")
+	if err != nil {
+		t.Fatalf("GetNodeLog failed: %v", err)
+	}
+	if nodeLog.Attempt != 2 || !nodeLog.Truncated || nodeLog.Logs != "pull failed\n" || nodeLog.PodName != "prewarm-task-logs-node-1-abcde" {
+		t.Errorf("Unexpected node log: %+v", nodeLog)
+	}
+
+	// 更新任务不影响日志，删除任务时一并删除
+	if err := repo.UpdateTask(ctx, &models.Task{ID: "task-logs", Status: models.TaskFailed}); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	if _, err := repo.GetNodeLog(ctx, "task-logs", "node-1"); err != nil {
+		t.Errorf("Expected node log to survive UpdateTask, got %v", err)
+	}
+	if err := repo.DeleteTask(ctx, "task-logs"); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+	if _, err := repo.GetNodeLog(ctx, "task-logs", "node-1"); err != ErrNodeLogNotFound {
+		t.Errorf("Expected ErrNodeLogNotFound after DeleteTask, got %v", err)
+	}
+}
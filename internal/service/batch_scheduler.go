@@ -3,75 +3,143 @@ package service
 import (
 	"context"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/kitsnail/ips/internal/k8s"
 	"github.com/kitsnail/ips/pkg/metrics"
+	"github.com/kitsnail/ips/pkg/models"
 	"github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // BatchScheduler 批次调度器
 type BatchScheduler struct {
-	jobCreator *k8s.JobCreator
-	logger     *logrus.Logger
+	jobCreator   *k8s.JobCreator
+	logger       *logrus.Logger
+	pollInterval time.Duration // pipelined/window 模式下检查 Job 状态的间隔
+	events       *EventBroker  // 由 TaskManager 设置
+	informer     *k8s.PrewarmInformer
+
+	stuckPodGracePeriod time.Duration // Pod 卡住超过该时间后其 Job 视为已失败
+	batchWaitTimeout    time.Duration // 等待批次达到完成比例的最长时间，0 表示不限制
+
+	// pauseCheck 返回任务是否已被暂停，由 TaskManager 设置
+	pauseCheck func(ctx context.Context, task *models.Task) bool
 }
 
 // NewBatchScheduler 创建批次调度器
 func NewBatchScheduler(jobCreator *k8s.JobCreator, logger *logrus.Logger) *BatchScheduler {
 	return &BatchScheduler{
-		jobCreator: jobCreator,
-		logger:     logger,
+		jobCreator:          jobCreator,
+		logger:              logger,
+		pollInterval:        5 * time.Second,
+		stuckPodGracePeriod: defaultStuckPodGracePeriod,
+		batchWaitTimeout:    defaultBatchWaitTimeout,
 	}
 }
 
+// defaultBatchWaitTimeout 等待批次结束的默认最长时间
+const defaultBatchWaitTimeout = 30 * time.Minute
+
+// cacheSyncTimeout 等待共享 Informer 缓存观察到新建 Job 的最长时间
+const cacheSyncTimeout = 10 * time.Second
+
+// SetStuckPodGracePeriod 设置 Pod 卡住多久后其 Job 在批次等待中视为已失败，与状态跟踪器保持一致
+func (s *BatchScheduler) SetStuckPodGracePeriod(gracePeriod time.Duration) {
+	if gracePeriod > 0 {
+		s.stuckPodGracePeriod = gracePeriod
+	}
+}
+
+// SetBatchWaitTimeout 设置等待批次达到完成比例的最长时间，超时后继续提交下一批次，0 表示不限制
+func (s *BatchScheduler) SetBatchWaitTimeout(timeout time.Duration) {
+	if timeout >= 0 {
+		s.batchWaitTimeout = timeout
+	}
+}
+
+// SetInformer 设置共享 Informer，缓存同步后从其读取任务的 Job 与 Pod，避免每次轮询都查询 API Server
+func (s *BatchScheduler) SetInformer(informer *k8s.PrewarmInformer) {
+	s.informer = informer
+}
+
 // ExecuteBatches 分批执行任务
-// taskID: 任务ID
+// task: 任务（提供 ID、镜像、批次大小、批次模式及 Secret 名称）
 // nodes: 目标节点列表
-// images: 要预热的镜像列表
-// batchSize: 每批次的节点数
-// secretName: 可选的包含凭据的 Secret 名称
-// onBatchComplete: 每批次完成后的回调函数（批次号, 成功数, 失败数）
+// onBatchComplete: 每批次提交后的回调函数（批次号, 成功数, 失败数）
+//
+// immediate 模式依次提交所有批次；pipelined 模式在前一批次达到完成比例后才提交下一批次；
+// window 模式保持同时拉取的节点数不超过窗口大小，每提交 BatchSize 个节点回调一次
+// 每批次提交前检查任务是否已被暂停，暂停后返回 ErrTaskPaused，正在提交的批次不受影响
+// 设置了灰度策略时先提交金丝雀批次并等待其结束，成功率未达标时返回 ErrCanaryFailed
 func (s *BatchScheduler) ExecuteBatches(
 	ctx context.Context,
-	taskID string,
+	task *models.Task,
 	nodes []string,
-	images []string,
-	batchSize int,
-	secretName string,
 	onBatchComplete func(batchNum, succeeded, failed int),
 ) error {
+	// 本次调用中已回调的批次数，其余批次的批次号在此基础上累加
+	reported := 0
+	if task.Canary != nil && task.Canary.Phase == models.CanaryPending {
+		rest, submitted, err := s.executeCanary(ctx, task, nodes, onBatchComplete)
+		if err != nil || task.Canary.Phase != models.CanaryPassed {
+			return err
+		}
+		nodes = rest
+		if submitted {
+			reported = 1
+		}
+	}
+
+	if task.BatchMode == models.BatchModeWindow {
+		return s.executeWindow(ctx, task, nodes, reported, onBatchComplete)
+	}
+
 	// 分批
-	batches := s.splitBatches(nodes, batchSize)
+	batches := s.splitBatches(nodes, task.BatchSize)
+	batchOffset := submittedBatches(task)
 
 	s.logger.WithFields(logrus.Fields{
-		"taskId":       taskID,
+		"taskId":       task.ID,
 		"totalNodes":   len(nodes),
 		"totalBatches": len(batches),
-		"batchSize":    batchSize,
+		"batchSize":    task.BatchSize,
+		"batchMode":    task.BatchMode,
 	}).Info("Starting batch execution")
 
 	// 顺序执行每个批次
 	for i, batch := range batches {
-		batchNum := i + 1
+		batchNum := reported + i + 1
+
+		if s.deadlineExceeded(task) {
+			return nil
+		}
+		if s.paused(ctx, task) {
+			return ErrTaskPaused
+		}
 
 		s.logger.WithFields(logrus.Fields{
-			"taskId":    taskID,
+			"taskId":    task.ID,
 			"batchNum":  batchNum,
 			"batchSize": len(batch),
 		}).Info("Executing batch")
 
 		// 记录批次执行开始时间
 		batchStartTime := time.Now()
+		s.events.publishBatchStarted(task, batchOffset+batchNum, len(batch))
 
 		// 为批次中的每个节点创建Job
-		succeeded, failed := s.executeBatch(ctx, taskID, batch, images, secretName)
+		succeeded, failed := s.executeBatch(ctx, task, batch)
 
 		// 记录批次执行耗时
 		batchDuration := time.Since(batchStartTime).Seconds()
 		metrics.BatchExecutionDuration.Observe(batchDuration)
 
 		s.logger.WithFields(logrus.Fields{
-			"taskId":    taskID,
+			"taskId":    task.ID,
 			"batchNum":  batchNum,
 			"succeeded": succeeded,
 			"failed":    failed,
@@ -85,41 +153,447 @@ func (s *BatchScheduler) ExecuteBatches(
 		// 如果上下文被取消，停止执行
 		select {
 		case <-ctx.Done():
-			s.logger.WithField("taskId", taskID).Warn("Batch execution cancelled")
+			s.logger.WithField("taskId", task.ID).Warn("Batch execution cancelled")
 			return ctx.Err()
 		default:
 			// 继续下一批
 		}
+
+		// pipelined 模式：等待当前批次达到完成比例后再提交下一批
+		if task.BatchMode == models.BatchModePipelined && i+1 < len(batches) {
+			if err := s.waitForBatch(ctx, task, batch); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
 }
 
+// waitForBatch 等待批次中结束的节点数达到 BatchRatio
+func (s *BatchScheduler) waitForBatch(ctx context.Context, task *models.Task, batch []string) error {
+	ratio := task.BatchRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	return s.waitForNodes(ctx, task, batch, int(math.Ceil(ratio*float64(len(batch)))))
+}
+
+// waitForNodes 等待节点中结束的数量达到 required
+// Job 不存在（创建失败或已被 TTL 清理）或 Pod 卡住的节点视为已结束；等待超过 batchWaitTimeout 后不再等待
+func (s *BatchScheduler) waitForNodes(ctx context.Context, task *models.Task, batch []string, required int) error {
+	inBatch := make(map[string]bool, len(batch))
+	for _, node := range batch {
+		inBatch[node] = true
+	}
+
+	start := time.Now()
+	for {
+		if s.deadlineExceeded(task) {
+			return nil
+		}
+		if s.batchWaitTimeout > 0 && time.Since(start) >= s.batchWaitTimeout {
+			s.logger.WithFields(logrus.Fields{
+				"taskId":   task.ID,
+				"required": required,
+				"timeout":  s.batchWaitTimeout,
+			}).Warn("Timed out waiting for batch to finish, continuing")
+			return nil
+		}
+		if s.paused(ctx, task) {
+			return ErrTaskPaused
+		}
+
+		active, err := s.countActiveJobs(ctx, task.ID, inBatch)
+		if err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"taskId": task.ID,
+				"error":  err,
+			}).Warn("Failed to check batch progress")
+		} else if finished := len(batch) - active; finished >= required {
+			s.logger.WithFields(logrus.Fields{
+				"taskId":   task.ID,
+				"finished": finished,
+				"required": required,
+			}).Info("Batch reached completion threshold")
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.WithField("taskId", task.ID).Warn("Batch execution cancelled")
+			return ctx.Err()
+		case <-time.After(s.pollInterval):
+		}
+	}
+}
+
+// executeWindow 滑动窗口提交：正在拉取的节点数低于窗口大小时补充提交
+// base 为本次调用中已回调的批次数（金丝雀批次）
+func (s *BatchScheduler) executeWindow(
+	ctx context.Context,
+	task *models.Task,
+	nodes []string,
+	base int,
+	onBatchComplete func(batchNum, succeeded, failed int),
+) error {
+	window := task.WindowSize
+	if window <= 0 {
+		window = task.BatchSize
+	}
+	batchSize := task.BatchSize
+	if batchSize <= 0 {
+		batchSize = 10
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"taskId":     task.ID,
+		"totalNodes": len(nodes),
+		"windowSize": window,
+	}).Info("Starting sliding window execution")
+
+	batchOffset := submittedBatches(task)
+	submitted, reported := 0, 0
+	var succeeded, failed int
+	for submitted < len(nodes) {
+		if s.deadlineExceeded(task) {
+			return nil
+		}
+		if s.paused(ctx, task) {
+			return ErrTaskPaused
+		}
+
+		// 统计整个任务仍在运行的 Job（包括重启前已提交的 Job）
+		active, err := s.countActiveJobs(ctx, task.ID, nil)
+		if err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"taskId": task.ID,
+				"error":  err,
+			}).Warn("Failed to check window usage")
+		} else if free := window - active; free > 0 {
+			chunk := nodes[submitted:min(submitted+free, len(nodes))]
+			s.events.publishBatchStarted(task, batchOffset+base+reported+1, len(chunk))
+			ok, fail := s.createJobs(ctx, task, chunk)
+			succeeded += ok
+			failed += fail
+			submitted += len(chunk)
+
+			// 每提交满一个 BatchSize 回调一次，保持批次进度语义
+			batchNum := submitted / batchSize
+			if submitted == len(nodes) {
+				batchNum = (submitted + batchSize - 1) / batchSize
+			}
+			if batchNum > reported {
+				reported = batchNum
+				if onBatchComplete != nil {
+					onBatchComplete(base+batchNum, succeeded, failed)
+				}
+				succeeded, failed = 0, 0
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.WithField("taskId", task.ID).Warn("Batch execution cancelled")
+			return ctx.Err()
+		case <-time.After(s.pollInterval):
+		}
+	}
+
+	return nil
+}
+
+// executeCanary 提交尚未提交的金丝雀节点，等待所有金丝雀节点结束后评估成功率
+// 返回金丝雀之外的节点，以及本次是否提交了金丝雀批次；成功率未达标时返回 ErrCanaryFailed
+// 截止时间已过时不评估（Phase 保持 pending），由状态跟踪器将任务记为超时
+func (s *BatchScheduler) executeCanary(
+	ctx context.Context,
+	task *models.Task,
+	nodes []string,
+	onBatchComplete func(batchNum, succeeded, failed int),
+) ([]string, bool, error) {
+	inCanary := make(map[string]bool, len(task.Canary.Nodes))
+	for _, nodeName := range task.Canary.Nodes {
+		inCanary[nodeName] = true
+	}
+	var pending, rest []string
+	for _, nodeName := range nodes {
+		if inCanary[nodeName] {
+			pending = append(pending, nodeName)
+		} else {
+			rest = append(rest, nodeName)
+		}
+	}
+
+	if s.deadlineExceeded(task) {
+		return nil, false, nil
+	}
+
+	// 重启前已提交的金丝雀节点不重复提交，只等待其结束
+	submitted := len(pending) > 0
+	if submitted {
+		if s.paused(ctx, task) {
+			return nil, false, ErrTaskPaused
+		}
+
+		s.logger.WithFields(logrus.Fields{
+			"taskId":      task.ID,
+			"canaryNodes": len(pending),
+			"threshold":   task.Rollout.Threshold(),
+		}).Info("Executing canary batch")
+
+		s.events.publishBatchStarted(task, submittedBatches(task)+1, len(pending))
+		succeeded, failed := s.createJobs(ctx, task, pending)
+		if onBatchComplete != nil {
+			onBatchComplete(1, succeeded, failed)
+		}
+	}
+
+	if err := s.waitForNodes(ctx, task, task.Canary.Nodes, len(task.Canary.Nodes)); err != nil {
+		return nil, submitted, err
+	}
+	if s.deadlineExceeded(task) {
+		return nil, submitted, nil
+	}
+
+	if err := s.evaluateCanary(ctx, task); err != nil {
+		return nil, submitted, err
+	}
+	if task.Canary.Phase == models.CanaryFailed {
+		return nil, submitted, ErrCanaryFailed
+	}
+	return rest, submitted, nil
+}
+
+// evaluateCanary 按每个金丝雀节点最近一次 Job 的结果计算成功率
+// Job 不存在（创建失败）的节点计为失败
+func (s *BatchScheduler) evaluateCanary(ctx context.Context, task *models.Task) error {
+	jobs, err := s.listJobs(ctx, task.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list canary jobs: %w", err)
+	}
+	latest := latestJobsByNode(jobs)
+
+	canary := task.Canary
+	canary.Succeeded, canary.Failed, canary.FailedNodes = 0, 0, nil
+	for _, nodeName := range canary.Nodes {
+		if job := latest[nodeName]; job != nil && job.Status.Succeeded > 0 && !s.imagesFailed(ctx, task, job.Name) {
+			canary.Succeeded++
+		} else {
+			canary.Failed++
+			canary.FailedNodes = append(canary.FailedNodes, nodeName)
+		}
+	}
+
+	canary.SuccessRate = math.Round(float64(canary.Succeeded)/float64(len(canary.Nodes))*1000) / 10
+	canary.Threshold = task.Rollout.Threshold()
+	now := time.Now()
+	canary.EvaluatedAt = &now
+	canary.Phase = models.CanaryPassed
+	if canary.SuccessRate < canary.Threshold {
+		canary.Phase = models.CanaryFailed
+	}
+
+	message := fmt.Sprintf("canary %s: %d/%d nodes succeeded (%.1f%%, threshold %.1f%%)",
+		canary.Phase, canary.Succeeded, len(canary.Nodes), canary.SuccessRate, canary.Threshold)
+	s.logger.WithFields(logrus.Fields{
+		"taskId":      task.ID,
+		"phase":       canary.Phase,
+		"succeeded":   canary.Succeeded,
+		"failed":      canary.Failed,
+		"successRate": canary.SuccessRate,
+		"threshold":   canary.Threshold,
+	}).Info("Canary evaluated")
+	s.events.Publish(models.TaskEvent{
+		TaskID:   task.ID,
+		Type:     models.TaskEventCanary,
+		Message:  message,
+		Progress: snapshotProgress(task),
+	})
+	return nil
+}
+
+// splitCanary 按灰度策略取目标节点的前 N 个作为金丝雀节点
+// 金丝雀节点数不小于节点总数时不设金丝雀阶段，返回 nil
+func splitCanary(rollout *models.RolloutStrategy, nodes []string) (canary, rest []string) {
+	size := rollout.CanarySize(len(nodes))
+	if size <= 0 || size >= len(nodes) {
+		return nil, nodes
+	}
+	return nodes[:size], nodes[size:]
+}
+
+// deadlineExceeded 任务超过截止时间后停止提交剩余节点，由状态跟踪器将其记为超时
+func (s *BatchScheduler) deadlineExceeded(task *models.Task) bool {
+	if !task.DeadlineExceeded(time.Now()) {
+		return false
+	}
+	s.logger.WithField("taskId", task.ID).Warn("Task deadline exceeded, stop submitting jobs")
+	return true
+}
+
+// paused 任务被暂停后停止提交剩余节点，已创建的 Job 继续执行
+func (s *BatchScheduler) paused(ctx context.Context, task *models.Task) bool {
+	if s.pauseCheck == nil || !s.pauseCheck(ctx, task) {
+		return false
+	}
+	s.logger.WithField("taskId", task.ID).Info("Task paused, stop submitting jobs")
+	return true
+}
+
+// countActiveJobs 统计任务中尚未结束的 Job 数，nodes 不为空时只统计这些节点
+// Pod 卡住（无法调度、镜像或容器配置错误、被驱逐）的 Job 不会自行结束，视为已失败，不计入
+func (s *BatchScheduler) countActiveJobs(ctx context.Context, taskID string, nodes map[string]bool) (int, error) {
+	jobs, err := s.listJobs(ctx, taskID)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	active := 0
+	for i := range jobs {
+		job := &jobs[i]
+		if nodes != nil && !nodes[job.Labels["node"]] {
+			continue
+		}
+		if job.Status.Succeeded == 0 && job.Status.Failed == 0 && !s.jobStuck(ctx, job, now) {
+			active++
+		}
+	}
+	return active, nil
+}
+
+// jobStuck 判断运行中 Job 的 Pod 是否卡住，判定规则与状态跟踪器相同
+func (s *BatchScheduler) jobStuck(ctx context.Context, job *batchv1.Job, now time.Time) bool {
+	pods, err := s.listPods(ctx, job.Name)
+	if err != nil {
+		return false
+	}
+	for i := range pods {
+		if _, _, stuck := classifyStuckPod(&pods[i], s.stuckPodGracePeriod, now); stuck {
+			return true
+		}
+	}
+	return false
+}
+
 // executeBatch 执行单个批次
-func (s *BatchScheduler) executeBatch(ctx context.Context, taskID string, nodes []string, images []string, secretName string) (succeeded, failed int) {
-	// 为批次中的每个节点创建Job
+func (s *BatchScheduler) executeBatch(ctx context.Context, task *models.Task, nodes []string) (succeeded, failed int) {
+	succeeded, failed = s.createJobs(ctx, task, nodes)
+
+	// 等待一小段时间，避免创建Job过快导致API Server压力过大
+	if len(nodes) > 10 {
+		time.Sleep(2 * time.Second)
+	}
+
+	return succeeded, failed
+}
+
+// createJobs 为每个节点创建Job
+func (s *BatchScheduler) createJobs(ctx context.Context, task *models.Task, nodes []string) (succeeded, failed int) {
+	opts := jobOptions(task)
+	var created []string
 	for _, nodeName := range nodes {
-		err := s.jobCreator.CreateJob(ctx, taskID, nodeName, images, secretName)
+		err := s.jobCreator.CreateJob(ctx, task.ID, nodeName, task.Images, opts)
 		if err != nil {
 			s.logger.WithFields(logrus.Fields{
-				"taskId":   taskID,
+				"taskId":   task.ID,
 				"nodeName": nodeName,
 				"error":    err,
 			}).Error("Failed to create job")
+			s.events.Publish(models.TaskEvent{
+				TaskID:   task.ID,
+				Type:     models.TaskEventJobCreateFailed,
+				NodeName: nodeName,
+				Message:  err.Error(),
+			})
 			failed++
 			metrics.JobCreationTotal.WithLabelValues("failed").Inc()
 		} else {
 			succeeded++
+			created = append(created, k8s.JobName(task.ID, nodeName, opts.Attempt))
 			metrics.JobCreationTotal.WithLabelValues("success").Inc()
 		}
 	}
 
-	// 等待一小段时间，避免创建Job过快导致API Server压力过大
-	if len(nodes) > 10 {
-		time.Sleep(2 * time.Second)
+	s.waitForCache(ctx, task.ID, created)
+	return succeeded, failed
+}
+
+// cacheSynced 共享 Informer 是否可用
+func (s *BatchScheduler) cacheSynced() bool {
+	return s.informer != nil && s.informer.HasSynced()
+}
+
+// listJobs 列出任务的所有 Job，共享 Informer 已同步时从缓存读取，否则查询 API Server
+func (s *BatchScheduler) listJobs(ctx context.Context, taskID string) ([]batchv1.Job, error) {
+	if s.cacheSynced() {
+		return s.informer.ListJobsByTaskID(taskID)
 	}
+	return s.jobCreator.ListJobsByTaskID(ctx, taskID)
+}
 
-	return succeeded, failed
+// listPods 列出 Job 创建的 Pod，共享 Informer 已同步时从缓存读取，否则查询 API Server
+func (s *BatchScheduler) listPods(ctx context.Context, jobName string) ([]corev1.Pod, error) {
+	if s.cacheSynced() {
+		return s.informer.ListPodsByJobName(jobName)
+	}
+	client := s.jobCreator.GetK8sClient()
+	podList, err := client.Clientset.CoreV1().Pods(client.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("job-name=%s", jobName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods for job %s: %w", jobName, err)
+	}
+	return podList.Items, nil
+}
+
+// waitForCache 等待缓存观察到刚创建的 Job
+// 否则紧接着从缓存统计会漏掉这些 Job，pipelined 模式会提前提交下一批次，window 模式会超出窗口
+func (s *BatchScheduler) waitForCache(ctx context.Context, taskID string, jobNames []string) {
+	if len(jobNames) == 0 || !s.cacheSynced() {
+		return
+	}
+
+	timeout := time.After(cacheSyncTimeout)
+	for _, name := range jobNames {
+		for !s.informer.HasJob(name) {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timeout:
+				s.logger.WithFields(logrus.Fields{
+					"taskId":  taskID,
+					"jobName": name,
+				}).Warn("Timed out waiting for informer cache to observe created job")
+				return
+			case <-time.After(50 * time.Millisecond):
+			}
+		}
+	}
+}
+
+// submittedBatches 返回已提交的批次数（续提交时批次号在此基础上累加）
+func submittedBatches(task *models.Task) int {
+	if task.Progress == nil {
+		return 0
+	}
+	return task.Progress.CurrentBatch
+}
+
+// jobOptions 根据任务配置生成 Job 选项
+// 设置了任务超时时，Job 的 ActiveDeadlineSeconds 为距任务截止时间的剩余时间
+func jobOptions(task *models.Task) k8s.JobOptions {
+	opts := k8s.JobOptions{
+		SecretName:       task.SecretName,
+		PullParallelism:  task.PullParallelism,
+		ImagePullTimeout: time.Duration(task.ImagePullTimeout) * time.Second,
+	}
+	if deadline, ok := task.Deadline(); ok {
+		opts.ActiveDeadline = max(time.Until(deadline), time.Second)
+	}
+	return opts
 }
 
 // splitBatches 将节点列表分批
@@ -1,7 +1,18 @@
 package service
 
 import (
+	"context"
 	"testing"
+	"time"
+
+	"github.com/kitsnail/ips/internal/k8s"
+	"github.com/kitsnail/ips/pkg/models"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestBatchScheduler_SplitBatches(t *testing.T) {
@@ -119,3 +130,325 @@ func TestBatchScheduler_CalculateBatches(t *testing.T) {
 		})
 	}
 }
+
+// markJobFinished 模拟 Job 结束
+func markJobFinished(t *testing.T, k8sClient *k8s.Client, job batchv1.Job, succeeded bool) {
+	t.Helper()
+
+	if succeeded {
+		job.Status.Succeeded = 1
+	} else {
+		job.Status.Failed = 1
+	}
+	_, err := k8sClient.Clientset.BatchV1().Jobs(job.Namespace).UpdateStatus(context.Background(), &job, metav1.UpdateOptions{})
+	require.NoError(t, err)
+}
+
+func TestBatchScheduler_ExecuteBatches_Pipelined(t *testing.T) {
+	_, _, k8sClient := setupTaskManager(t)
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	scheduler := NewBatchScheduler(k8s.NewJobCreator(k8sClient, "", "", ""), logger)
+	scheduler.pollInterval = 20 * time.Millisecond
+
+	task := &models.Task{
+		ID:         "task-pipelined",
+		Images:     []string{"nginx:latest"},
+		BatchSize:  2,
+		BatchMode:  models.BatchModePipelined,
+		BatchRatio: 0.5,
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- scheduler.ExecuteBatches(context.Background(), task, []string{"node-1", "node-2", "node-3", "node-4"}, nil)
+	}()
+
+	// 第一批次提交后等待
+	jobs := waitForJobs(t, k8sClient, task.ID, 2)
+	time.Sleep(100 * time.Millisecond)
+	waitForJobs(t, k8sClient, task.ID, 2)
+
+	// 一半节点结束后提交第二批次
+	markJobFinished(t, k8sClient, jobs[0], false)
+	waitForJobs(t, k8sClient, task.ID, 4)
+	require.NoError(t, <-done)
+}
+
+func TestBatchScheduler_ExecuteBatches_PipelinedStuckPod(t *testing.T) {
+	_, _, k8sClient := setupTaskManager(t)
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	scheduler := NewBatchScheduler(k8s.NewJobCreator(k8sClient, "", "", ""), logger)
+	scheduler.pollInterval = 20 * time.Millisecond
+
+	task := &models.Task{
+		ID:        "task-pipelined-stuck",
+		Images:    []string{"nginx:latest"},
+		BatchSize: 1,
+		BatchMode: models.BatchModePipelined,
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- scheduler.ExecuteBatches(context.Background(), task, []string{"node-1", "node-2"}, nil)
+	}()
+	jobs := waitForJobs(t, k8sClient, task.ID, 1)
+
+	// Pod 长时间无法调度，Job 不会自行结束，视为已失败后提交下一批次
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobs[0].Name + "-abcde",
+			Namespace: "default",
+			Labels:    map[string]string{"job-name": jobs[0].Name},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			Conditions: []corev1.PodCondition{{
+				Type:               corev1.PodScheduled,
+				Status:             corev1.ConditionFalse,
+				Reason:             corev1.PodReasonUnschedulable,
+				LastTransitionTime: metav1.NewTime(time.Now().Add(-10 * time.Minute)),
+			}},
+		},
+	}
+	_, err := k8sClient.Clientset.CoreV1().Pods("default").Create(context.Background(), pod, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	waitForJobs(t, k8sClient, task.ID, 2)
+	require.NoError(t, <-done)
+}
+
+func TestBatchScheduler_ExecuteBatches_PipelinedWaitTimeout(t *testing.T) {
+	_, _, k8sClient := setupTaskManager(t)
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	scheduler := NewBatchScheduler(k8s.NewJobCreator(k8sClient, "", "", ""), logger)
+	scheduler.pollInterval = 20 * time.Millisecond
+	scheduler.SetBatchWaitTimeout(200 * time.Millisecond)
+
+	task := &models.Task{
+		ID:        "task-pipelined-timeout",
+		Images:    []string{"nginx:latest"},
+		BatchSize: 1,
+		BatchMode: models.BatchModePipelined,
+	}
+
+	// 第一批次一直未结束，等待超时后继续提交
+	require.NoError(t, scheduler.ExecuteBatches(context.Background(), task, []string{"node-1", "node-2"}, nil))
+	waitForJobs(t, k8sClient, task.ID, 2)
+}
+
+func TestBatchScheduler_ExecuteBatches_Window(t *testing.T) {
+	_, _, k8sClient := setupTaskManager(t)
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	scheduler := NewBatchScheduler(k8s.NewJobCreator(k8sClient, "", "", ""), logger)
+	scheduler.pollInterval = 20 * time.Millisecond
+
+	task := &models.Task{
+		ID:         "task-window",
+		Images:     []string{"nginx:latest"},
+		BatchSize:  1,
+		BatchMode:  models.BatchModeWindow,
+		WindowSize: 2,
+	}
+
+	var batches []int
+	done := make(chan error, 1)
+	go func() {
+		done <- scheduler.ExecuteBatches(context.Background(), task, []string{"node-1", "node-2", "node-3"}, func(batchNum, succeeded, failed int) {
+			batches = append(batches, batchNum)
+		})
+	}()
+
+	// 窗口已满，第三个节点等待
+	jobs := waitForJobs(t, k8sClient, task.ID, 2)
+	time.Sleep(100 * time.Millisecond)
+	waitForJobs(t, k8sClient, task.ID, 2)
+
+	markJobFinished(t, k8sClient, jobs[1], true)
+	waitForJobs(t, k8sClient, task.ID, 3)
+	require.NoError(t, <-done)
+	assert.Equal(t, []int{2, 3}, batches)
+}
+
+func TestBatchScheduler_ExecuteBatches_WindowInformer(t *testing.T) {
+	_, _, k8sClient := setupTaskManager(t)
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	scheduler := NewBatchScheduler(k8s.NewJobCreator(k8sClient, "", "", ""), logger)
+	scheduler.pollInterval = 20 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	informer, err := k8sClient.NewPrewarmInformer(0)
+	require.NoError(t, err)
+	informer.Start(ctx)
+	require.NoError(t, informer.WaitForCacheSync(ctx))
+	scheduler.SetInformer(informer)
+
+	task := &models.Task{
+		ID:         "task-window-informer",
+		Images:     []string{"nginx:latest"},
+		BatchSize:  1,
+		BatchMode:  models.BatchModeWindow,
+		WindowSize: 2,
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- scheduler.ExecuteBatches(ctx, task, []string{"node-1", "node-2", "node-3"}, nil)
+	}()
+
+	// 从缓存统计时同样不超出窗口
+	jobs := waitForJobs(t, k8sClient, task.ID, 2)
+	time.Sleep(100 * time.Millisecond)
+	waitForJobs(t, k8sClient, task.ID, 2)
+
+	markJobFinished(t, k8sClient, jobs[1], true)
+	waitForJobs(t, k8sClient, task.ID, 3)
+	require.NoError(t, <-done)
+}
+
+func TestBatchScheduler_ExecuteBatches_PullOptions(t *testing.T) {
+	_, _, k8sClient := setupTaskManager(t)
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	scheduler := NewBatchScheduler(k8s.NewJobCreator(k8sClient, "", "", ""), logger)
+
+	task := &models.Task{
+		ID:               "task-pull-options",
+		Images:           []string{"nginx:latest", "redis:7"},
+		BatchSize:        1,
+		PullParallelism:  2,
+		ImagePullTimeout: 120,
+	}
+	require.NoError(t, scheduler.ExecuteBatches(context.Background(), task, []string{"node-1"}, nil))
+
+	jobs := waitForJobs(t, k8sClient, task.ID, 1)
+	env := map[string]string{}
+	for _, e := range jobs[0].Spec.Template.Spec.Containers[0].Env {
+		env[e.Name] = e.Value
+	}
+	assert.Equal(t, "2", env["PULL_PARALLELISM"])
+	assert.Equal(t, "120", env["IMAGE_PULL_TIMEOUT_SECONDS"])
+}
+
+func TestBatchScheduler_ExecuteBatches_ActiveDeadline(t *testing.T) {
+	_, _, k8sClient := setupTaskManager(t)
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	scheduler := NewBatchScheduler(k8s.NewJobCreator(k8sClient, "", "", ""), logger)
+
+	startedAt := time.Now().Add(-time.Minute)
+	task := &models.Task{
+		ID:             "task-deadline",
+		Images:         []string{"nginx:latest"},
+		BatchSize:      1,
+		TimeoutSeconds: 300,
+		StartedAt:      &startedAt,
+	}
+	require.NoError(t, scheduler.ExecuteBatches(context.Background(), task, []string{"node-1"}, nil))
+
+	// ActiveDeadlineSeconds 为剩余时间
+	jobs := waitForJobs(t, k8sClient, task.ID, 1)
+	require.NotNil(t, jobs[0].Spec.ActiveDeadlineSeconds)
+	assert.InDelta(t, 240, *jobs[0].Spec.ActiveDeadlineSeconds, 2)
+
+	// 已超过截止时间，不再提交
+	expired := time.Now().Add(-10 * time.Minute)
+	task.ID = "task-expired"
+	task.StartedAt = &expired
+	require.NoError(t, scheduler.ExecuteBatches(context.Background(), task, []string{"node-1"}, nil))
+	waitForJobs(t, k8sClient, task.ID, 0)
+}
+
+func TestBatchScheduler_ExecuteBatches_RecordsTimeline(t *testing.T) {
+	taskManager, repo, _ := setupTaskManager(t,
+		newPrewarmJob("task-timeline", "node-2", batchv1.JobStatus{Active: 1}),
+	)
+
+	// 续提交时批次号在已提交的批次数基础上累加
+	task := &models.Task{
+		ID:        "task-timeline",
+		Images:    []string{"nginx:latest"},
+		BatchSize: 1,
+		Progress:  &models.Progress{CurrentBatch: 1, TotalBatches: 3},
+	}
+	require.NoError(t, taskManager.batchScheduler.ExecuteBatches(context.Background(), task, []string{"node-1", "node-2"}, nil))
+
+	// 事件异步写入，读取前等待写入完成
+	taskManager.Events().Flush()
+	events, err := repo.ListTaskEvents(context.Background(), task.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	assert.Equal(t, models.TaskEventBatchStarted, events[0].Type)
+	assert.Equal(t, 2, events[0].Batch)
+	assert.Equal(t, models.TaskEventBatchStarted, events[1].Type)
+	assert.Equal(t, 3, events[1].Batch)
+
+	// node-2 的 Job 已存在，创建失败
+	assert.Equal(t, models.TaskEventJobCreateFailed, events[2].Type)
+	assert.Equal(t, "node-2", events[2].NodeName)
+	assert.Contains(t, events[2].Message, "already exists")
+}
+
+func TestBatchScheduler_ExecuteBatches_Canary(t *testing.T) {
+	_, _, k8sClient := setupTaskManager(t)
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	scheduler := NewBatchScheduler(k8s.NewJobCreator(k8sClient, "", "", ""), logger)
+	scheduler.pollInterval = 20 * time.Millisecond
+
+	nodes := []string{"node-1", "node-2", "node-3", "node-4"}
+	newTask := func(id string) *models.Task {
+		task := &models.Task{
+			ID:        id,
+			Images:    []string{"nginx:latest"},
+			BatchSize: 1,
+			Rollout:   &models.RolloutStrategy{CanaryPercent: 50, SuccessThreshold: 100},
+		}
+		initCanary(task, nodes)
+		return task
+	}
+
+	// 金丝雀节点全部成功后继续提交其余节点，金丝雀为第一批次
+	task := newTask("task-canary-passed")
+	var batches []int
+	done := make(chan error, 1)
+	go func() {
+		done <- scheduler.ExecuteBatches(context.Background(), task, nodes, func(batchNum, succeeded, failed int) {
+			batches = append(batches, batchNum)
+		})
+	}()
+
+	jobs := waitForJobs(t, k8sClient, task.ID, 2)
+	time.Sleep(100 * time.Millisecond)
+	waitForJobs(t, k8sClient, task.ID, 2)
+	markJobFinished(t, k8sClient, jobs[0], true)
+	markJobFinished(t, k8sClient, jobs[1], true)
+
+	require.NoError(t, <-done)
+	waitForJobs(t, k8sClient, task.ID, 4)
+	assert.Equal(t, []int{1, 2, 3}, batches)
+	assert.Equal(t, models.CanaryPassed, task.Canary.Phase)
+	assert.Equal(t, float64(100), task.Canary.SuccessRate)
+
+	// 成功率未达标时不再提交其余节点
+	task = newTask("task-canary-failed")
+	go func() {
+		done <- scheduler.ExecuteBatches(context.Background(), task, nodes, nil)
+	}()
+
+	jobs = waitForJobs(t, k8sClient, task.ID, 2)
+	markJobFinished(t, k8sClient, jobs[0], true)
+	markJobFinished(t, k8sClient, jobs[1], false)
+
+	assert.ErrorIs(t, <-done, ErrCanaryFailed)
+	waitForJobs(t, k8sClient, task.ID, 2)
+	assert.Equal(t, models.CanaryFailed, task.Canary.Phase)
+	assert.Equal(t, 1, task.Canary.Failed)
+	assert.Equal(t, []string{jobs[1].Labels["node"]}, task.Canary.FailedNodes)
+}
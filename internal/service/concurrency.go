@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/kitsnail/ips/internal/repository"
+	"github.com/kitsnail/ips/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// concurrencyLimitsKey 并发限制在配置表中的 key
+const concurrencyLimitsKey = "concurrency_limits"
+
+// GetConcurrencyLimits 获取当前并发限制
+func (m *TaskManager) GetConcurrencyLimits() models.ConcurrencyLimits {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return copyLimits(m.limits)
+}
+
+// UpdateConcurrencyLimits 更新并发限制，持久化后立即生效
+// 调低限制不会中断正在执行的任务，只影响后续调度
+func (m *TaskManager) UpdateConcurrencyLimits(ctx context.Context, limits models.ConcurrencyLimits) error {
+	if err := limits.Validate(); err != nil {
+		return err
+	}
+
+	if m.settingsRepo != nil {
+		data, err := json.Marshal(limits)
+		if err != nil {
+			return fmt.Errorf("failed to encode concurrency limits: %w", err)
+		}
+		if err := m.settingsRepo.SetSetting(ctx, concurrencyLimitsKey, string(data)); err != nil {
+			return fmt.Errorf("failed to save concurrency limits: %w", err)
+		}
+	}
+
+	m.mu.Lock()
+	m.limits = copyLimits(limits)
+	m.mu.Unlock()
+
+	m.logger.WithFields(logrus.Fields{
+		"global":               limits.Global,
+		"perCreator":           limits.PerCreator,
+		"perNodeSelectorGroup": limits.PerNodeSelectorGroup,
+	}).Info("Concurrency limits updated")
+
+	// 调高限制后可能有排队任务可以执行
+	m.dispatch()
+	return nil
+}
+
+// ConcurrencyStats 返回并发限制及使用情况
+// Leader 直接读取调度使用的内存计数；Follower 不执行任务，按数据库中的任务状态统计
+func (m *TaskManager) ConcurrencyStats(ctx context.Context) (*models.ConcurrencyStats, error) {
+	if m.IsLeader() {
+		return m.localConcurrencyStats(), nil
+	}
+
+	tasks, err := m.repo.ListTasksByStatus(ctx, models.TaskPending, models.TaskRunning)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unfinished tasks: %w", err)
+	}
+
+	stats := &models.ConcurrencyStats{
+		Limits:                     m.GetConcurrencyLimits(),
+		RunningByCreator:           make(map[string]int),
+		RunningByNodeSelectorGroup: make(map[string]int),
+	}
+	for _, task := range tasks {
+		if task.Status == models.TaskPending {
+			stats.Queued++
+			continue
+		}
+		stats.Running++
+		if task.CreatedBy != "" {
+			stats.RunningByCreator[task.CreatedBy]++
+		}
+		stats.RunningByNodeSelectorGroup[nodeSelectorGroup(task)]++
+	}
+
+	return stats, nil
+}
+
+// localConcurrencyStats 返回本副本调度器的并发计数
+func (m *TaskManager) localConcurrencyStats() *models.ConcurrencyStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := &models.ConcurrencyStats{
+		Limits:                     copyLimits(m.limits),
+		Running:                    m.running,
+		Queued:                     m.queue.Size(),
+		RunningByCreator:           make(map[string]int, len(m.runningByCreator)),
+		RunningByNodeSelectorGroup: make(map[string]int, len(m.runningByGroup)),
+	}
+	for creator, n := range m.runningByCreator {
+		stats.RunningByCreator[creator] = n
+	}
+	for group, n := range m.runningByGroup {
+		stats.RunningByNodeSelectorGroup[group] = n
+	}
+	return stats
+}
+
+// ConcurrencySummary 返回并发使用情况汇总
+// 统计失败时记录日志并返回 nil，由调用方省略该部分
+func (m *TaskManager) ConcurrencySummary(ctx context.Context) *models.ConcurrencySummary {
+	stats, err := m.ConcurrencyStats(ctx)
+	if err != nil {
+		m.logger.WithField("error", err).Warn("Failed to get concurrency stats")
+		return nil
+	}
+	return &models.ConcurrencySummary{
+		GlobalLimit: stats.Limits.Global,
+		Running:     stats.Running,
+		Queued:      stats.Queued,
+	}
+}
+
+// loadConcurrencyLimits 从配置表加载并发限制，未保存过时保留当前值
+func (m *TaskManager) loadConcurrencyLimits(ctx context.Context) error {
+	if m.settingsRepo == nil {
+		return nil
+	}
+
+	value, err := m.settingsRepo.GetSetting(ctx, concurrencyLimitsKey)
+	if errors.Is(err, repository.ErrSettingNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var limits models.ConcurrencyLimits
+	if err := json.Unmarshal([]byte(value), &limits); err != nil {
+		return fmt.Errorf("failed to decode concurrency limits: %w", err)
+	}
+	if err := limits.Validate(); err != nil {
+		return fmt.Errorf("invalid concurrency limits: %w", err)
+	}
+
+	m.mu.Lock()
+	m.limits = limits
+	m.mu.Unlock()
+	return nil
+}
+
+// canRunLocked 检查任务的创建者与节点选择器分组是否还有空闲槽位，调用方需持有 mu
+func (m *TaskManager) canRunLocked(task *models.Task) bool {
+	if task.CreatedBy != "" {
+		if limit := m.limits.CreatorLimit(task.CreatedBy); limit > 0 && m.runningByCreator[task.CreatedBy] >= limit {
+			return false
+		}
+	}
+
+	group := nodeSelectorGroup(task)
+	if limit := m.limits.NodeSelectorGroupLimit(group); limit > 0 && m.runningByGroup[group] >= limit {
+		return false
+	}
+
+	return true
+}
+
+// acquireSlotLocked 占用任务的并发槽位，调用方需持有 mu
+func (m *TaskManager) acquireSlotLocked(task *models.Task) {
+	m.running++
+	if task.CreatedBy != "" {
+		m.runningByCreator[task.CreatedBy]++
+	}
+	m.runningByGroup[nodeSelectorGroup(task)]++
+}
+
+// releaseSlotLocked 释放任务的并发槽位，调用方需持有 mu
+func (m *TaskManager) releaseSlotLocked(task *models.Task) {
+	m.running--
+	if task.CreatedBy != "" {
+		if m.runningByCreator[task.CreatedBy]--; m.runningByCreator[task.CreatedBy] <= 0 {
+			delete(m.runningByCreator, task.CreatedBy)
+		}
+	}
+	group := nodeSelectorGroup(task)
+	if m.runningByGroup[group]--; m.runningByGroup[group] <= 0 {
+		delete(m.runningByGroup, group)
+	}
+}
+
+// nodeSelectorGroup 返回任务所属的节点选择器分组，同时考虑 nodeSelector 与 nodeSelection
+func nodeSelectorGroup(task *models.Task) string {
+	return models.TaskNodeSelectorGroup(task.NodeSelector, task.NodeSelection)
+}
+
+func copyLimits(limits models.ConcurrencyLimits) models.ConcurrencyLimits {
+	result := limits
+	if limits.CreatorOverrides != nil {
+		result.CreatorOverrides = make(map[string]int, len(limits.CreatorOverrides))
+		for k, v := range limits.CreatorOverrides {
+			result.CreatorOverrides[k] = v
+		}
+	}
+	if limits.NodeSelectorGroupOverrides != nil {
+		result.NodeSelectorGroupOverrides = make(map[string]int, len(limits.NodeSelectorGroupOverrides))
+		for k, v := range limits.NodeSelectorGroupOverrides {
+			result.NodeSelectorGroupOverrides[k] = v
+		}
+	}
+	return result
+}
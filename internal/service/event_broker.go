@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kitsnail/ips/internal/repository"
+	"github.com/kitsnail/ips/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultEventHistorySize 订阅所有任务时最多回放的事件数
+	defaultEventHistorySize = 1000
+	// subscriberBufferSize 订阅者的事件缓冲，消费过慢时断开订阅，由客户端携带 Last-Event-ID 重连
+	subscriberBufferSize = 256
+	// defaultEventPollInterval 轮询事件表的间隔，非 Leader 副本由此获得 Leader 写入的事件
+	defaultEventPollInterval = time.Second
+	// eventPageSize 每次从事件表读取的事件数
+	eventPageSize = 500
+	// eventQueueSize 等待写入存储的事件数上限，队列满时 Publish 阻塞
+	eventQueueSize = 1024
+	// defaultEventRetention 任务事件的默认保留时间
+	defaultEventRetention = 30 * 24 * time.Hour
+	// eventCleanupInterval Leader 清理过期事件的间隔
+	eventCleanupInterval = time.Hour
+)
+
+// EventBroker 任务事件的发布订阅
+// 事件由 TaskManager、BatchScheduler 与 StatusTracker 发布，由后台协程按发布顺序写入存储作为任务时间线，
+// 避免 SQLite 写入拖慢任务执行；
+// 订阅者从存储中回放与轮询事件，事件序号即存储的自增 ID，因此任意副本都能推送事件，
+// 服务重启或 Leader 切换后 Last-Event-ID 仍然有效
+type EventBroker struct {
+	store        repository.TaskEventRepository
+	logger       *logrus.Logger
+	historySize  int
+	pollInterval time.Duration
+	notify       chan struct{} // 本副本发布事件后唤醒轮询
+	queue        chan eventWrite
+	writerOnce   sync.Once
+
+	mu          sync.Mutex
+	lastID      int64 // 已推送给订阅者的最大事件序号
+	tailing     bool  // 是否正在轮询，没有订阅者时停止
+	subscribers map[*eventSubscriber]struct{}
+}
+
+// eventWrite 等待写入的事件，done 不为空时为 Flush 的标记
+type eventWrite struct {
+	event models.TaskEvent
+	done  chan struct{}
+}
+
+type eventSubscriber struct {
+	taskID string // 为空时订阅所有任务
+	ch     chan models.TaskEvent
+}
+
+// NewEventBroker 创建事件发布器，historySize 为订阅所有任务时最多回放的事件数
+// store 为空时使用内存存储，事件只在本副本内可见
+func NewEventBroker(historySize int, store repository.TaskEventRepository, logger *logrus.Logger) *EventBroker {
+	if historySize <= 0 {
+		historySize = defaultEventHistorySize
+	}
+	if store == nil {
+		store = repository.NewMemoryRepository()
+	}
+	return &EventBroker{
+		store:        store,
+		logger:       logger,
+		historySize:  historySize,
+		pollInterval: defaultEventPollInterval,
+		notify:       make(chan struct{}, 1),
+		queue:        make(chan eventWrite, eventQueueSize),
+		subscribers:  make(map[*eventSubscriber]struct{}),
+	}
+}
+
+// Publish 发布事件，事件异步写入存储，由存储分配事件序号
+// 写入失败的事件只记录日志，不会推送给订阅者
+func (b *EventBroker) Publish(event models.TaskEvent) {
+	if b == nil {
+		return
+	}
+
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	b.writerOnce.Do(func() { go b.writeLoop() })
+	b.queue <- eventWrite{event: event}
+}
+
+// Flush 等待此前发布的事件全部写入存储
+func (b *EventBroker) Flush() {
+	if b == nil {
+		return
+	}
+
+	b.writerOnce.Do(func() { go b.writeLoop() })
+	done := make(chan struct{})
+	b.queue <- eventWrite{done: done}
+	<-done
+}
+
+// writeLoop 按发布顺序写入事件
+func (b *EventBroker) writeLoop() {
+	for write := range b.queue {
+		if write.done != nil {
+			close(write.done)
+			continue
+		}
+		b.persist(write.event)
+	}
+}
+
+// persist 写入单个事件并唤醒轮询
+func (b *EventBroker) persist(event models.TaskEvent) {
+	if err := b.store.CreateTaskEvent(context.Background(), &event); err != nil {
+		if b.logger != nil {
+			b.logger.WithFields(logrus.Fields{
+				"taskId":    event.TaskID,
+				"eventType": event.Type,
+				"error":     err,
+			}).Warn("Failed to persist task event")
+		}
+		return
+	}
+
+	// 本副本发布的事件无需等待轮询间隔
+	select {
+	case b.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Subscribe 订阅事件，taskID 为空时订阅所有任务
+// 返回序号大于 afterID 的历史事件及后续事件通道；通道关闭表示订阅已被断开
+// 订阅所有任务时最多回放最近 historySize 个事件，afterID 为 0 时不回放
+// 回放与订阅在同一临界区内完成，保证事件不重复、不遗漏
+func (b *EventBroker) Subscribe(taskID string, afterID int64) ([]models.TaskEvent, <-chan models.TaskEvent, func(), error) {
+	// 本副本已发布但尚未写入的事件需包含在回放中
+	b.Flush()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ctx := context.Background()
+	if !b.tailing {
+		// 停止轮询期间写入的事件由回放补齐，之后只推送新事件
+		latest, err := b.store.LatestTaskEventID(ctx)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to get latest event id: %w", err)
+		}
+		b.lastID = latest
+	}
+
+	replay, err := b.replay(ctx, taskID, afterID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to replay events: %w", err)
+	}
+
+	sub := &eventSubscriber{
+		taskID: taskID,
+		ch:     make(chan models.TaskEvent, subscriberBufferSize),
+	}
+	b.subscribers[sub] = struct{}{}
+	if !b.tailing {
+		b.tailing = true
+		go b.tail()
+	}
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[sub]; ok {
+			delete(b.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+	return replay, sub.ch, unsubscribe, nil
+}
+
+// replay 读取序号在 (afterID, lastID] 范围内的事件，之后的事件由轮询推送
+func (b *EventBroker) replay(ctx context.Context, taskID string, afterID int64) ([]models.TaskEvent, error) {
+	if taskID == "" {
+		if afterID == 0 {
+			return nil, nil
+		}
+		if b.lastID-afterID > int64(b.historySize) {
+			afterID = b.lastID - int64(b.historySize)
+		}
+	}
+
+	var replay []models.TaskEvent
+	for afterID < b.lastID {
+		events, err := b.store.ListTaskEventsAfter(ctx, taskID, afterID, eventPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range events {
+			if event.ID > b.lastID {
+				return replay, nil
+			}
+			replay = append(replay, *event)
+			afterID = event.ID
+		}
+		if len(events) < eventPageSize {
+			break
+		}
+	}
+	return replay, nil
+}
+
+// tail 轮询事件表并推送新事件，没有订阅者时退出
+func (b *EventBroker) tail() {
+	ticker := time.NewTicker(b.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-b.notify:
+		}
+		if !b.poll() {
+			return
+		}
+	}
+}
+
+// poll 推送序号大于 lastID 的事件，没有订阅者时返回 false
+func (b *EventBroker) poll() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.subscribers) == 0 {
+		b.tailing = false
+		return false
+	}
+
+	for {
+		events, err := b.store.ListTaskEventsAfter(context.Background(), "", b.lastID, eventPageSize)
+		if err != nil {
+			if b.logger != nil {
+				b.logger.WithFields(logrus.Fields{
+					"lastEventId": b.lastID,
+					"error":       err,
+				}).Warn("Failed to poll task events")
+			}
+			return true
+		}
+		for _, event := range events {
+			b.lastID = event.ID
+			b.dispatch(*event)
+		}
+		if len(events) < eventPageSize {
+			return true
+		}
+	}
+}
+
+// dispatch 推送事件给匹配的订阅者，调用方需持有锁
+func (b *EventBroker) dispatch(event models.TaskEvent) {
+	for sub := range b.subscribers {
+		if sub.taskID != "" && sub.taskID != event.TaskID {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			// 消费过慢，断开订阅
+			delete(b.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// publishStatus 发布任务状态变更事件
+func (b *EventBroker) publishStatus(task *models.Task, message string) {
+	b.Publish(models.TaskEvent{
+		TaskID:   task.ID,
+		Type:     models.TaskEventStatus,
+		Status:   task.Status,
+		Message:  message,
+		Progress: snapshotProgress(task),
+	})
+}
+
+// publishBatch 发布批次提交事件
+func (b *EventBroker) publishBatch(task *models.Task, batchNum, succeeded, failed int) {
+	b.Publish(models.TaskEvent{
+		TaskID:   task.ID,
+		Type:     models.TaskEventBatchSubmitted,
+		Batch:    batchNum,
+		Message:  fmt.Sprintf("%d jobs submitted, %d failed", succeeded, failed),
+		Progress: snapshotProgress(task),
+	})
+}
+
+// publishBatchStarted 发布开始提交批次的事件
+func (b *EventBroker) publishBatchStarted(task *models.Task, batchNum, nodes int) {
+	b.Publish(models.TaskEvent{
+		TaskID:   task.ID,
+		Type:     models.TaskEventBatchStarted,
+		Batch:    batchNum,
+		Message:  fmt.Sprintf("submitting %d nodes", nodes),
+		Progress: snapshotProgress(task),
+	})
+}
+
+// snapshotProgress 复制任务当前进度，避免事件与任务共享同一对象
+func snapshotProgress(task *models.Task) *models.Progress {
+	if task.Progress == nil {
+		return nil
+	}
+	progress := *task.Progress
+	return &progress
+}
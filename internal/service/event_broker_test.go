@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kitsnail/ips/internal/repository"
+	"github.com/kitsnail/ips/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+)
+
+func TestEventBroker_ReplayAndSubscribe(t *testing.T) {
+	broker := NewEventBroker(3, nil, nil)
+	for _, taskID := range []string{"task-1", "task-2", "task-1", "task-1"} {
+		broker.Publish(models.TaskEvent{TaskID: taskID, Type: models.TaskEventStatus})
+	}
+
+	// 订阅所有任务且未携带 Last-Event-ID 时不回放
+	replay, _, unsubscribe, err := broker.Subscribe("", 0)
+	require.NoError(t, err)
+	unsubscribe()
+	assert.Empty(t, replay)
+
+	// 订阅所有任务时只回放最近 3 个事件
+	replay, _, unsubscribe, err = broker.Subscribe("", 1)
+	require.NoError(t, err)
+	unsubscribe()
+	require.Len(t, replay, 3)
+	assert.Equal(t, int64(2), replay[0].ID)
+
+	// 按任务过滤，且只回放 Last-Event-ID 之后的事件
+	replay, events, unsubscribe, err := broker.Subscribe("task-1", 3)
+	require.NoError(t, err)
+	defer unsubscribe()
+	require.Len(t, replay, 1)
+	assert.Equal(t, int64(4), replay[0].ID)
+
+	broker.Publish(models.TaskEvent{TaskID: "task-2", Type: models.TaskEventStatus})
+	broker.Publish(models.TaskEvent{TaskID: "task-1", Type: models.TaskEventNodeResult, NodeName: "node-1"})
+	event := <-events
+	assert.Equal(t, int64(6), event.ID)
+	assert.Equal(t, "node-1", event.NodeName)
+	assert.False(t, event.Timestamp.IsZero())
+
+	// 订阅单个任务时回放其全部事件
+	replay, _, unsubscribeAll, err := broker.Subscribe("task-1", 0)
+	require.NoError(t, err)
+	unsubscribeAll()
+	assert.Len(t, replay, 4)
+}
+
+func TestEventBroker_StreamsEventsWrittenByOtherReplicas(t *testing.T) {
+	store := repository.NewMemoryRepository()
+	ctx := context.Background()
+	require.NoError(t, store.CreateTaskEvent(ctx, &models.TaskEvent{TaskID: "task-1", Type: models.TaskEventStatus}))
+
+	// 新的副本使用存储中的事件序号，重启后 Last-Event-ID 仍然有效
+	broker := NewEventBroker(10, store, nil)
+	broker.pollInterval = 10 * time.Millisecond
+	replay, events, unsubscribe, err := broker.Subscribe("", 0)
+	require.NoError(t, err)
+	defer unsubscribe()
+	assert.Empty(t, replay)
+
+	// 其他副本（Leader）写入的事件通过轮询推送
+	require.NoError(t, store.CreateTaskEvent(ctx, &models.TaskEvent{TaskID: "task-1", Type: models.TaskEventNodeResult}))
+	select {
+	case event := <-events:
+		assert.Equal(t, int64(2), event.ID)
+		assert.Equal(t, models.TaskEventNodeResult, event.Type)
+	case <-time.After(time.Second):
+		t.Fatal("event written by another replica was not streamed")
+	}
+
+	replay, _, unsubscribeReplay, err := broker.Subscribe("", 1)
+	require.NoError(t, err)
+	unsubscribeReplay()
+	require.Len(t, replay, 1)
+	assert.Equal(t, int64(2), replay[0].ID)
+}
+
+func TestEventBroker_DropsSlowSubscriber(t *testing.T) {
+	broker := NewEventBroker(10, nil, nil)
+	_, events, unsubscribe, err := broker.Subscribe("", 0)
+	require.NoError(t, err)
+	defer unsubscribe()
+
+	for i := 0; i <= subscriberBufferSize; i++ {
+		broker.Publish(models.TaskEvent{TaskID: "task-1", Type: models.TaskEventStatus})
+	}
+
+	// 等待轮询推送完所有事件，缓冲写满后订阅被断开
+	require.Eventually(t, func() bool {
+		broker.mu.Lock()
+		defer broker.mu.Unlock()
+		return len(broker.subscribers) == 0
+	}, time.Second, 10*time.Millisecond)
+
+	received := 0
+	for range events {
+		received++
+	}
+	assert.Equal(t, subscriberBufferSize, received)
+}
+
+func TestStatusTracker_PublishesEvents(t *testing.T) {
+	taskManager, repo, _ := setupTaskManager(t,
+		newPrewarmJob("task-1", "node-1", batchv1.JobStatus{Succeeded: 1}),
+	)
+
+	ctx := context.Background()
+	task := &models.Task{
+		ID:          "task-1",
+		Status:      models.TaskRunning,
+		Images:      []string{"nginx:latest"},
+		TargetNodes: []string{"node-1"},
+		Progress:    &models.Progress{TotalNodes: 1},
+	}
+	require.NoError(t, repo.CreateTask(ctx, task))
+
+	_, events, unsubscribe, err := taskManager.Events().Subscribe(task.ID, 0)
+	require.NoError(t, err)
+	defer unsubscribe()
+
+	require.NoError(t, taskManager.statusTracker.updateTaskStatus(ctx, task))
+
+	nodeEvent := <-events
+	assert.Equal(t, models.TaskEventNodeResult, nodeEvent.Type)
+	assert.Equal(t, "node-1", nodeEvent.NodeName)
+	assert.Equal(t, models.NodeResultSucceeded, nodeEvent.Result)
+	assert.Equal(t, 1, nodeEvent.Attempt)
+
+	statusEvent := <-events
+	assert.Equal(t, models.TaskEventStatus, statusEvent.Type)
+	assert.Equal(t, models.TaskCompleted, statusEvent.Status)
+	assert.True(t, statusEvent.IsTerminal())
+	require.NotNil(t, statusEvent.Progress)
+	assert.Equal(t, 1, statusEvent.Progress.CompletedNodes)
+}
+
+func TestTaskManager_CleanupOldTaskEvents(t *testing.T) {
+	taskManager, repo, _ := setupTaskManager(t)
+	ctx := context.Background()
+	taskManager.SetEventRetention(24 * time.Hour)
+
+	taskManager.Events().Publish(models.TaskEvent{TaskID: "task-old", Type: models.TaskEventStatus, Timestamp: time.Now().Add(-48 * time.Hour)})
+	taskManager.Events().Publish(models.TaskEvent{TaskID: "task-new", Type: models.TaskEventStatus})
+	taskManager.Events().Flush()
+
+	// 只清理超过保留时间的事件
+	deleted, err := taskManager.CleanupOldTaskEvents(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	events, err := repo.ListTaskEventsAfter(ctx, "", 0, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "task-new", events[0].TaskID)
+}
@@ -0,0 +1,525 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kitsnail/ips/internal/k8s"
+	"github.com/kitsnail/ips/internal/repository"
+	"github.com/kitsnail/ips/pkg/models"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ErrInvalidImagePolicy 镜像常驻策略无效
+var ErrInvalidImagePolicy = errors.New("invalid image policy")
+
+const (
+	// defaultPolicyResyncInterval 未收到节点事件时定期全量协调的间隔
+	defaultPolicyResyncInterval = time.Minute
+	// defaultPolicyCooldown 同一节点两次修复任务之间的最小间隔，避免镜像未上报（kubelet 最多上报 50 个镜像）或持续拉取失败的节点反复触发任务
+	defaultPolicyCooldown = 10 * time.Minute
+)
+
+// ImagePolicyController 镜像常驻策略控制器
+// 监听节点新增、就绪、标签及镜像列表变化，为匹配策略但缺少镜像的节点创建预热任务，只在 Leader 上运行协调
+type ImagePolicyController struct {
+	repo        repository.ImagePolicyRepository
+	taskManager *TaskManager
+	k8sClient   *k8s.Client
+	informer    *k8s.NodeInformer // 为空或未同步时直接查询 API
+	logger      *logrus.Logger
+
+	resyncInterval time.Duration
+	cooldown       time.Duration
+
+	trigger chan struct{}
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewImagePolicyController 创建镜像常驻策略控制器
+func NewImagePolicyController(repo repository.ImagePolicyRepository, taskManager *TaskManager, k8sClient *k8s.Client, logger *logrus.Logger) *ImagePolicyController {
+	return &ImagePolicyController{
+		repo:           repo,
+		taskManager:    taskManager,
+		k8sClient:      k8sClient,
+		logger:         logger,
+		resyncInterval: defaultPolicyResyncInterval,
+		cooldown:       defaultPolicyCooldown,
+		trigger:        make(chan struct{}, 1),
+	}
+}
+
+// SetNodeInformer 设置节点 Informer，节点变化时触发协调，需在 Informer 启动前调用
+func (c *ImagePolicyController) SetNodeInformer(informer *k8s.NodeInformer) error {
+	if err := informer.AddEventHandler(c.onNodeEvent); err != nil {
+		return err
+	}
+	c.informer = informer
+	return nil
+}
+
+// SetCooldown 设置同一节点两次修复任务之间的最小间隔
+func (c *ImagePolicyController) SetCooldown(cooldown time.Duration) {
+	c.cooldown = cooldown
+}
+
+// Start 启动协调循环（成为 Leader 时调用），ctx 结束或调用 Stop 时停止
+func (c *ImagePolicyController) Start(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancel != nil {
+		return
+	}
+
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.done = make(chan struct{})
+	go c.run(ctx, c.done)
+	c.logger.Info("Image policy controller started")
+}
+
+// Stop 停止协调循环并等待正在进行的协调结束（重复调用无副作用）
+func (c *ImagePolicyController) Stop() {
+	c.mu.Lock()
+	cancel, done := c.cancel, c.done
+	c.cancel, c.done = nil, nil
+	c.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+	c.logger.Info("Image policy controller stopped")
+}
+
+// Trigger 请求尽快协调所有策略，多次请求会合并
+func (c *ImagePolicyController) Trigger() {
+	select {
+	case c.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// run 协调循环：启动时、收到触发请求时及定期全量协调
+func (c *ImagePolicyController) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(c.resyncInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.ReconcileAll(ctx); err != nil && ctx.Err() == nil {
+			c.logger.WithField("error", err).Error("Failed to reconcile image policies")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-c.trigger:
+		}
+	}
+}
+
+// onNodeEvent 节点新增、变为就绪或可能影响策略匹配与合规的字段变化时触发协调
+func (c *ImagePolicyController) onNodeEvent(old, node *corev1.Node) {
+	if nodeNeedsReconcile(old, node) {
+		c.Trigger()
+	}
+}
+
+// nodeNeedsReconcile 节点变化是否需要重新协调，忽略心跳等仅更新状态时间的变化
+// 删除的节点在下次协调时从策略状态中清理
+func nodeNeedsReconcile(old, node *corev1.Node) bool {
+	switch {
+	case node == nil:
+		return false
+	case old == nil:
+		return true
+	case k8s.IsNodeReady(old) != k8s.IsNodeReady(node):
+		return true
+	case old.Spec.Unschedulable != node.Spec.Unschedulable:
+		return true
+	case !maps.Equal(old.Labels, node.Labels):
+		return true
+	case !reflect.DeepEqual(old.Spec.Taints, node.Spec.Taints):
+		return true
+	}
+	return !reflect.DeepEqual(old.Status.Images, node.Status.Images)
+}
+
+// ReconcileAll 协调所有启用的策略
+func (c *ImagePolicyController) ReconcileAll(ctx context.Context) error {
+	policies, err := c.repo.ListImagePolicies(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list image policies: %w", err)
+	}
+	if len(policies) == 0 {
+		return nil
+	}
+
+	nodes, err := c.listNodes(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, policy := range policies {
+		if !policy.Enabled {
+			continue
+		}
+		if err := c.reconcilePolicy(ctx, policy, nodes); err != nil {
+			c.logger.WithFields(logrus.Fields{
+				"policyId": policy.ID,
+				"error":    err,
+			}).Error("Failed to reconcile image policy")
+		}
+	}
+	return nil
+}
+
+// reconcilePolicy 为匹配策略但缺少镜像的节点创建修复任务
+// 上一个修复任务结束前不创建新任务，同一节点在冷却时间内不重复修复
+func (c *ImagePolicyController) reconcilePolicy(ctx context.Context, policy *models.ImagePolicy, nodes []corev1.Node) error {
+	matched, err := selectNodes(&policy.TaskConfig, nodes)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	state := models.ImagePolicyState{
+		ActiveTaskID:    policy.State.ActiveTaskID,
+		LastTaskID:      policy.State.LastTaskID,
+		LastReconcileAt: &now,
+		RemediatedAt:    make(map[string]time.Time),
+		VerifiedNodes:   make(map[string]models.VerifiedNode),
+	}
+	// 只保留仍匹配策略的节点的修复与确认记录
+	for i := range matched {
+		if at, ok := policy.State.RemediatedAt[matched[i].Name]; ok {
+			state.RemediatedAt[matched[i].Name] = at
+		}
+		if verified, ok := policy.State.VerifiedNodes[matched[i].Name]; ok {
+			state.VerifiedNodes[matched[i].Name] = verified
+		}
+	}
+
+	if state.ActiveTaskID != "" {
+		task, finished, err := c.taskFinished(ctx, state.ActiveTaskID)
+		if err != nil {
+			return err
+		}
+		if !finished {
+			return c.repo.UpdateImagePolicyState(ctx, policy.ID, &state)
+		}
+		if task != nil {
+			recordVerifiedNodes(&state, task, matched, policy.TaskConfig.Images)
+		}
+		state.LastTaskID, state.ActiveTaskID = state.ActiveTaskID, ""
+	}
+
+	var drifted []string
+	for i := range matched {
+		node := &matched[i]
+		if k8s.NodeHasImages(node, policy.TaskConfig.Images) {
+			continue
+		}
+		// 镜像列表被截断时未上报的镜像可能已存在：交给修复任务由 puller 通过 CRI 确认，确认后不再重复创建任务
+		if k8s.NodeImagesTruncated(node) && nodeVerified(state.VerifiedNodes[node.Name], node, policy.TaskConfig.Images) {
+			continue
+		}
+		if at, ok := state.RemediatedAt[node.Name]; ok && now.Sub(at) < c.cooldown {
+			continue
+		}
+		drifted = append(drifted, node.Name)
+	}
+
+	if len(drifted) > 0 {
+		task, err := c.createRemediationTask(ctx, policy, drifted)
+		if err != nil {
+			if stateErr := c.repo.UpdateImagePolicyState(ctx, policy.ID, &state); stateErr != nil {
+				c.logger.WithFields(logrus.Fields{
+					"policyId": policy.ID,
+					"error":    stateErr,
+				}).Warn("Failed to update image policy state")
+			}
+			return fmt.Errorf("failed to create remediation task: %w", err)
+		}
+		state.ActiveTaskID = task.ID
+		for _, nodeName := range drifted {
+			state.RemediatedAt[nodeName] = now
+		}
+
+		c.logger.WithFields(logrus.Fields{
+			"policyId": policy.ID,
+			"taskId":   task.ID,
+			"nodes":    drifted,
+		}).Info("Created remediation task for image policy")
+	}
+
+	return c.repo.UpdateImagePolicyState(ctx, policy.ID, &state)
+}
+
+// createRemediationTask 按策略的任务配置为指定节点创建预热任务
+func (c *ImagePolicyController) createRemediationTask(ctx context.Context, policy *models.ImagePolicy, nodeNames []string) (*models.Task, error) {
+	req := nodesTaskRequest(&policy.TaskConfig, "policy", policy.CreatedBy, nodeNames)
+	return c.taskManager.CreateTask(ctx, req)
+}
+
+// nodesTaskRequest 按任务配置生成只预热指定节点的任务请求，跳过已存在镜像的节点
+func nodesTaskRequest(config *models.TaskConfig, idPrefix, createdBy string, nodeNames []string) *models.CreateTaskRequest {
+	req := config.CreateTaskRequest()
+	req.ID = models.GenerateTaskID(idPrefix)
+	req.CreatedBy = createdBy
+	req.SkipPresent = true
+
+	// 复制筛选条件，避免修改配置中共享的对象
+	selection := models.NodeSelection{}
+	if req.NodeSelection != nil {
+		selection = *req.NodeSelection
+	}
+	selection.IncludeNodes = nodeNames
+	req.NodeSelection = &selection
+	return req
+}
+
+// taskFinished 修复任务是否已结束，任务已被删除时视为结束（返回的任务为 nil）
+func (c *ImagePolicyController) taskFinished(ctx context.Context, taskID string) (*models.Task, bool, error) {
+	task, err := c.taskManager.GetTask(ctx, taskID)
+	if errors.Is(err, repository.ErrTaskNotFound) {
+		return nil, true, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get remediation task %s: %w", taskID, err)
+	}
+	return task, task.Status.IsTerminal(), nil
+}
+
+// recordVerifiedNodes 记录修复任务确认已存在全部镜像、但上报的镜像列表被截断的节点
+// 记录策略中的镜像引用（任务中的镜像可能已固定为 digest）
+func recordVerifiedNodes(state *models.ImagePolicyState, task *models.Task, matched []corev1.Node, images []string) {
+	for i := range matched {
+		node := &matched[i]
+		result := task.NodeResults[node.Name]
+		if result == nil || !k8s.NodeImagesTruncated(node) {
+			continue
+		}
+		if result.Status == models.NodeResultSucceeded || result.Status == models.NodeResultSkipped {
+			state.VerifiedNodes[node.Name] = models.VerifiedNode{
+				UID:    string(node.UID),
+				Images: images,
+			}
+		}
+	}
+}
+
+// nodeVerified 节点是否已由修复任务确认存在策略的全部镜像（节点未重建且策略镜像未变化）
+func nodeVerified(verified models.VerifiedNode, node *corev1.Node, images []string) bool {
+	if verified.UID == "" || verified.UID != string(node.UID) {
+		return false
+	}
+	confirmed := make(map[string]bool, len(verified.Images))
+	for _, image := range verified.Images {
+		confirmed[k8s.NormalizeImageRef(image)] = true
+	}
+	for _, image := range images {
+		if !confirmed[k8s.NormalizeImageRef(image)] {
+			return false
+		}
+	}
+	return true
+}
+
+// listNodes 列出所有节点，节点 Informer 已同步时从缓存读取
+func (c *ImagePolicyController) listNodes(ctx context.Context) ([]corev1.Node, error) {
+	if c.informer != nil && c.informer.HasSynced() {
+		return c.informer.ListNodes(), nil
+	}
+	return c.k8sClient.GetNodes(ctx)
+}
+
+// selectNodes 返回匹配任务配置节点选择条件的节点（默认只包含就绪且可调度的节点），按名称排序
+func selectNodes(config *models.TaskConfig, nodes []corev1.Node) ([]corev1.Node, error) {
+	labelSelector, err := nodeLabelSelector(config.NodeSelector, config.NodeSelection)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []corev1.Node
+	for i := range nodes {
+		if nodeExclusionReason(&nodes[i], labelSelector, config.NodeSelection) == "" {
+			matched = append(matched, nodes[i])
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].Name < matched[j].Name
+	})
+	return matched, nil
+}
+
+// ValidateImagePolicy 校验策略的任务配置，错误均包装为 ErrInvalidImagePolicy
+// 策略需要根据镜像判断节点是否合规，因此必须直接指定镜像，不支持从工作负载解析
+func ValidateImagePolicy(config *models.TaskConfig) error {
+	if len(config.Images) == 0 {
+		return fmt.Errorf("%w: images are required", ErrInvalidImagePolicy)
+	}
+	if len(config.Images) > 50 {
+		return fmt.Errorf("%w: max 50 images allowed per policy", ErrInvalidImagePolicy)
+	}
+	if config.Workloads != nil {
+		return fmt.Errorf("%w: workloads are not supported by image policies", ErrInvalidImagePolicy)
+	}
+	if config.BatchSize <= 0 {
+		return fmt.Errorf("%w: batchSize must be positive", ErrInvalidImagePolicy)
+	}
+
+	for _, err := range []error{
+		ValidateImages(config.Images),
+		ValidateNodeSelection(config.NodeSelector, config.NodeSelection),
+		ValidateRollout(config.Rollout),
+		ValidateSuccessPolicy(config.SuccessPolicy),
+	} {
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidImagePolicy, err)
+		}
+	}
+	return nil
+}
+
+// CreatePolicy 创建策略，启用时立即触发协调
+func (c *ImagePolicyController) CreatePolicy(ctx context.Context, req *models.CreateImagePolicyRequest, createdBy string) (*models.ImagePolicy, error) {
+	if err := ValidateImagePolicy(&req.TaskConfig); err != nil {
+		return nil, err
+	}
+
+	policy := &models.ImagePolicy{
+		ID:          models.GenerateTaskID("ipol"),
+		Name:        req.Name,
+		Description: req.Description,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+		TaskConfig:  req.TaskConfig,
+		CreatedBy:   createdBy,
+	}
+	if err := c.repo.CreateImagePolicy(ctx, policy); err != nil {
+		return nil, fmt.Errorf("failed to create image policy: %w", err)
+	}
+
+	if policy.Enabled {
+		c.Trigger()
+	}
+	return policy, nil
+}
+
+// GetPolicy 获取策略
+func (c *ImagePolicyController) GetPolicy(ctx context.Context, id string) (*models.ImagePolicy, error) {
+	return c.repo.GetImagePolicy(ctx, id)
+}
+
+// ListPolicies 列出所有策略
+func (c *ImagePolicyController) ListPolicies(ctx context.Context) ([]*models.ImagePolicy, error) {
+	return c.repo.ListImagePolicies(ctx)
+}
+
+// UpdatePolicy 更新策略定义，启用时立即触发协调
+func (c *ImagePolicyController) UpdatePolicy(ctx context.Context, id string, req *models.UpdateImagePolicyRequest) (*models.ImagePolicy, error) {
+	policy, err := c.repo.GetImagePolicy(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		policy.Name = *req.Name
+	}
+	if req.Description != nil {
+		policy.Description = *req.Description
+	}
+	if req.Enabled != nil {
+		policy.Enabled = *req.Enabled
+	}
+	if req.TaskConfig != nil {
+		if err := ValidateImagePolicy(req.TaskConfig); err != nil {
+			return nil, err
+		}
+		policy.TaskConfig = *req.TaskConfig
+	}
+
+	if err := c.repo.UpdateImagePolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+
+	if policy.Enabled {
+		c.Trigger()
+	}
+	return policy, nil
+}
+
+// DeletePolicy 删除策略，已创建的修复任务不受影响
+func (c *ImagePolicyController) DeletePolicy(ctx context.Context, id string) error {
+	return c.repo.DeleteImagePolicy(ctx, id)
+}
+
+// GetCompliance 生成策略的合规报告：匹配节点中已存在全部镜像的节点及各节点缺少的镜像
+func (c *ImagePolicyController) GetCompliance(ctx context.Context, id string) (*models.ImagePolicyCompliance, error) {
+	policy, err := c.repo.GetImagePolicy(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := c.listNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matched, err := selectNodes(&policy.TaskConfig, nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ImagePolicyCompliance{
+		PolicyID:          policy.ID,
+		Images:            policy.TaskConfig.Images,
+		MatchedNodes:      len(matched),
+		CompliancePercent: 100,
+		Nodes:             make([]models.NodeCompliance, 0, len(matched)),
+		ActiveTaskID:      policy.State.ActiveTaskID,
+		LastTaskID:        policy.State.LastTaskID,
+		LastReconcileAt:   policy.State.LastReconcileAt,
+		GeneratedAt:       time.Now(),
+	}
+
+	for i := range matched {
+		node := &matched[i]
+		result := models.NodeCompliance{NodeName: node.Name}
+		// 镜像列表被截断的节点以修复任务的确认结果为准
+		verified := k8s.NodeImagesTruncated(node) && nodeVerified(policy.State.VerifiedNodes[node.Name], node, policy.TaskConfig.Images)
+		for _, image := range policy.TaskConfig.Images {
+			if !verified && !k8s.NodeHasImages(node, []string{image}) {
+				result.MissingImages = append(result.MissingImages, image)
+			}
+		}
+		result.Compliant = len(result.MissingImages) == 0
+		if result.Compliant {
+			report.CompliantNodes++
+		} else if k8s.NodeImagesTruncated(node) {
+			result.Unknown = true
+			report.UnknownNodes++
+		}
+		if at, ok := policy.State.RemediatedAt[node.Name]; ok {
+			result.RemediatedAt = &at
+		}
+		report.Nodes = append(report.Nodes, result)
+	}
+
+	if report.MatchedNodes > 0 {
+		report.CompliancePercent = math.Round(float64(report.CompliantNodes)/float64(report.MatchedNodes)*1000) / 10
+	}
+	return report, nil
+}
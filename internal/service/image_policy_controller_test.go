@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kitsnail/ips/internal/k8s"
+	"github.com/kitsnail/ips/internal/repository"
+	"github.com/kitsnail/ips/pkg/models"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// policyTestNode 创建就绪的节点，images 为 kubelet 上报的镜像名
+func policyTestNode(name string, labels map[string]string, images ...string) *corev1.Node {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+	for _, image := range images {
+		node.Status.Images = append(node.Status.Images, corev1.ContainerImage{Names: []string{image}})
+	}
+	return node
+}
+
+func setupImagePolicyController(t *testing.T, nodes ...*corev1.Node) (*ImagePolicyController, *repository.MemoryRepository) {
+	t.Helper()
+
+	objects := make([]runtime.Object, 0, len(nodes))
+	for _, node := range nodes {
+		objects = append(objects, node)
+	}
+	taskManager, repo, k8sClient := setupTaskManager(t, objects...)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	return NewImagePolicyController(repo, taskManager, k8sClient, logger), repo
+}
+
+func TestImagePolicyController_ReconcilePolicy(t *testing.T) {
+	controller, repo := setupImagePolicyController(t,
+		policyTestNode("gpu-1", map[string]string{"pool": "gpu"}, "docker.io/library/nginx:1.25"),
+		policyTestNode("gpu-2", map[string]string{"pool": "gpu"}),
+		policyTestNode("cpu-1", map[string]string{"pool": "cpu"}),
+	)
+	ctx := context.Background()
+
+	policy, err := controller.CreatePolicy(ctx, &models.CreateImagePolicyRequest{
+		Name: "gpu-nginx",
+		TaskConfig: models.TaskConfig{
+			Images:       []string{"nginx:1.25"},
+			BatchSize:    10,
+			NodeSelector: map[string]string{"pool": "gpu"},
+		},
+	}, "admin")
+	require.NoError(t, err)
+	assert.True(t, policy.Enabled)
+
+	// 只为匹配策略且缺少镜像的节点创建修复任务
+	require.NoError(t, controller.ReconcileAll(ctx))
+	policy, err = repo.GetImagePolicy(ctx, policy.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, policy.State.ActiveTaskID)
+	require.NotNil(t, policy.State.LastReconcileAt)
+	assert.Contains(t, policy.State.RemediatedAt, "gpu-2")
+
+	task, err := repo.GetTask(ctx, policy.State.ActiveTaskID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nginx:1.25"}, task.Images)
+	assert.Equal(t, "admin", task.CreatedBy)
+	assert.True(t, task.SkipPresent)
+	require.NotNil(t, task.NodeSelection)
+	assert.Equal(t, []string{"gpu-2"}, task.NodeSelection.IncludeNodes)
+
+	// 修复任务结束前不创建新任务
+	require.NoError(t, controller.ReconcileAll(ctx))
+	_, total, err := repo.ListTasks(ctx, 0, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+
+	// 修复任务结束（已删除）后，冷却时间内不重复修复同一节点
+	state := policy.State
+	state.ActiveTaskID = "missing-task"
+	require.NoError(t, repo.UpdateImagePolicyState(ctx, policy.ID, &state))
+	require.NoError(t, controller.ReconcileAll(ctx))
+	policy, err = repo.GetImagePolicy(ctx, policy.ID)
+	require.NoError(t, err)
+	assert.Empty(t, policy.State.ActiveTaskID)
+	assert.Equal(t, "missing-task", policy.State.LastTaskID)
+	_, total, err = repo.ListTasks(ctx, 0, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+
+	// 冷却时间过后再次修复
+	controller.SetCooldown(0)
+	require.NoError(t, controller.ReconcileAll(ctx))
+	policy, err = repo.GetImagePolicy(ctx, policy.ID)
+	require.NoError(t, err)
+	assert.NotEmpty(t, policy.State.ActiveTaskID)
+	_, total, err = repo.ListTasks(ctx, 0, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	// 禁用的策略不协调
+	disabled := false
+	_, err = controller.UpdatePolicy(ctx, policy.ID, &models.UpdateImagePolicyRequest{Enabled: &disabled})
+	require.NoError(t, err)
+	state = policy.State
+	state.ActiveTaskID = ""
+	require.NoError(t, repo.UpdateImagePolicyState(ctx, policy.ID, &state))
+	require.NoError(t, controller.ReconcileAll(ctx))
+	_, total, err = repo.ListTasks(ctx, 0, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+}
+
+func TestImagePolicyController_GetCompliance(t *testing.T) {
+	// node-4 上报的镜像数达到 kubelet 上限，未上报的镜像无法确认
+	truncated := []string{"docker.io/library/nginx:1.25"}
+	for i := 1; i < k8s.MaxReportedNodeImages; i++ {
+		truncated = append(truncated, fmt.Sprintf("registry.example.com/app-%d:v1", i))
+	}
+	controller, _ := setupImagePolicyController(t,
+		policyTestNode("node-1", nil, "docker.io/library/nginx:1.25", "docker.io/library/redis:7"),
+		policyTestNode("node-2", nil, "docker.io/library/nginx:1.25"),
+		policyTestNode("node-3", nil),
+		policyTestNode("node-4", nil, truncated...),
+	)
+	ctx := context.Background()
+
+	disabled := false
+	policy, err := controller.CreatePolicy(ctx, &models.CreateImagePolicyRequest{
+		Name:    "cache",
+		Enabled: &disabled,
+		TaskConfig: models.TaskConfig{
+			Images:    []string{"nginx:1.25", "redis:7"},
+			BatchSize: 10,
+		},
+	}, "admin")
+	require.NoError(t, err)
+
+	report, err := controller.GetCompliance(ctx, policy.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.MatchedNodes)
+	assert.Equal(t, 1, report.CompliantNodes)
+	assert.Equal(t, 1, report.UnknownNodes)
+	assert.Equal(t, 25.0, report.CompliancePercent)
+	require.Len(t, report.Nodes, 4)
+	assert.True(t, report.Nodes[0].Compliant)
+	assert.Equal(t, []string{"redis:7"}, report.Nodes[1].MissingImages)
+	assert.False(t, report.Nodes[1].Unknown)
+	assert.Equal(t, []string{"nginx:1.25", "redis:7"}, report.Nodes[2].MissingImages)
+	assert.False(t, report.Nodes[3].Compliant)
+	assert.True(t, report.Nodes[3].Unknown)
+	assert.Equal(t, []string{"redis:7"}, report.Nodes[3].MissingImages)
+
+	_, err = controller.GetCompliance(ctx, "missing")
+	assert.ErrorIs(t, err, repository.ErrImagePolicyNotFound)
+}
+
+func TestImagePolicyController_ReconcilePolicy_TruncatedNode(t *testing.T) {
+	// 上报的镜像数达到 kubelet 上限，无法从节点状态确认策略镜像是否存在
+	var images []string
+	for i := 0; i < k8s.MaxReportedNodeImages; i++ {
+		images = append(images, fmt.Sprintf("registry.example.com/app-%d:v1", i))
+	}
+	node := policyTestNode("node-1", nil, images...)
+	node.UID = "uid-1"
+	controller, repo := setupImagePolicyController(t, node)
+	controller.SetCooldown(0)
+	ctx := context.Background()
+
+	policy, err := controller.CreatePolicy(ctx, &models.CreateImagePolicyRequest{
+		Name: "cache",
+		TaskConfig: models.TaskConfig{
+			Images:    []string{"nginx:1.25"},
+			BatchSize: 10,
+		},
+	}, "admin")
+	require.NoError(t, err)
+
+	// 首次交给修复任务确认
+	require.NoError(t, controller.ReconcileAll(ctx))
+	policy, err = repo.GetImagePolicy(ctx, policy.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, policy.State.ActiveTaskID)
+
+	// puller 确认镜像已存在，任务结束
+	task, err := repo.GetTask(ctx, policy.State.ActiveTaskID)
+	require.NoError(t, err)
+	task.Status = models.TaskCompleted
+	task.NodeResults = map[string]*models.NodeResult{
+		"node-1": {NodeName: "node-1", Status: models.NodeResultSucceeded},
+	}
+	require.NoError(t, repo.UpdateTask(ctx, task))
+
+	// 已确认的节点不再反复创建修复任务，合规报告中视为合规
+	for i := 0; i < 2; i++ {
+		require.NoError(t, controller.ReconcileAll(ctx))
+	}
+	policy, err = repo.GetImagePolicy(ctx, policy.ID)
+	require.NoError(t, err)
+	assert.Empty(t, policy.State.ActiveTaskID)
+	assert.Equal(t, models.VerifiedNode{UID: "uid-1", Images: []string{"nginx:1.25"}}, policy.State.VerifiedNodes["node-1"])
+	_, total, err := repo.ListTasks(ctx, 0, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+
+	report, err := controller.GetCompliance(ctx, policy.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.CompliantNodes)
+	assert.Equal(t, 0, report.UnknownNodes)
+
+	// 策略镜像变化后确认失效，重新修复
+	_, err = controller.UpdatePolicy(ctx, policy.ID, &models.UpdateImagePolicyRequest{
+		TaskConfig: &models.TaskConfig{Images: []string{"nginx:1.25", "redis:7"}, BatchSize: 10},
+	})
+	require.NoError(t, err)
+	require.NoError(t, controller.ReconcileAll(ctx))
+	_, total, err = repo.ListTasks(ctx, 0, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+}
+
+func TestValidateImagePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  models.TaskConfig
+		wantErr bool
+	}{
+		{"valid", models.TaskConfig{Images: []string{"nginx:1.25"}, BatchSize: 10}, false},
+		{"no images", models.TaskConfig{BatchSize: 10}, true},
+		{"invalid image", models.TaskConfig{Images: []string{"NGINX:1.25"}, BatchSize: 10}, true},
+		{"workloads", models.TaskConfig{Images: []string{"nginx:1.25"}, BatchSize: 10, Workloads: &models.WorkloadSource{Namespace: "default"}}, true},
+		{"zero batch size", models.TaskConfig{Images: []string{"nginx:1.25"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateImagePolicy(&tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateImagePolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNodeNeedsReconcile(t *testing.T) {
+	base := policyTestNode("node-1", map[string]string{"pool": "gpu"}, "docker.io/library/nginx:1.25")
+
+	heartbeat := base.DeepCopy()
+	heartbeat.Status.Conditions[0].LastHeartbeatTime = metav1.NewTime(time.Now())
+
+	notReady := base.DeepCopy()
+	notReady.Status.Conditions[0].Status = corev1.ConditionFalse
+
+	relabeled := base.DeepCopy()
+	relabeled.Labels["pool"] = "cpu"
+
+	imagesChanged := base.DeepCopy()
+	imagesChanged.Status.Images = nil
+
+	tests := []struct {
+		name string
+		old  *corev1.Node
+		node *corev1.Node
+		want bool
+	}{
+		{"added", nil, base, true},
+		{"deleted", base, nil, false},
+		{"heartbeat only", base, heartbeat, false},
+		{"readiness changed", base, notReady, true},
+		{"labels changed", base, relabeled, true},
+		{"images changed", base, imagesChanged, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nodeNeedsReconcile(tt.old, tt.node); got != tt.want {
+				t.Errorf("nodeNeedsReconcile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/distribution/reference"
+	"github.com/kitsnail/ips/internal/registry"
+	"github.com/kitsnail/ips/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidImage 镜像引用格式错误或仓库中不存在
+var ErrInvalidImage = errors.New("invalid image")
+
+// ValidateImages 校验镜像引用格式，创建任务前调用
+func ValidateImages(images []string) error {
+	_, err := parseImages(images)
+	return err
+}
+
+// parseImages 解析镜像引用，支持省略仓库与 tag 的简写（如 nginx）
+func parseImages(images []string) ([]reference.Named, error) {
+	refs := make([]reference.Named, 0, len(images))
+	for _, image := range images {
+		ref, err := reference.ParseNormalizedNamed(image)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", ErrInvalidImage, image, err)
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// SetRegistryClient 设置解析镜像 digest 使用的仓库客户端
+func (m *TaskManager) SetRegistryClient(client *registry.Client) {
+	m.registryClient = client
+}
+
+// resolveImageDigests 查询仓库将镜像 tag 解析为 digest，返回固定到 digest 的镜像列表及原始引用 -> 固定引用的映射
+// 所有节点拉取同一内容，避免执行期间 tag 被覆盖
+func (m *TaskManager) resolveImageDigests(ctx context.Context, req *models.CreateTaskRequest, refs []reference.Named) ([]string, map[string]string, error) {
+	registryName, creds, err := m.resolveCredentials(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pinned := make([]string, 0, len(refs))
+	digests := make(map[string]string, len(refs))
+	for i, ref := range refs {
+		var refCreds *registry.Credentials
+		if creds != nil && registry.MatchesRegistry(ref, registryName) {
+			refCreds = creds
+		}
+
+		dgst, err := m.registryClient.ResolveDigest(ctx, ref, refCreds)
+		if err != nil {
+			if errors.Is(err, registry.ErrManifestNotFound) {
+				return nil, nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+			}
+			return nil, nil, fmt.Errorf("failed to resolve digest of %s: %w", req.Images[i], err)
+		}
+
+		canonical, err := reference.WithDigest(reference.TrimNamed(ref), dgst)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to pin %s to digest: %w", req.Images[i], err)
+		}
+		pinned = append(pinned, reference.FamiliarString(canonical))
+		digests[req.Images[i]] = pinned[i]
+	}
+
+	m.logger.WithFields(logrus.Fields{
+		"images": digests,
+	}).Info("Resolved image digests")
+	return pinned, digests, nil
+}
+
+// resolveCredentials 返回请求中凭据对应的仓库地址与认证信息，未配置认证时返回 nil
+// 与 prepareCredentials 相同，手动输入的凭据优先于已保存的认证
+func (m *TaskManager) resolveCredentials(ctx context.Context, req *models.CreateTaskRequest) (string, *registry.Credentials, error) {
+	if req.Registry != "" && req.Username != "" && req.Password != "" {
+		return req.Registry, &registry.Credentials{Username: req.Username, Password: req.Password}, nil
+	}
+
+	if req.SecretID > 0 {
+		secret, err := m.secretRepo.GetSecretCredentials(ctx, req.SecretID)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to get secret credentials: %w", err)
+		}
+		return secret.Registry, &registry.Credentials{Username: secret.Username, Password: secret.Password}, nil
+	}
+
+	return "", nil, nil
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kitsnail/ips/internal/registry"
+	"github.com/kitsnail/ips/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateImages(t *testing.T) {
+	assert.NoError(t, ValidateImages([]string{"nginx", "redis:7", "harbor.example.com:5000/team/app:v1", "nginx@sha256:4c0fdaa8b6341bfdeca5f18f7837462c80cff90527ee35ef185571e1c327beac"}))
+
+	for _, image := range []string{"", "Nginx:latest", "nginx:", "nginx:latest:1", "nginx@sha256:abc"} {
+		assert.ErrorIs(t, ValidateImages([]string{image}), ErrInvalidImage, image)
+	}
+}
+
+func TestTaskManager_CreateTask_ResolveDigests(t *testing.T) {
+	const digest = "sha256:4c0fdaa8b6341bfdeca5f18f7837462c80cff90527ee35ef185571e1c327beac"
+
+	// 本地仓库替身，只存在 team/app:v1，要求 Basic 认证
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "robot" || pass != "secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/v2/team/app/manifests/v1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Docker-Content-Digest", digest)
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	taskManager, repo, _ := setupTaskManager(t)
+	taskManager.SetRegistryClient(registry.NewClient(server.Client()))
+	ctx := context.Background()
+
+	secret := &models.RegistrySecret{Name: "local", Registry: host, Username: "robot", Password: "secret"}
+	require.NoError(t, repo.CreateSecret(ctx, secret))
+
+	task, err := taskManager.CreateTask(ctx, &models.CreateTaskRequest{
+		Images:         []string{host + "/team/app:v1"},
+		BatchSize:      10,
+		SecretID:       secret.ID,
+		ResolveDigests: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{host + "/team/app@" + digest}, task.Images)
+	assert.Equal(t, map[string]string{host + "/team/app:v1": host + "/team/app@" + digest}, task.ImageDigests)
+
+	// tag 不存在时拒绝创建任务
+	_, err = taskManager.CreateTask(ctx, &models.CreateTaskRequest{
+		Images:         []string{host + "/team/app:v2"},
+		BatchSize:      10,
+		SecretID:       secret.ID,
+		ResolveDigests: true,
+	})
+	assert.ErrorIs(t, err, ErrInvalidImage)
+
+	// 格式错误的引用在查询仓库之前被拒绝
+	_, err = taskManager.CreateTask(ctx, &models.CreateTaskRequest{
+		Images:    []string{"Team/App:v1"},
+		BatchSize: 10,
+	})
+	assert.ErrorIs(t, err, ErrInvalidImage)
+}
@@ -2,9 +2,14 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/kitsnail/ips/internal/k8s"
+	"github.com/kitsnail/ips/pkg/models"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
 )
 
 // NodeFilter 节点过滤服务
@@ -19,33 +24,161 @@ func NewNodeFilter(k8sClient *k8s.Client) *NodeFilter {
 	}
 }
 
-// FilterNodes 根据选择器过滤节点
-// 返回符合条件且就绪的节点名称列表
-func (n *NodeFilter) FilterNodes(ctx context.Context, selector map[string]string) ([]string, error) {
-	var nodes []string
-	var err error
+// ErrInvalidNodeSelection 节点选择条件无效
+var ErrInvalidNodeSelection = errors.New("invalid node selection")
+
+// ValidateNodeSelection 校验节点选择器与筛选条件，创建任务前调用
+func ValidateNodeSelection(selector map[string]string, selection *models.NodeSelection) error {
+	_, err := nodeLabelSelector(selector, selection)
+	return err
+}
 
-	// 如果有选择器，使用选择器过滤
-	if len(selector) > 0 {
-		nodeList, err := n.k8sClient.GetNodesBySelector(ctx, selector)
+// nodeLabelSelector 合并 nodeSelector 与 matchExpressions 为标签选择器
+func nodeLabelSelector(selector map[string]string, selection *models.NodeSelection) (labels.Selector, error) {
+	if err := selection.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNodeSelection, err)
+	}
+
+	labelSelector, err := labels.ValidatedSelectorFromSet(selector)
+	if err != nil {
+		return nil, fmt.Errorf("%w: nodeSelector: %v", ErrInvalidNodeSelection, err)
+	}
+	if selection == nil {
+		return labelSelector, nil
+	}
+
+	for i, expr := range selection.MatchExpressions {
+		req, err := labels.NewRequirement(expr.Key, nodeSelectorOperators[expr.Operator], expr.Values)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get nodes by selector: %w", err)
+			return nil, fmt.Errorf("%w: matchExpressions[%d]: %v", ErrInvalidNodeSelection, i, err)
 		}
-		// 过滤出就绪且可调度的节点
-		nodes = k8s.FilterReadyNodes(nodeList)
-	} else {
-		// 否则获取所有节点
-		nodeList, err := n.k8sClient.GetNodes(ctx)
+		labelSelector = labelSelector.Add(*req)
+	}
+	return labelSelector, nil
+}
+
+// nodeSelectorOperators 表达式运算符 -> 标签选择器运算符
+var nodeSelectorOperators = map[models.NodeSelectorOperator]selection.Operator{
+	models.NodeSelectorOpIn:           selection.In,
+	models.NodeSelectorOpNotIn:        selection.NotIn,
+	models.NodeSelectorOpExists:       selection.Exists,
+	models.NodeSelectorOpDoesNotExist: selection.DoesNotExist,
+}
+
+// FilterNodes 根据选择器及筛选条件过滤节点
+// 返回符合条件的节点名称列表，默认只包含就绪且可调度的节点
+func (n *NodeFilter) FilterNodes(ctx context.Context, selector map[string]string, nodeSelection *models.NodeSelection) ([]string, error) {
+	labelSelector, err := nodeLabelSelector(selector, nodeSelection)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodeList []corev1.Node
+	if labelSelector.Empty() {
+		nodeList, err = n.k8sClient.GetNodes(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get all nodes: %w", err)
 		}
-		// 过滤出就绪且可调度的节点
-		nodes = k8s.FilterReadyNodes(nodeList)
+	} else {
+		nodeList, err = n.k8sClient.GetNodesBySelector(ctx, labelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get nodes by selector: %w", err)
+		}
 	}
 
+	var nodes []string
+	for i := range nodeList {
+		if nodeExclusionReason(&nodeList[i], labelSelector, nodeSelection) == "" {
+			nodes = append(nodes, nodeList[i].Name)
+		}
+	}
 	if len(nodes) == 0 {
 		return nil, fmt.Errorf("no ready nodes found")
 	}
 
-	return nodes, err
+	return nodes, nil
+}
+
+// PlanNodes 对集群中所有节点应用筛选条件，返回匹配的节点及未被选中节点的原因
+func (n *NodeFilter) PlanNodes(ctx context.Context, selector map[string]string, nodeSelection *models.NodeSelection) ([]string, []models.ExcludedNode, error) {
+	labelSelector, err := nodeLabelSelector(selector, nodeSelection)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nodeList, err := n.k8sClient.GetNodes(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get all nodes: %w", err)
+	}
+
+	matched := []string{}
+	excluded := []models.ExcludedNode{}
+	for i := range nodeList {
+		if reason := nodeExclusionReason(&nodeList[i], labelSelector, nodeSelection); reason != "" {
+			excluded = append(excluded, models.ExcludedNode{NodeName: nodeList[i].Name, Reason: reason})
+		} else {
+			matched = append(matched, nodeList[i].Name)
+		}
+	}
+	return matched, excluded, nil
+}
+
+// nodeExclusionReason 返回节点未被选中的原因，选中时返回空字符串
+// 未指定筛选条件时只选择就绪且可调度的节点
+func nodeExclusionReason(node *corev1.Node, labelSelector labels.Selector, nodeSelection *models.NodeSelection) string {
+	if nodeSelection == nil {
+		nodeSelection = &models.NodeSelection{}
+	}
+
+	switch {
+	case !labelSelector.Matches(labels.Set(node.Labels)):
+		return models.ExclusionLabelMismatch
+	case len(nodeSelection.IncludeNodes) > 0 && !containsString(nodeSelection.IncludeNodes, node.Name):
+		return models.ExclusionNotIncluded
+	case containsString(nodeSelection.ExcludeNodes, node.Name):
+		return models.ExclusionExcluded
+	case hasExcludedTaint(node, nodeSelection.ExcludeTaints):
+		return models.ExclusionTainted
+	case !nodeSelection.IncludeNotReady && !k8s.IsNodeReady(node):
+		return models.ExclusionNotReady
+	case !nodeSelection.IncludeCordoned && !k8s.IsNodeSchedulable(node):
+		return models.ExclusionCordoned
+	}
+	return ""
+}
+
+// hasExcludedTaint 节点是否带有任一需要排除的污点
+func hasExcludedTaint(node *corev1.Node, taints []models.TaintSelector) bool {
+	for _, taint := range taints {
+		if k8s.NodeHasTaint(node, taint.Key, taint.Value, taint.Effect) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString 列表中是否包含指定字符串
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+// NodesWithImages 返回已存在全部指定镜像的节点名称集合
+func (n *NodeFilter) NodesWithImages(ctx context.Context, images []string) (map[string]bool, error) {
+	nodeList, err := n.k8sClient.GetNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all nodes: %w", err)
+	}
+
+	warmed := make(map[string]bool)
+	for i := range nodeList {
+		if k8s.NodeHasImages(&nodeList[i], images) {
+			warmed[nodeList[i].Name] = true
+		}
+	}
+	return warmed, nil
 }
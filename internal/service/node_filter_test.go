@@ -2,9 +2,13 @@ package service
 
 import (
 	"context"
+	"errors"
+	"reflect"
+	"sort"
 	"testing"
 
 	"github.com/kitsnail/ips/internal/k8s"
+	"github.com/kitsnail/ips/pkg/models"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
@@ -44,7 +48,7 @@ func TestNodeFilter_FilterNodes_AllNodes(t *testing.T) {
 	ctx := context.Background()
 
 	// 测试获取所有节点（无选择器）
-	nodes, err := filter.FilterNodes(ctx, nil)
+	nodes, err := filter.FilterNodes(ctx, nil, nil)
 	if err != nil {
 		t.Fatalf("FilterNodes failed: %v", err)
 	}
@@ -96,7 +100,7 @@ func TestNodeFilter_FilterNodes_WithSelector(t *testing.T) {
 	// 测试使用选择器过滤
 	nodes, err := filter.FilterNodes(ctx, map[string]string{
 		"workload": "compute",
-	})
+	}, nil)
 	if err != nil {
 		t.Fatalf("FilterNodes failed: %v", err)
 	}
@@ -109,3 +113,142 @@ func TestNodeFilter_FilterNodes_WithSelector(t *testing.T) {
 		t.Errorf("Expected node-1, got %s", nodes[0])
 	}
 }
+
+func TestNodeFilter_FilterNodes_NodeSelection(t *testing.T) {
+	newNode := func(name, zone string, ready, unschedulable bool, taints ...corev1.Taint) *corev1.Node {
+		status := corev1.ConditionTrue
+		if !ready {
+			status = corev1.ConditionFalse
+		}
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"zone": zone}},
+			Spec:       corev1.NodeSpec{Unschedulable: unschedulable, Taints: taints},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}},
+			},
+		}
+	}
+	gpuTaint := corev1.Taint{Key: "nvidia.com/gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule}
+
+	fakeClientset := fake.NewSimpleClientset(
+		newNode("node-1", "a", true, false),
+		newNode("node-2", "b", true, false, gpuTaint),
+		newNode("node-3", "c", true, false),
+		newNode("node-4", "a", false, false),
+		newNode("node-5", "b", true, true),
+	)
+	filter := NewNodeFilter(&k8s.Client{Clientset: fakeClientset, Namespace: "default"})
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		selection *models.NodeSelection
+		expected  []string
+	}{
+		{
+			name: "match expressions",
+			selection: &models.NodeSelection{MatchExpressions: []models.NodeSelectorRequirement{
+				{Key: "zone", Operator: models.NodeSelectorOpIn, Values: []string{"a", "b"}},
+			}},
+			expected: []string{"node-1", "node-2"},
+		},
+		{
+			name: "not in",
+			selection: &models.NodeSelection{MatchExpressions: []models.NodeSelectorRequirement{
+				{Key: "zone", Operator: models.NodeSelectorOpNotIn, Values: []string{"a"}},
+			}},
+			expected: []string{"node-2", "node-3"},
+		},
+		{
+			name:      "include and exclude nodes",
+			selection: &models.NodeSelection{IncludeNodes: []string{"node-1", "node-2", "node-4"}, ExcludeNodes: []string{"node-2"}},
+			expected:  []string{"node-1"},
+		},
+		{
+			name:      "exclude taints",
+			selection: &models.NodeSelection{ExcludeTaints: []models.TaintSelector{{Key: "nvidia.com/gpu"}}},
+			expected:  []string{"node-1", "node-3"},
+		},
+		{
+			name:      "taint effect mismatch",
+			selection: &models.NodeSelection{ExcludeTaints: []models.TaintSelector{{Key: "nvidia.com/gpu", Effect: models.TaintEffectNoExecute}}},
+			expected:  []string{"node-1", "node-2", "node-3"},
+		},
+		{
+			name:      "include not ready and cordoned",
+			selection: &models.NodeSelection{IncludeNotReady: true, IncludeCordoned: true},
+			expected:  []string{"node-1", "node-2", "node-3", "node-4", "node-5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, err := filter.FilterNodes(ctx, nil, tt.selection)
+			if err != nil {
+				t.Fatalf("FilterNodes failed: %v", err)
+			}
+			sort.Strings(nodes)
+			if !reflect.DeepEqual(nodes, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, nodes)
+			}
+		})
+	}
+}
+
+func TestValidateNodeSelection(t *testing.T) {
+	tests := []struct {
+		name      string
+		selector  map[string]string
+		selection *models.NodeSelection
+		valid     bool
+	}{
+		{name: "empty", valid: true},
+		{name: "invalid selector key", selector: map[string]string{"bad key": "x"}},
+		{
+			name: "exists",
+			selection: &models.NodeSelection{MatchExpressions: []models.NodeSelectorRequirement{
+				{Key: "node-role.kubernetes.io/worker", Operator: models.NodeSelectorOpExists},
+			}},
+			valid: true,
+		},
+		{
+			name: "in without values",
+			selection: &models.NodeSelection{MatchExpressions: []models.NodeSelectorRequirement{
+				{Key: "zone", Operator: models.NodeSelectorOpIn},
+			}},
+		},
+		{
+			name: "exists with values",
+			selection: &models.NodeSelection{MatchExpressions: []models.NodeSelectorRequirement{
+				{Key: "zone", Operator: models.NodeSelectorOpExists, Values: []string{"a"}},
+			}},
+		},
+		{
+			name: "unknown operator",
+			selection: &models.NodeSelection{MatchExpressions: []models.NodeSelectorRequirement{
+				{Key: "zone", Operator: "Gt", Values: []string{"1"}},
+			}},
+		},
+		{
+			name: "invalid value",
+			selection: &models.NodeSelection{MatchExpressions: []models.NodeSelectorRequirement{
+				{Key: "zone", Operator: models.NodeSelectorOpIn, Values: []string{"not valid!"}},
+			}},
+		},
+		{name: "taint without key", selection: &models.NodeSelection{ExcludeTaints: []models.TaintSelector{{Value: "x"}}}},
+		{name: "unknown taint effect", selection: &models.NodeSelection{ExcludeTaints: []models.TaintSelector{{Key: "k", Effect: "Never"}}}},
+		{name: "empty node name", selection: &models.NodeSelection{ExcludeNodes: []string{""}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateNodeSelection(tt.selector, tt.selection)
+			if tt.valid && err != nil {
+				t.Errorf("Expected valid, got %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidNodeSelection) {
+				t.Errorf("Expected ErrInvalidNodeSelection, got %v", err)
+			}
+		})
+	}
+}
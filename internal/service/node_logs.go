@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kitsnail/ips/pkg/models"
+	"github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// nodeLogTailLines 采集 puller 日志的最大行数
+	nodeLogTailLines = 200
+	// maxNodeLogBytes 单个节点保存的日志上限，超过时只保留末尾部分
+	maxNodeLogBytes = 16 * 1024
+)
+
+// captureNodeLogs 采集失败节点的 puller 容器日志并单独保存，不随任务记录反复写入
+// Job 被清理后日志无法再获取，因此在判定失败时立即采集；采集或保存失败只记录日志
+func (t *StatusTracker) captureNodeLogs(ctx context.Context, task *models.Task, nodeName string, attempt *models.NodeAttempt, job *batchv1.Job) {
+	pods, err := t.listPods(ctx, job.Name)
+	if err != nil || len(pods) == 0 {
+		return
+	}
+
+	// 取最近创建的 Pod
+	pod := &pods[0]
+	for i := range pods[1:] {
+		if pods[i+1].CreationTimestamp.After(pod.CreationTimestamp.Time) {
+			pod = &pods[i+1]
+		}
+	}
+
+	logs, err := t.fetchPullerLogs(ctx, pod.Name)
+	if err != nil {
+		t.logger.WithFields(logrus.Fields{
+			"taskId":   task.ID,
+			"nodeName": nodeName,
+			"podName":  pod.Name,
+			"error":    err,
+		}).Warn("Failed to capture puller logs")
+		return
+	}
+
+	logs, clipped := tailLogLines(logs, nodeLogTailLines)
+	logs, truncated := truncateLogs(logs, maxNodeLogBytes)
+	nodeLog := &models.NodeLog{
+		NodeName:   nodeName,
+		Attempt:    attempt.Attempt,
+		JobName:    job.Name,
+		PodName:    pod.Name,
+		Logs:       logs,
+		Truncated:  clipped || truncated,
+		CapturedAt: time.Now(),
+	}
+	if err := t.repo.SaveNodeLog(ctx, task.ID, nodeLog); err != nil {
+		t.logger.WithFields(logrus.Fields{
+			"taskId":   task.ID,
+			"nodeName": nodeName,
+			"error":    err,
+		}).Warn("Failed to save puller logs")
+	}
+}
+
+// fetchPullerLogs 获取 Pod 中 puller 容器的最后若干行日志
+// 多请求一行，用于判断日志是否超过 nodeLogTailLines 行
+func (t *StatusTracker) fetchPullerLogs(ctx context.Context, podName string) (string, error) {
+	client := t.jobCreator.GetK8sClient()
+	tailLines := int64(nodeLogTailLines + 1)
+	raw, err := client.Clientset.CoreV1().Pods(client.Namespace).GetLogs(podName, &corev1.PodLogOptions{
+		Container: "puller",
+		TailLines: &tailLines,
+	}).DoRaw(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get logs of pod %s: %w", podName, err)
+	}
+	return string(raw), nil
+}
+
+// tailLogLines 日志超过 limit 行时只保留最后 limit 行
+func tailLogLines(logs string, limit int) (string, bool) {
+	lines := strings.SplitAfter(logs, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) <= limit {
+		return logs, false
+	}
+	return strings.Join(lines[len(lines)-limit:], ""), true
+}
+
+// truncateLogs 日志超过 limit 字节时只保留末尾部分，并从下一行开始截取
+func truncateLogs(logs string, limit int) (string, bool) {
+	if len(logs) <= limit {
+		return logs, false
+	}
+	logs = logs[len(logs)-limit:]
+	if i := strings.IndexByte(logs, '\n'); i >= 0 && i < len(logs)-1 {
+		logs = logs[i+1:]
+	}
+	return logs, true
+}
@@ -0,0 +1,546 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kitsnail/ips/internal/k8s"
+	"github.com/kitsnail/ips/internal/repository"
+	"github.com/kitsnail/ips/pkg/models"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ErrInvalidNodeWarmupConfig 新节点自动预热配置无效
+var ErrInvalidNodeWarmupConfig = errors.New("invalid node warmup config")
+
+const (
+	// nodeWarmupKey 新节点自动预热配置在配置表中的 key
+	nodeWarmupKey = "node_warmup"
+	// nodeWarmupWarmedKey 已处理节点在配置表中的 key，选主切换后新 Leader 据此避免重复预热
+	nodeWarmupWarmedKey = "node_warmup_warmed"
+	// nodeWarmupCreator 镜像库预热任务的创建者
+	nodeWarmupCreator = "node-warmup"
+	// nodeWarmupLibrarySource 镜像库条目对应的预热来源
+	nodeWarmupLibrarySource = "library"
+	// defaultNodeWarmupInterval 检查等待预热节点的间隔
+	defaultNodeWarmupInterval = 5 * time.Second
+)
+
+// NodeWarmupController 新节点自动预热控制器
+// 节点变为就绪时记录为待预热节点，等待合并窗口后按镜像库条目及匹配的定时任务配置创建预热任务，只在 Leader 上运行
+type NodeWarmupController struct {
+	settingsRepo  repository.SettingsRepository
+	libraryRepo   repository.LibraryRepository
+	scheduledRepo repository.ScheduledTaskRepository
+	taskManager   *TaskManager
+	k8sClient     *k8s.Client
+	informer      *k8s.NodeInformer // 为空或未同步时直接查询 API
+	logger        *logrus.Logger
+
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*pendingWarmupNode // nodeName -> 等待预热的节点
+	warmed  map[types.UID]time.Time       // 已处理的节点，避免重复预热，持久化到配置表
+	created []time.Time                   // 最近一分钟内创建预热任务的时间，用于限速
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// pendingWarmupNode 等待预热的节点
+type pendingWarmupNode struct {
+	uid     types.UID
+	readyAt time.Time
+	done    map[string]bool // 已创建预热任务的来源，限速时剩余来源延后处理
+}
+
+// warmupSource 预热来源：镜像库条目或定时任务配置
+type warmupSource struct {
+	key       string
+	config    models.TaskConfig
+	createdBy string
+}
+
+// NewNodeWarmupController 创建新节点自动预热控制器
+func NewNodeWarmupController(
+	settingsRepo repository.SettingsRepository,
+	libraryRepo repository.LibraryRepository,
+	scheduledRepo repository.ScheduledTaskRepository,
+	taskManager *TaskManager,
+	k8sClient *k8s.Client,
+	logger *logrus.Logger,
+) *NodeWarmupController {
+	return &NodeWarmupController{
+		settingsRepo:  settingsRepo,
+		libraryRepo:   libraryRepo,
+		scheduledRepo: scheduledRepo,
+		taskManager:   taskManager,
+		k8sClient:     k8sClient,
+		logger:        logger,
+		interval:      defaultNodeWarmupInterval,
+		pending:       make(map[string]*pendingWarmupNode),
+		warmed:        make(map[types.UID]time.Time),
+	}
+}
+
+// SetNodeInformer 设置节点 Informer，需在 Informer 启动前调用
+func (c *NodeWarmupController) SetNodeInformer(informer *k8s.NodeInformer) error {
+	if err := informer.AddEventHandler(c.onNodeEvent); err != nil {
+		return err
+	}
+	c.informer = informer
+	return nil
+}
+
+// Start 启动预热循环（成为 Leader 时调用），ctx 结束或调用 Stop 时停止
+// 启动时加载已处理的节点，并将其余已就绪的节点加入待预热列表，接管选主切换期间加入的节点（超过最大创建时长的节点会被忽略）
+func (c *NodeWarmupController) Start(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancel != nil {
+		return
+	}
+
+	warmed, err := c.loadWarmed(ctx)
+	if err != nil {
+		// 加载失败时已处理的节点可能被重复预热，预热任务会跳过已有镜像的节点
+		c.logger.WithField("error", err).Warn("Failed to load warmed nodes")
+	}
+	for uid, at := range warmed {
+		c.warmed[uid] = at
+	}
+
+	if c.informer != nil && c.informer.HasSynced() {
+		now := time.Now()
+		for _, node := range c.informer.ListNodes() {
+			if k8s.IsNodeReady(&node) {
+				c.addPendingLocked(&node, now)
+			}
+		}
+	}
+
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.done = make(chan struct{})
+	go c.run(ctx, c.done)
+	c.logger.Info("Node warmup controller started")
+}
+
+// Stop 停止预热循环并清空待预热节点（重复调用无副作用）
+func (c *NodeWarmupController) Stop() {
+	c.mu.Lock()
+	cancel, done := c.cancel, c.done
+	c.cancel, c.done = nil, nil
+	c.pending = make(map[string]*pendingWarmupNode)
+	c.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+	c.logger.Info("Node warmup controller stopped")
+}
+
+// run 定期为超过合并窗口的待预热节点创建任务
+func (c *NodeWarmupController) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.flush(ctx, time.Now()); err != nil && ctx.Err() == nil {
+				c.logger.WithField("error", err).Error("Failed to warm up new nodes")
+			}
+		}
+	}
+}
+
+// onNodeEvent 节点由未就绪变为就绪（或以就绪状态加入）时加入待预热列表，只在运行时记录
+func (c *NodeWarmupController) onNodeEvent(old, node *corev1.Node) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancel == nil {
+		return
+	}
+
+	if node == nil {
+		delete(c.pending, old.Name)
+		return
+	}
+	if !k8s.IsNodeReady(node) || (old != nil && k8s.IsNodeReady(old)) {
+		return
+	}
+	c.addPendingLocked(node, time.Now())
+}
+
+// addPendingLocked 将节点加入待预热列表，已处理或已在列表中的节点忽略，调用方需持有 mu
+func (c *NodeWarmupController) addPendingLocked(node *corev1.Node, now time.Time) {
+	if _, ok := c.warmed[node.UID]; ok {
+		return
+	}
+	if pending, ok := c.pending[node.Name]; ok && pending.uid == node.UID {
+		return
+	}
+	c.pending[node.Name] = &pendingWarmupNode{
+		uid:     node.UID,
+		readyAt: now,
+		done:    make(map[string]bool),
+	}
+}
+
+// flush 为就绪时间超过合并窗口的待预热节点创建任务
+// 同一来源的节点合并为一个任务；超过限速时剩余节点保留到下次处理
+func (c *NodeWarmupController) flush(ctx context.Context, now time.Time) error {
+	config, err := c.loadConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if !config.Enabled {
+		c.pending = make(map[string]*pendingWarmupNode)
+		c.mu.Unlock()
+		return nil
+	}
+	var warmedChanged bool
+	for uid, at := range c.warmed {
+		// 超过最大创建时长的节点不会再被预热，无需继续记录
+		if now.Sub(at) > config.MaxNodeAge() {
+			delete(c.warmed, uid)
+			warmedChanged = true
+		}
+	}
+	due := make(map[string]*pendingWarmupNode)
+	for name, pending := range c.pending {
+		if now.Sub(pending.readyAt) >= config.BatchWindow() {
+			due[name] = pending
+		}
+	}
+	c.mu.Unlock()
+
+	if len(due) == 0 {
+		if warmedChanged {
+			return c.saveWarmed(ctx)
+		}
+		return nil
+	}
+
+	nodes, err := c.listNodes(ctx)
+	if err != nil {
+		return err
+	}
+	// 只处理仍然存在、就绪且创建时间在范围内的节点，其余节点直接移出待预热列表
+	var candidates []corev1.Node
+	for i := range nodes {
+		node := &nodes[i]
+		pending, ok := due[node.Name]
+		if !ok || pending.uid != node.UID || !k8s.IsNodeReady(node) || now.Sub(node.CreationTimestamp.Time) > config.MaxNodeAge() {
+			continue
+		}
+		candidates = append(candidates, *node)
+	}
+
+	var limited bool
+	if len(candidates) > 0 {
+		sources, err := c.warmupSources(ctx, config)
+		if err != nil {
+			return err
+		}
+		limited = c.createWarmupTasks(ctx, config, sources, candidates, due, now)
+	}
+
+	c.mu.Lock()
+	isCandidate := make(map[string]bool, len(candidates))
+	for i := range candidates {
+		isCandidate[candidates[i].Name] = true
+	}
+	for name, pending := range due {
+		if limited && isCandidate[name] {
+			continue
+		}
+		if c.pending[name] == pending {
+			delete(c.pending, name)
+		}
+		if isCandidate[name] {
+			c.warmed[pending.uid] = now
+			warmedChanged = true
+		}
+	}
+	c.mu.Unlock()
+
+	if warmedChanged {
+		return c.saveWarmed(ctx)
+	}
+	return nil
+}
+
+// loadWarmed 从配置表加载已处理的节点，未保存过时返回空
+func (c *NodeWarmupController) loadWarmed(ctx context.Context) (map[types.UID]time.Time, error) {
+	value, err := c.settingsRepo.GetSetting(ctx, nodeWarmupWarmedKey)
+	if errors.Is(err, repository.ErrSettingNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load warmed nodes: %w", err)
+	}
+
+	var warmed map[types.UID]time.Time
+	if err := json.Unmarshal([]byte(value), &warmed); err != nil {
+		return nil, fmt.Errorf("failed to decode warmed nodes: %w", err)
+	}
+	return warmed, nil
+}
+
+// saveWarmed 将已处理的节点保存到配置表
+func (c *NodeWarmupController) saveWarmed(ctx context.Context) error {
+	c.mu.Lock()
+	data, err := json.Marshal(c.warmed)
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode warmed nodes: %w", err)
+	}
+	if err := c.settingsRepo.SetSetting(ctx, nodeWarmupWarmedKey, string(data)); err != nil {
+		return fmt.Errorf("failed to save warmed nodes: %w", err)
+	}
+	return nil
+}
+
+// createWarmupTasks 为每个来源匹配的节点创建预热任务，达到限速时停止并返回 true
+func (c *NodeWarmupController) createWarmupTasks(ctx context.Context, config *models.NodeWarmupConfig, sources []warmupSource, candidates []corev1.Node, due map[string]*pendingWarmupNode, now time.Time) bool {
+	for _, source := range sources {
+		matched, err := selectNodes(&source.config, candidates)
+		if err != nil {
+			c.logger.WithFields(logrus.Fields{
+				"source": source.key,
+				"error":  err,
+			}).Warn("Skipping warmup source with invalid node selection")
+			continue
+		}
+
+		var nodeNames []string
+		for i := range matched {
+			if !due[matched[i].Name].done[source.key] {
+				nodeNames = append(nodeNames, matched[i].Name)
+			}
+		}
+		if len(nodeNames) == 0 {
+			continue
+		}
+
+		if !c.allowTask(now, config.TaskRateLimit()) {
+			c.logger.WithFields(logrus.Fields{
+				"source":            source.key,
+				"maxTasksPerMinute": config.TaskRateLimit(),
+			}).Warn("Node warmup rate limit reached, deferring remaining nodes")
+			return true
+		}
+
+		req := nodesTaskRequest(&source.config, "warmup", source.createdBy, nodeNames)
+		task, err := c.taskManager.CreateTask(ctx, req)
+		if err != nil {
+			// 配置错误等无法通过重试解决，记录后不再为这些节点创建该来源的任务
+			c.logger.WithFields(logrus.Fields{
+				"source": source.key,
+				"nodes":  nodeNames,
+				"error":  err,
+			}).Error("Failed to create warmup task for new nodes")
+		} else {
+			c.logger.WithFields(logrus.Fields{
+				"source": source.key,
+				"taskId": task.ID,
+				"nodes":  nodeNames,
+			}).Info("Created warmup task for new nodes")
+		}
+		for _, nodeName := range nodeNames {
+			due[nodeName].done[source.key] = true
+		}
+	}
+	return false
+}
+
+// allowTask 最近一分钟内创建的任务数未达到限制时记录本次创建并返回 true
+func (c *NodeWarmupController) allowTask(now time.Time, limit int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	recent := c.created[:0]
+	for _, at := range c.created {
+		if now.Sub(at) < time.Minute {
+			recent = append(recent, at)
+		}
+	}
+	c.created = recent
+	if len(c.created) >= limit {
+		return false
+	}
+	c.created = append(c.created, now)
+	return true
+}
+
+// warmupSources 返回配置的预热来源，已删除的定时任务会被跳过
+func (c *NodeWarmupController) warmupSources(ctx context.Context, config *models.NodeWarmupConfig) ([]warmupSource, error) {
+	var sources []warmupSource
+
+	if len(config.LibraryImageIDs) > 0 {
+		library, err := c.libraryImages(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var images []string
+		for _, id := range config.LibraryImageIDs {
+			if image, ok := library[id]; ok && !containsString(images, image.Image) {
+				images = append(images, image.Image)
+			}
+		}
+		if len(images) > 0 {
+			sources = append(sources, warmupSource{
+				key: nodeWarmupLibrarySource,
+				config: models.TaskConfig{
+					Images:       images,
+					BatchSize:    config.LibraryBatchSize(),
+					NodeSelector: config.LibraryNodeSelector,
+				},
+				createdBy: nodeWarmupCreator,
+			})
+		}
+	}
+
+	for _, id := range config.ScheduledTaskIDs {
+		scheduledTask, err := c.scheduledRepo.GetScheduledTask(ctx, id)
+		if errors.Is(err, repository.ErrScheduledTaskNotFound) {
+			c.logger.WithField("scheduledTaskId", id).Warn("Scheduled task configured for node warmup not found")
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get scheduled task %s: %w", id, err)
+		}
+		sources = append(sources, warmupSource{
+			key:       "scheduled/" + id,
+			config:    scheduledTask.TaskConfig,
+			createdBy: scheduledTask.CreatedBy,
+		})
+	}
+	return sources, nil
+}
+
+// libraryImages 返回镜像库中的所有条目
+func (c *NodeWarmupController) libraryImages(ctx context.Context) (map[int64]*models.LibraryImage, error) {
+	const pageSize = 100
+
+	result := make(map[int64]*models.LibraryImage)
+	for offset := 0; ; offset += pageSize {
+		images, total, err := c.libraryRepo.ListImages(ctx, offset, pageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list library images: %w", err)
+		}
+		for _, image := range images {
+			result[image.ID] = image
+		}
+		if len(images) == 0 || offset+pageSize >= total {
+			return result, nil
+		}
+	}
+}
+
+// listNodes 列出所有节点，节点 Informer 已同步时从缓存读取
+func (c *NodeWarmupController) listNodes(ctx context.Context) ([]corev1.Node, error) {
+	if c.informer != nil && c.informer.HasSynced() {
+		return c.informer.ListNodes(), nil
+	}
+	return c.k8sClient.GetNodes(ctx)
+}
+
+// loadConfig 从配置表加载新节点自动预热配置，未保存过时返回禁用的默认配置
+func (c *NodeWarmupController) loadConfig(ctx context.Context) (*models.NodeWarmupConfig, error) {
+	value, err := c.settingsRepo.GetSetting(ctx, nodeWarmupKey)
+	if errors.Is(err, repository.ErrSettingNotFound) {
+		return &models.NodeWarmupConfig{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load node warmup config: %w", err)
+	}
+
+	var config models.NodeWarmupConfig
+	if err := json.Unmarshal([]byte(value), &config); err != nil {
+		return nil, fmt.Errorf("failed to decode node warmup config: %w", err)
+	}
+	return &config, nil
+}
+
+// GetStatus 返回新节点自动预热配置及等待预热的节点
+func (c *NodeWarmupController) GetStatus(ctx context.Context) (*models.NodeWarmupStatus, error) {
+	config, err := c.loadConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	pendingNodes := make([]string, 0, len(c.pending))
+	for name := range c.pending {
+		pendingNodes = append(pendingNodes, name)
+	}
+	c.mu.Unlock()
+	sort.Strings(pendingNodes)
+
+	return &models.NodeWarmupStatus{
+		Config:       *config,
+		PendingNodes: pendingNodes,
+	}, nil
+}
+
+// UpdateConfig 校验并保存新节点自动预热配置，Leader 在下次检查时生效
+func (c *NodeWarmupController) UpdateConfig(ctx context.Context, config *models.NodeWarmupConfig) error {
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidNodeWarmupConfig, err)
+	}
+	if err := ValidateNodeSelection(config.LibraryNodeSelector, nil); err != nil {
+		return fmt.Errorf("%w: libraryNodeSelector: %v", ErrInvalidNodeWarmupConfig, err)
+	}
+
+	if len(config.LibraryImageIDs) > 0 {
+		library, err := c.libraryImages(ctx)
+		if err != nil {
+			return err
+		}
+		for _, id := range config.LibraryImageIDs {
+			if _, ok := library[id]; !ok {
+				return fmt.Errorf("%w: library image %d not found", ErrInvalidNodeWarmupConfig, id)
+			}
+		}
+	}
+	for _, id := range config.ScheduledTaskIDs {
+		if _, err := c.scheduledRepo.GetScheduledTask(ctx, id); err != nil {
+			if errors.Is(err, repository.ErrScheduledTaskNotFound) {
+				return fmt.Errorf("%w: scheduled task %s not found", ErrInvalidNodeWarmupConfig, id)
+			}
+			return fmt.Errorf("failed to get scheduled task %s: %w", id, err)
+		}
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to encode node warmup config: %w", err)
+	}
+	if err := c.settingsRepo.SetSetting(ctx, nodeWarmupKey, string(data)); err != nil {
+		return fmt.Errorf("failed to save node warmup config: %w", err)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"enabled":          config.Enabled,
+		"libraryImageIds":  config.LibraryImageIDs,
+		"scheduledTaskIds": config.ScheduledTaskIDs,
+	}).Info("Node warmup config updated")
+	return nil
+}
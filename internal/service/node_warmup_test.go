@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kitsnail/ips/internal/repository"
+	"github.com/kitsnail/ips/pkg/models"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// warmupTestNode 创建指定创建时间的就绪节点
+func warmupTestNode(name string, labels map[string]string, created time.Time) *corev1.Node {
+	node := policyTestNode(name, labels)
+	node.UID = types.UID(name + "-uid")
+	node.CreationTimestamp = metav1.NewTime(created)
+	return node
+}
+
+func setupNodeWarmupController(t *testing.T, nodes ...*corev1.Node) (*NodeWarmupController, *repository.MemoryRepository) {
+	t.Helper()
+
+	objects := make([]runtime.Object, 0, len(nodes))
+	for _, node := range nodes {
+		objects = append(objects, node)
+	}
+	taskManager, repo, k8sClient := setupTaskManager(t, objects...)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	controller := NewNodeWarmupController(repo, repo, repo, taskManager, k8sClient, logger)
+	// 测试中手动调用 flush
+	controller.interval = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	controller.Start(ctx)
+	t.Cleanup(func() {
+		controller.Stop()
+		cancel()
+	})
+	return controller, repo
+}
+
+// notReady 返回节点未就绪时的副本
+func notReady(node *corev1.Node) *corev1.Node {
+	old := node.DeepCopy()
+	old.Status.Conditions[0].Status = corev1.ConditionFalse
+	return old
+}
+
+func TestNodeWarmupController_Flush(t *testing.T) {
+	now := time.Now()
+	gpuNode := warmupTestNode("gpu-new", map[string]string{"pool": "gpu"}, now)
+	cpuNode := warmupTestNode("cpu-new", map[string]string{"pool": "cpu"}, now)
+	oldNode := warmupTestNode("gpu-old", map[string]string{"pool": "gpu"}, now.Add(-24*time.Hour))
+	controller, repo := setupNodeWarmupController(t, gpuNode, cpuNode, oldNode)
+	ctx := context.Background()
+
+	library := &models.LibraryImage{Name: "nginx", Image: "nginx:1.25"}
+	require.NoError(t, repo.SaveImage(ctx, library))
+	require.NoError(t, repo.CreateScheduledTask(ctx, &models.ScheduledTask{
+		ID:        "sched-gpu",
+		CreatedBy: "alice",
+		TaskConfig: models.TaskConfig{
+			Images:       []string{"ml/trainer:v3"},
+			BatchSize:    5,
+			NodeSelector: map[string]string{"pool": "gpu"},
+		},
+	}))
+	require.NoError(t, controller.UpdateConfig(ctx, &models.NodeWarmupConfig{
+		Enabled:          true,
+		LibraryImageIDs:  []int64{library.ID},
+		ScheduledTaskIDs: []string{"sched-gpu"},
+	}))
+
+	controller.onNodeEvent(notReady(gpuNode), gpuNode)
+	controller.onNodeEvent(nil, cpuNode)
+	controller.onNodeEvent(notReady(oldNode), oldNode)
+	// 已就绪节点的更新不重复加入
+	controller.onNodeEvent(gpuNode, gpuNode)
+
+	status, err := controller.GetStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cpu-new", "gpu-new", "gpu-old"}, status.PendingNodes)
+
+	// 合并窗口内不创建任务
+	require.NoError(t, controller.flush(ctx, time.Now()))
+	_, total, err := repo.ListTasks(ctx, 0, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+
+	// 镜像库条目预热所有新节点，定时任务配置只预热匹配的节点，超过最大创建时长的节点被忽略
+	require.NoError(t, controller.flush(ctx, time.Now().Add(models.DefaultNodeWarmupBatchWindow)))
+	tasks, total, err := repo.ListTasks(ctx, 0, 100)
+	require.NoError(t, err)
+	require.Equal(t, 2, total)
+
+	byCreator := make(map[string]*models.Task)
+	for _, task := range tasks {
+		byCreator[task.CreatedBy] = task
+		assert.True(t, task.SkipPresent)
+	}
+	require.Contains(t, byCreator, nodeWarmupCreator)
+	assert.Equal(t, []string{"nginx:1.25"}, byCreator[nodeWarmupCreator].Images)
+	assert.Equal(t, []string{"cpu-new", "gpu-new"}, byCreator[nodeWarmupCreator].NodeSelection.IncludeNodes)
+	require.Contains(t, byCreator, "alice")
+	assert.Equal(t, []string{"ml/trainer:v3"}, byCreator["alice"].Images)
+	assert.Equal(t, []string{"gpu-new"}, byCreator["alice"].NodeSelection.IncludeNodes)
+
+	// 已预热的节点再次变为就绪时不重复预热
+	controller.onNodeEvent(notReady(gpuNode), gpuNode)
+	status, err = controller.GetStatus(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, status.PendingNodes)
+}
+
+func TestNodeWarmupController_RateLimit(t *testing.T) {
+	node := warmupTestNode("gpu-new", map[string]string{"pool": "gpu"}, time.Now())
+	controller, repo := setupNodeWarmupController(t, node)
+	ctx := context.Background()
+
+	library := &models.LibraryImage{Name: "nginx", Image: "nginx:1.25"}
+	require.NoError(t, repo.SaveImage(ctx, library))
+	require.NoError(t, repo.CreateScheduledTask(ctx, &models.ScheduledTask{
+		ID:         "sched-all",
+		TaskConfig: models.TaskConfig{Images: []string{"redis:7"}, BatchSize: 5},
+	}))
+	require.NoError(t, controller.UpdateConfig(ctx, &models.NodeWarmupConfig{
+		Enabled:           true,
+		LibraryImageIDs:   []int64{library.ID},
+		ScheduledTaskIDs:  []string{"sched-all"},
+		MaxTasksPerMinute: 1,
+	}))
+
+	controller.onNodeEvent(nil, node)
+	flushAt := time.Now().Add(models.DefaultNodeWarmupBatchWindow)
+
+	// 超过限速的来源延后到下一分钟处理，节点保留在待预热列表中
+	require.NoError(t, controller.flush(ctx, flushAt))
+	_, total, err := repo.ListTasks(ctx, 0, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	status, err := controller.GetStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gpu-new"}, status.PendingNodes)
+
+	require.NoError(t, controller.flush(ctx, flushAt.Add(time.Minute)))
+	_, total, err = repo.ListTasks(ctx, 0, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	status, err = controller.GetStatus(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, status.PendingNodes)
+}
+
+func TestNodeWarmupController_WarmedNodesSurviveFailover(t *testing.T) {
+	node := warmupTestNode("gpu-new", map[string]string{"pool": "gpu"}, time.Now())
+	controller, repo := setupNodeWarmupController(t, node)
+	ctx := context.Background()
+
+	library := &models.LibraryImage{Name: "nginx", Image: "nginx:1.25"}
+	require.NoError(t, repo.SaveImage(ctx, library))
+	require.NoError(t, controller.UpdateConfig(ctx, &models.NodeWarmupConfig{
+		Enabled:         true,
+		LibraryImageIDs: []int64{library.ID},
+	}))
+
+	controller.onNodeEvent(nil, node)
+	require.NoError(t, controller.flush(ctx, time.Now().Add(models.DefaultNodeWarmupBatchWindow)))
+	_, total, err := repo.ListTasks(ctx, 0, 100)
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+
+	// 新 Leader 从配置表加载已处理的节点，节点再次就绪时不重复预热
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	next := NewNodeWarmupController(repo, repo, repo, controller.taskManager, controller.k8sClient, logger)
+	next.interval = time.Hour
+	next.Start(ctx)
+	t.Cleanup(next.Stop)
+
+	next.onNodeEvent(notReady(node), node)
+	status, err := next.GetStatus(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, status.PendingNodes)
+}
+
+func TestNodeWarmupController_UpdateConfig(t *testing.T) {
+	controller, repo := setupNodeWarmupController(t)
+	ctx := context.Background()
+
+	library := &models.LibraryImage{Name: "nginx", Image: "nginx:1.25"}
+	require.NoError(t, repo.SaveImage(ctx, library))
+
+	tests := []struct {
+		name    string
+		config  models.NodeWarmupConfig
+		wantErr bool
+	}{
+		{"disabled without sources", models.NodeWarmupConfig{}, false},
+		{"library image", models.NodeWarmupConfig{Enabled: true, LibraryImageIDs: []int64{library.ID}}, false},
+		{"enabled without sources", models.NodeWarmupConfig{Enabled: true}, true},
+		{"unknown library image", models.NodeWarmupConfig{Enabled: true, LibraryImageIDs: []int64{404}}, true},
+		{"unknown scheduled task", models.NodeWarmupConfig{Enabled: true, ScheduledTaskIDs: []string{"missing"}}, true},
+		{"invalid node selector", models.NodeWarmupConfig{Enabled: true, LibraryImageIDs: []int64{library.ID}, LibraryNodeSelector: map[string]string{"bad key": "x"}}, true},
+		{"negative rate limit", models.NodeWarmupConfig{MaxTasksPerMinute: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := controller.UpdateConfig(ctx, &tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UpdateConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				assert.ErrorIs(t, err, ErrInvalidNodeWarmupConfig)
+			}
+		})
+	}
+
+	status, err := controller.GetStatus(ctx)
+	require.NoError(t, err)
+	assert.True(t, status.Config.Enabled)
+	assert.Equal(t, []int64{library.ID}, status.Config.LibraryImageIDs)
+}
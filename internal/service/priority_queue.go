@@ -2,7 +2,9 @@ package service
 
 import (
 	"container/heap"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/kitsnail/ips/pkg/models"
 )
@@ -18,6 +20,10 @@ type TaskItem struct {
 type PriorityQueue struct {
 	items []*TaskItem
 	mu    sync.RWMutex
+
+	// agingInterval 优先级老化间隔，任务每等待一个间隔有效优先级加 1
+	// 为 0 时不启用老化
+	agingInterval time.Duration
 }
 
 // NewPriorityQueue 创建优先级队列
@@ -29,6 +35,13 @@ func NewPriorityQueue() *PriorityQueue {
 	return pq
 }
 
+// NewPriorityQueueWithAging 创建带优先级老化的队列，避免低优先级任务饿死
+func NewPriorityQueueWithAging(agingInterval time.Duration) *PriorityQueue {
+	pq := NewPriorityQueue()
+	pq.agingInterval = agingInterval
+	return pq
+}
+
 // Len 返回队列长度
 func (pq *PriorityQueue) Len() int {
 	return len(pq.items)
@@ -37,12 +50,26 @@ func (pq *PriorityQueue) Len() int {
 // Less 比较两个任务的优先级
 // 优先级高的排在前面；如果优先级相同，按创建时间排序（先进先出）
 func (pq *PriorityQueue) Less(i, j int) bool {
+	return pq.less(pq.items[i].Task, pq.items[j].Task)
+}
+
+func (pq *PriorityQueue) less(a, b *models.Task) bool {
 	// 优先级高的排前面
-	if pq.items[i].Task.Priority != pq.items[j].Task.Priority {
-		return pq.items[i].Task.Priority > pq.items[j].Task.Priority
+	if pa, pb := pq.score(a), pq.score(b); pa != pb {
+		return pa > pb
 	}
 	// 优先级相同时，按创建时间排序（早创建的排前面）
-	return pq.items[i].Task.CreatedAt.Before(pq.items[j].Task.CreatedAt)
+	return a.CreatedAt.Before(b.CreatedAt)
+}
+
+// score 计算用于排序的优先级
+// 启用老化时有效优先级为 Priority + 等待时长/老化间隔。所有任务随时间以相同速率老化，
+// 因此以 CreatedAt 代替当前时间计算得到的相对顺序不随时间变化，堆结构始终有效
+func (pq *PriorityQueue) score(task *models.Task) float64 {
+	if pq.agingInterval <= 0 {
+		return float64(task.Priority)
+	}
+	return float64(task.Priority) - float64(task.CreatedAt.UnixNano())/float64(pq.agingInterval)
 }
 
 // Swap 交换两个元素
@@ -95,6 +122,27 @@ func (pq *PriorityQueue) Dequeue() *models.Task {
 	return item.Task
 }
 
+// DequeueFunc 按优先级顺序取出第一个满足条件的任务
+// 用于跳过因并发限制暂时无法执行的任务，没有满足条件的任务时返回 nil
+func (pq *PriorityQueue) DequeueFunc(accept func(task *models.Task) bool) *models.Task {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+
+	for _, task := range pq.sorted() {
+		if !accept(task) {
+			continue
+		}
+		for _, item := range pq.items {
+			if item.Task == task {
+				heap.Remove(pq, item.index)
+				break
+			}
+		}
+		return task
+	}
+	return nil
+}
+
 // Peek 查看队首元素但不移除
 func (pq *PriorityQueue) Peek() *models.Task {
 	pq.mu.RLock()
@@ -122,3 +170,73 @@ func (pq *PriorityQueue) Size() int {
 
 	return pq.Len()
 }
+
+// Remove 移除指定任务，返回任务是否在队列中
+func (pq *PriorityQueue) Remove(taskID string) bool {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+
+	for _, item := range pq.items {
+		if item.Task.ID == taskID {
+			heap.Remove(pq, item.index)
+			return true
+		}
+	}
+	return false
+}
+
+// Contains 检查任务是否在队列中
+func (pq *PriorityQueue) Contains(taskID string) bool {
+	pq.mu.RLock()
+	defer pq.mu.RUnlock()
+
+	for _, item := range pq.items {
+		if item.Task.ID == taskID {
+			return true
+		}
+	}
+	return false
+}
+
+// Position 返回任务在队列中的位置（从 1 开始），不在队列中时返回 0
+func (pq *PriorityQueue) Position(taskID string) int {
+	pq.mu.RLock()
+	defer pq.mu.RUnlock()
+
+	for i, task := range pq.sorted() {
+		if task.ID == taskID {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// List 按出队顺序返回队列中的任务
+func (pq *PriorityQueue) List() []*models.Task {
+	pq.mu.RLock()
+	defer pq.mu.RUnlock()
+
+	return pq.sorted()
+}
+
+// Clear 清空队列
+func (pq *PriorityQueue) Clear() {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+
+	for _, item := range pq.items {
+		item.index = -1
+	}
+	pq.items = make([]*TaskItem, 0)
+}
+
+func (pq *PriorityQueue) sorted() []*models.Task {
+	tasks := make([]*models.Task, 0, len(pq.items))
+	for _, item := range pq.items {
+		tasks = append(tasks, item.Task)
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return pq.less(tasks[i], tasks[j])
+	})
+	return tasks
+}
@@ -128,3 +128,62 @@ func TestPriorityQueue_IsEmpty(t *testing.T) {
 		t.Error("Queue should be empty after dequeue")
 	}
 }
+
+func TestPriorityQueue_Aging(t *testing.T) {
+	pq := NewPriorityQueueWithAging(time.Minute)
+
+	now := time.Now()
+	// 低优先级任务已等待 10 分钟，有效优先级 3+10 超过新建的高优先级任务
+	oldLow := &models.Task{ID: "old-low", Priority: 3, CreatedAt: now.Add(-10 * time.Minute)}
+	newHigh := &models.Task{ID: "new-high", Priority: 8, CreatedAt: now}
+	recentLow := &models.Task{ID: "recent-low", Priority: 3, CreatedAt: now.Add(-1 * time.Minute)}
+
+	pq.Enqueue(newHigh)
+	pq.Enqueue(recentLow)
+	pq.Enqueue(oldLow)
+
+	for _, want := range []string{"old-low", "new-high", "recent-low"} {
+		if got := pq.Dequeue(); got.ID != want {
+			t.Errorf("Expected %s, got %s", want, got.ID)
+		}
+	}
+}
+
+func TestPriorityQueue_RemoveAndPosition(t *testing.T) {
+	pq := NewPriorityQueue()
+
+	now := time.Now()
+	pq.Enqueue(&models.Task{ID: "task-1", Priority: 5, CreatedAt: now})
+	pq.Enqueue(&models.Task{ID: "task-2", Priority: 8, CreatedAt: now})
+	pq.Enqueue(&models.Task{ID: "task-3", Priority: 3, CreatedAt: now})
+
+	if pos := pq.Position("task-2"); pos != 1 {
+		t.Errorf("Expected task-2 at position 1, got %d", pos)
+	}
+	if pos := pq.Position("task-3"); pos != 3 {
+		t.Errorf("Expected task-3 at position 3, got %d", pos)
+	}
+	if pos := pq.Position("missing"); pos != 0 {
+		t.Errorf("Expected missing task at position 0, got %d", pos)
+	}
+
+	if !pq.Remove("task-1") {
+		t.Error("Expected task-1 to be removed")
+	}
+	if pq.Remove("task-1") {
+		t.Error("Expected second removal of task-1 to fail")
+	}
+	if pq.Contains("task-1") {
+		t.Error("Expected task-1 not to be in queue")
+	}
+	if pos := pq.Position("task-3"); pos != 2 {
+		t.Errorf("Expected task-3 at position 2, got %d", pos)
+	}
+
+	if got := pq.Dequeue(); got.ID != "task-2" {
+		t.Errorf("Expected task-2, got %s", got.ID)
+	}
+	if got := pq.Dequeue(); got.ID != "task-3" {
+		t.Errorf("Expected task-3, got %s", got.ID)
+	}
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kitsnail/ips/pkg/metrics"
+	"github.com/kitsnail/ips/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidRollout 灰度策略无效
+var ErrInvalidRollout = errors.New("invalid rollout strategy")
+
+// ErrCanaryFailed 金丝雀节点成功率未达到阈值
+var ErrCanaryFailed = errors.New("canary failed")
+
+// ValidateRollout 校验灰度策略，创建任务前调用
+func ValidateRollout(rollout *models.RolloutStrategy) error {
+	if err := rollout.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRollout, err)
+	}
+	return nil
+}
+
+// initCanary 确定目标节点后初始化金丝雀阶段，节点数不足以划分金丝雀时不设该阶段
+// 返回金丝雀之外的节点数
+func initCanary(task *models.Task, nodes []string) int {
+	canary, rest := splitCanary(task.Rollout, nodes)
+	if len(canary) == 0 {
+		task.Canary = nil
+		return len(rest)
+	}
+	task.Canary = &models.CanaryStatus{
+		Phase: models.CanaryPending,
+		Nodes: canary,
+	}
+	return len(rest)
+}
+
+// handleCanaryFailure 按灰度策略处理未达标的金丝雀：暂停任务或直接标记失败
+func (m *TaskManager) handleCanaryFailure(ctx context.Context, task *models.Task, startTime time.Time) error {
+	canary := task.Canary
+	message := fmt.Sprintf("canary success rate %.1f%% is below threshold %.1f%%, failed nodes: %v",
+		canary.SuccessRate, canary.Threshold, canary.FailedNodes)
+
+	if task.Rollout.Action() == models.CanaryActionPause {
+		// 先保存金丝雀结果（running 不会覆盖期间写入的暂停与终态），再仅从 running 条件更新为暂停
+		if err := m.repo.UpdateTask(ctx, task); err != nil {
+			return fmt.Errorf("failed to save canary result: %w", err)
+		}
+		paused, err := m.repo.UpdateTaskStatus(ctx, task.ID, models.TaskPaused, models.TaskRunning)
+		if err != nil {
+			return fmt.Errorf("failed to update task status to paused: %w", err)
+		}
+		if !paused {
+			// 任务已被取消或暂停，由对应的操作负责后续处理
+			m.logger.WithField("taskId", task.ID).Info("Canary failed but task is no longer running, skip pausing")
+			return nil
+		}
+		task.Status = models.TaskPaused
+		metrics.ActiveTasks.Dec()
+		m.events.publishStatus(task, message+", task paused")
+
+		m.logger.WithFields(logrus.Fields{
+			"taskId":      task.ID,
+			"successRate": canary.SuccessRate,
+			"threshold":   canary.Threshold,
+		}).Warn("Canary failed, task paused")
+		return nil
+	}
+
+	task.Status = models.TaskFailed
+	task.ErrorMessage = message
+	now := time.Now()
+	task.FinishedAt = &now
+	if err := m.repo.UpdateTask(ctx, task); err != nil {
+		return fmt.Errorf("failed to update task status to failed: %w", err)
+	}
+	m.events.publishStatus(task, message)
+
+	duration := time.Since(startTime).Seconds()
+	metrics.TasksTotal.WithLabelValues(string(models.TaskFailed)).Inc()
+	metrics.TaskDuration.WithLabelValues(string(models.TaskFailed)).Observe(duration)
+	metrics.ActiveTasks.Dec()
+
+	m.logger.WithFields(logrus.Fields{
+		"taskId":      task.ID,
+		"successRate": canary.SuccessRate,
+		"threshold":   canary.Threshold,
+	}).Error("Canary failed, task aborted")
+
+	if webhookErr := m.webhookNotifier.NotifyTaskFailed(ctx, task); webhookErr != nil {
+		m.logger.WithFields(logrus.Fields{
+			"taskId": task.ID,
+			"error":  webhookErr,
+		}).Warn("Failed to send webhook notification for failed task")
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kitsnail/ips/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskManager_CanaryFailurePausesTask(t *testing.T) {
+	manager, repo, k8sClient := setupTaskManager(t, newReadyNode("node-1"), newReadyNode("node-2"), newReadyNode("node-3"))
+	manager.batchScheduler.pollInterval = 20 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	manager.Start(ctx)
+	defer manager.Stop()
+
+	task, err := manager.CreateTask(ctx, &models.CreateTaskRequest{
+		Images:    []string{"nginx:latest"},
+		BatchSize: 10,
+		Rollout:   &models.RolloutStrategy{CanaryNodes: 1, OnFailure: models.CanaryActionPause},
+	})
+	require.NoError(t, err)
+
+	jobs := waitForJobs(t, k8sClient, task.ID, 1)
+	markJobFinished(t, k8sClient, jobs[0], false)
+
+	require.Eventually(t, func() bool {
+		stored, err := repo.GetTask(ctx, task.ID)
+		return err == nil && stored.Status == models.TaskPaused
+	}, 5*time.Second, 20*time.Millisecond)
+	waitForJobs(t, k8sClient, task.ID, 1)
+
+	stored, err := repo.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.CanaryFailed, stored.Canary.Phase)
+	assert.Equal(t, 2, stored.Progress.TotalBatches)
+
+	// 人工确认后恢复，提交其余节点
+	_, err = manager.ResumeTask(ctx, task.ID)
+	require.NoError(t, err)
+	waitForJobs(t, k8sClient, task.ID, 3)
+
+	_, err = manager.DeleteTask(ctx, task.ID)
+	require.NoError(t, err)
+}
+
+func TestTaskManager_CanaryFailureKeepsCancelledTask(t *testing.T) {
+	manager, repo, _ := setupTaskManager(t)
+	ctx := context.Background()
+
+	task := &models.Task{
+		ID:       "task-canary-cancelled",
+		Status:   models.TaskRunning,
+		Images:   []string{"nginx:latest"},
+		Progress: &models.Progress{TotalNodes: 3},
+		Rollout:  &models.RolloutStrategy{CanaryNodes: 1, OnFailure: models.CanaryActionPause},
+	}
+	executing := *task
+	require.NoError(t, repo.CreateTask(ctx, task))
+	cancelled, err := repo.UpdateTaskStatus(ctx, task.ID, models.TaskCancelled, models.TaskRunning)
+	require.NoError(t, err)
+	require.True(t, cancelled)
+
+	// 执行器持有的任务仍为 running，金丝雀未达标时任务已被取消，不再暂停
+	executing.Canary = &models.CanaryStatus{Phase: models.CanaryFailed, Nodes: []string{"node-1"}, Failed: 1}
+	require.NoError(t, manager.handleCanaryFailure(ctx, &executing, time.Now()))
+
+	stored, err := repo.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TaskCancelled, stored.Status)
+	assert.Equal(t, models.TaskRunning, executing.Status)
+}
+
+func TestTaskManager_CreateTask_InvalidRollout(t *testing.T) {
+	manager, _, _ := setupTaskManager(t)
+
+	_, err := manager.CreateTask(context.Background(), &models.CreateTaskRequest{
+		Images:    []string{"nginx:latest"},
+		BatchSize: 10,
+		Rollout:   &models.RolloutStrategy{CanaryNodes: 1, CanaryPercent: 10},
+	})
+	assert.ErrorIs(t, err, ErrInvalidRollout)
+}
+
+func TestTaskManager_PlanTask_Canary(t *testing.T) {
+	taskManager, _, _ := setupTaskManager(t, newReadyNode("node-1"), newReadyNode("node-2"), newReadyNode("node-3"))
+
+	plan, err := taskManager.PlanTask(context.Background(), &models.CreateTaskRequest{
+		Images:    []string{"nginx:latest"},
+		BatchSize: 10,
+		Rollout:   &models.RolloutStrategy{CanaryPercent: 30},
+	})
+	require.NoError(t, err)
+	require.Len(t, plan.Batches, 2)
+	assert.Equal(t, models.PlanBatch{Batch: 1, Nodes: []string{"node-1"}, Canary: true}, plan.Batches[0])
+	assert.Equal(t, []string{"node-2", "node-3"}, plan.Batches[1].Nodes)
+}
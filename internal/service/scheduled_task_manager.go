@@ -27,6 +27,8 @@ type ScheduledTaskManager struct {
 	cronScheduler  *cron.Cron
 	mu             sync.RWMutex
 	cronEntries    map[string]cron.EntryID
+	cronSpecs      map[string]string
+	syncInterval   time.Duration
 	executingTasks map[string]bool
 	taskQueue      map[string][]string
 	ctx            context.Context
@@ -46,6 +48,8 @@ func NewScheduledTaskManager(
 		logger:            logger,
 		cronScheduler:     cron.New(cron.WithParser(cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor))),
 		cronEntries:       make(map[string]cron.EntryID),
+		cronSpecs:         make(map[string]string),
+		syncInterval:      30 * time.Second,
 		executingTasks:    make(map[string]bool),
 		taskQueue:         make(map[string][]string),
 	}
@@ -62,6 +66,10 @@ func (m *ScheduledTaskManager) Start() error {
 		}
 
 		for _, task := range tasks {
+			// Follower 期间通过 API 添加的任务已在调度器中
+			if _, exists := m.cronEntries[task.ID]; exists {
+				continue
+			}
 			if err := m.addTaskToScheduler(task); err != nil {
 				m.logger.WithFields(logrus.Fields{
 					"taskId":   task.ID,
@@ -73,7 +81,14 @@ func (m *ScheduledTaskManager) Start() error {
 		}
 
 		metrics.ActiveScheduledTasks.Set(float64(len(tasks)))
+
+		// 先恢复上一个 Leader 遗留的执行记录，再启动调度，避免新的触发与仍在执行的任务重叠
+		m.recoverRunningExecutions(context.Background())
 		m.cronScheduler.Start()
+
+		m.ctx, m.cancel = context.WithCancel(context.Background())
+		go m.syncLoop(m.ctx)
+
 		m.logger.WithField("tasksLoaded", len(tasks)).Info("Scheduled task manager started")
 	}
 
@@ -82,19 +97,85 @@ func (m *ScheduledTaskManager) Start() error {
 
 func (m *ScheduledTaskManager) Stop() {
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+	}
+	m.mu.Unlock()
 
+	// 等待正在运行的调度任务结束，不持有锁以免与任务执行互相等待
 	if m.cronScheduler != nil {
 		ctx := m.cronScheduler.Stop()
 		<-ctx.Done()
 		m.logger.Info("Scheduled task manager stopped")
 	}
+}
 
-	if m.cancel != nil {
-		m.cancel()
+// syncLoop 定期与数据库同步调度配置
+// 多副本部署时其他副本上的增删改只写入数据库，由 Leader 同步到调度器
+func (m *ScheduledTaskManager) syncLoop(ctx context.Context) {
+	ticker := time.NewTicker(m.syncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.syncSchedules(ctx); err != nil {
+				m.logger.WithField("error", err).Error("Failed to sync scheduled tasks")
+			}
+		case <-ctx.Done():
+			return
+		}
 	}
 }
 
+// syncSchedules 按数据库中启用的定时任务增删调度条目
+func (m *ScheduledTaskManager) syncSchedules(ctx context.Context) error {
+	tasks, err := m.scheduledTaskRepo.ListEnabledScheduledTasks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load scheduled tasks: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	enabled := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		enabled[task.ID] = true
+
+		if spec, exists := m.cronSpecs[task.ID]; exists {
+			if spec == task.CronExpr {
+				continue
+			}
+			// Cron 表达式已在其他副本上修改
+			m.cronScheduler.Remove(m.cronEntries[task.ID])
+			delete(m.cronEntries, task.ID)
+			delete(m.cronSpecs, task.ID)
+		}
+
+		if err := m.addTaskToScheduler(task); err != nil {
+			m.logger.WithFields(logrus.Fields{
+				"taskId":   task.ID,
+				"cronExpr": task.CronExpr,
+				"error":    err,
+			}).Error("Failed to add scheduled task to scheduler")
+		}
+	}
+
+	for taskID, entryID := range m.cronEntries {
+		if enabled[taskID] {
+			continue
+		}
+		m.cronScheduler.Remove(entryID)
+		delete(m.cronEntries, taskID)
+		delete(m.cronSpecs, taskID)
+		m.logger.WithField("taskId", taskID).Info("Scheduled task removed from scheduler")
+	}
+
+	metrics.ActiveScheduledTasks.Set(float64(len(tasks)))
+	return nil
+}
+
 func (m *ScheduledTaskManager) AddTask(task *models.ScheduledTask) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -119,6 +200,7 @@ func (m *ScheduledTaskManager) addTaskToScheduler(task *models.ScheduledTask) er
 	}
 
 	m.cronEntries[task.ID] = entryID
+	m.cronSpecs[task.ID] = task.CronExpr
 
 	nextRun := m.cronScheduler.Entry(entryID).Next
 	task.NextExecutionAt = &nextRun
@@ -151,6 +233,7 @@ func (m *ScheduledTaskManager) RemoveTask(taskID string) error {
 
 	m.cronScheduler.Remove(entryID)
 	delete(m.cronEntries, taskID)
+	delete(m.cronSpecs, taskID)
 
 	m.logger.WithField("taskId", taskID).Info("Scheduled task removed from scheduler")
 	return nil
@@ -219,6 +302,14 @@ func (m *ScheduledTaskManager) DeleteScheduledTask(ctx context.Context, taskID s
 }
 
 func (m *ScheduledTaskManager) isPreviousTaskRunning(scheduledTaskID string) bool {
+	// 先检查内存中的执行标记（执行记录可能尚未落库）
+	m.mu.RLock()
+	executing := m.executingTasks[scheduledTaskID]
+	m.mu.RUnlock()
+	if executing {
+		return true
+	}
+
 	executions, err := m.executionRepo.ListRunningExecutions(context.Background(), scheduledTaskID)
 	if err != nil {
 		m.logger.WithFields(logrus.Fields{
@@ -275,9 +366,6 @@ func (m *ScheduledTaskManager) executeTask(scheduledTaskID string) (string, erro
 			m.logger.WithField("scheduledTaskId", scheduledTaskID).Info("Scheduled task execution skipped (previous task still running)")
 			metrics.ScheduledTaskExecutionsTotal.WithLabelValues("skipped").Inc()
 
-			m.mu.Lock()
-			delete(m.executingTasks, scheduledTaskID)
-			m.mu.Unlock()
 			return "", fmt.Errorf("task execution skipped: previous task still running")
 		}
 	}
@@ -303,9 +391,6 @@ func (m *ScheduledTaskManager) executeTask(scheduledTaskID string) (string, erro
 			m.logger.WithField("scheduledTaskId", scheduledTaskID).Info("Scheduled task execution queued (previous task still running)")
 			metrics.ScheduledTaskExecutionsTotal.WithLabelValues("queued").Inc()
 
-			m.mu.Lock()
-			delete(m.executingTasks, scheduledTaskID)
-			m.mu.Unlock()
 			return "", fmt.Errorf("task execution queued: previous task still running")
 		}
 	}
@@ -349,18 +434,10 @@ func (m *ScheduledTaskManager) executeTask(scheduledTaskID string) (string, erro
 		defer cancel()
 	}
 
-	createReq := &models.CreateTaskRequest{
-		ID:            taskID, // 使用预生成的 sched- 前缀 ID
-		Images:        task.TaskConfig.Images,
-		BatchSize:     task.TaskConfig.BatchSize,
-		Priority:      task.TaskConfig.Priority,
-		NodeSelector:  task.TaskConfig.NodeSelector,
-		MaxRetries:    task.TaskConfig.MaxRetries,
-		RetryStrategy: task.TaskConfig.RetryStrategy,
-		RetryDelay:    task.TaskConfig.RetryDelay,
-		WebhookURL:    task.TaskConfig.WebhookURL,
-		SecretID:      task.TaskConfig.SecretID,
-	}
+	createReq := task.TaskConfig.CreateTaskRequest()
+	createReq.ID = taskID // 使用预生成的 sched- 前缀 ID
+	createReq.TimeoutSeconds = task.TimeoutSeconds
+	createReq.CreatedBy = task.CreatedBy
 
 	actualTask, err := m.taskManager.CreateTask(ctx, createReq)
 	if err != nil {
@@ -409,6 +486,88 @@ func (m *ScheduledTaskManager) executeTask(scheduledTaskID string) (string, erro
 	return actualTask.ID, nil
 }
 
+// recoverRunningExecutions 对账数据库中处于 running 的执行记录
+// 执行记录只由进程内的监控协程结束，重启或切主后按关联任务的实际状态结束记录，仍在执行的任务重新启动监控
+// 调用方需持有 m.mu
+func (m *ScheduledTaskManager) recoverRunningExecutions(ctx context.Context) {
+	executions, err := m.executionRepo.ListRunningExecutions(ctx, "")
+	if err != nil {
+		m.logger.WithField("error", err).Error("Failed to list running executions for recovery")
+		return
+	}
+
+	for _, execution := range executions {
+		// 本进程的监控协程仍在运行（切主后再次当选）
+		if m.executingTasks[execution.ScheduledTaskID] {
+			continue
+		}
+
+		task, err := m.taskManager.GetTask(ctx, execution.TaskID)
+		if errors.Is(err, repository.ErrTaskNotFound) {
+			m.finishRecoveredExecution(ctx, execution, models.ScheduledExecutionFailed, "Underlying task not found")
+			continue
+		}
+		if err != nil {
+			m.logger.WithFields(logrus.Fields{
+				"scheduledTaskId": execution.ScheduledTaskID,
+				"taskId":          execution.TaskID,
+				"error":           err,
+			}).Error("Failed to get task status during execution recovery")
+			continue
+		}
+
+		switch task.Status {
+		case models.TaskCompleted:
+			m.finishRecoveredExecution(ctx, execution, models.ScheduledExecutionSuccess, "")
+		case models.TaskFailed:
+			m.finishRecoveredExecution(ctx, execution, models.ScheduledExecutionFailed, "Underlying task failed")
+		case models.TaskCancelled:
+			m.finishRecoveredExecution(ctx, execution, models.ScheduledExecutionFailed, "Underlying task was cancelled")
+		default:
+			// 超时从执行开始时计算，剩余时间耗尽的执行在监控协程中立即按超时处理
+			timeoutSeconds := 0
+			if scheduledTask, err := m.scheduledTaskRepo.GetScheduledTask(ctx, execution.ScheduledTaskID); err == nil && scheduledTask.TimeoutSeconds > 0 {
+				remaining := time.Duration(scheduledTask.TimeoutSeconds)*time.Second - time.Since(execution.StartedAt)
+				timeoutSeconds = max(int(remaining.Seconds()), 1)
+			}
+
+			m.executingTasks[execution.ScheduledTaskID] = true
+			go m.monitorExecution(execution.ScheduledTaskID, execution.TaskID, execution, timeoutSeconds)
+
+			m.logger.WithFields(logrus.Fields{
+				"scheduledTaskId": execution.ScheduledTaskID,
+				"taskId":          execution.TaskID,
+				"taskStatus":      task.Status,
+			}).Info("Resumed monitoring of scheduled task execution")
+		}
+	}
+}
+
+// finishRecoveredExecution 结束恢复时关联任务已结束或不存在的执行记录
+func (m *ScheduledTaskManager) finishRecoveredExecution(ctx context.Context, execution *models.ScheduledExecution, status models.ScheduledTaskExecutionStatus, message string) {
+	execution.Status = status
+	execution.ErrorMessage = message
+	finishedAt := time.Now()
+	execution.FinishedAt = &finishedAt
+	execution.DurationSeconds = finishedAt.Sub(execution.StartedAt).Seconds()
+
+	if err := m.executionRepo.UpdateExecution(ctx, execution); err != nil {
+		m.logger.WithFields(logrus.Fields{
+			"scheduledTaskId": execution.ScheduledTaskID,
+			"taskId":          execution.TaskID,
+			"error":           err,
+		}).Error("Failed to update recovered execution record")
+		return
+	}
+	metrics.ScheduledTaskExecutionsTotal.WithLabelValues(string(status)).Inc()
+
+	m.logger.WithFields(logrus.Fields{
+		"scheduledTaskId": execution.ScheduledTaskID,
+		"taskId":          execution.TaskID,
+		"status":          status,
+	}).Info("Closed scheduled task execution left running by a previous instance")
+}
+
 func (m *ScheduledTaskManager) monitorExecution(scheduledTaskID, taskID string, execution *models.ScheduledExecution, timeoutSeconds int) {
 	var monitorCtx context.Context
 	var cancel context.CancelFunc
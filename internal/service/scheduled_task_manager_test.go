@@ -21,6 +21,8 @@ func setupScheduledTaskManager(t *testing.T) (*ScheduledTaskManager, *repository
 	logger.SetLevel(logrus.ErrorLevel)
 
 	taskManager := NewTaskManager(
+		repo,
+		repo,
 		repo,
 		repo,
 		nil,
@@ -394,6 +396,35 @@ func TestScheduledTaskManager_OverlapPolicySkip(t *testing.T) {
 	assert.Contains(t, err.Error(), "skipped")
 }
 
+func TestScheduledTaskManager_OverlapKeepsRunningMark(t *testing.T) {
+	manager, repo := setupScheduledTaskManager(t)
+
+	for _, policy := range []models.OverlapPolicy{models.OverlapPolicySkip, models.OverlapPolicyQueue} {
+		task := &models.ScheduledTask{
+			ID:       "overlap-" + string(policy),
+			Name:     "Overlap " + string(policy),
+			CronExpr: "* * * * *",
+			Enabled:  true,
+			TaskConfig: models.TaskConfig{
+				Images: []string{"nginx:latest"},
+			},
+			OverlapPolicy: policy,
+			CreatedBy:     "test-user",
+		}
+		require.NoError(t, repo.CreateScheduledTask(context.Background(), task))
+
+		// 上一次执行仍在进行（执行记录尚未落库）
+		manager.executingTasks[task.ID] = true
+
+		// 跳过或排队的触发不清除上一次执行的标记，否则下一次触发会与其重叠
+		for i := 0; i < 2; i++ {
+			_, err := manager.executeTask(task.ID)
+			assert.Error(t, err)
+		}
+		assert.True(t, manager.executingTasks[task.ID])
+	}
+}
+
 func TestScheduledTaskManager_Start(t *testing.T) {
 	manager, repo := setupScheduledTaskManager(t)
 
@@ -423,6 +454,68 @@ func TestScheduledTaskManager_Start(t *testing.T) {
 	manager.Stop()
 }
 
+func TestScheduledTaskManager_Start_RecoversRunningExecutions(t *testing.T) {
+	manager, repo := setupScheduledTaskManager(t)
+	ctx := context.Background()
+
+	startedAt := time.Now().Add(-time.Minute)
+	executions := make(map[models.TaskStatus]*models.ScheduledExecution)
+	for _, status := range []models.TaskStatus{models.TaskCompleted, models.TaskFailed, models.TaskRunning} {
+		taskID := "sched-recover-" + string(status)
+		require.NoError(t, repo.CreateTask(ctx, &models.Task{
+			ID:        taskID,
+			Status:    status,
+			Images:    []string{"nginx:latest"},
+			CreatedAt: startedAt,
+		}))
+
+		execution := &models.ScheduledExecution{
+			ScheduledTaskID: "recover-" + string(status),
+			TaskID:          taskID,
+			Status:          models.ScheduledExecutionRunning,
+			TriggeredAt:     startedAt,
+			StartedAt:       startedAt,
+		}
+		require.NoError(t, repo.CreateExecution(ctx, execution))
+		executions[status] = execution
+	}
+
+	// 关联任务已被删除
+	missing := &models.ScheduledExecution{
+		ScheduledTaskID: "recover-missing",
+		TaskID:          "sched-missing",
+		Status:          models.ScheduledExecutionRunning,
+		TriggeredAt:     startedAt,
+		StartedAt:       startedAt,
+	}
+	require.NoError(t, repo.CreateExecution(ctx, missing))
+
+	require.NoError(t, manager.Start())
+	defer manager.Stop()
+
+	// 已结束的任务按实际状态结束执行记录
+	completed, err := repo.GetExecution(ctx, executions[models.TaskCompleted].ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ScheduledExecutionSuccess, completed.Status)
+	assert.NotNil(t, completed.FinishedAt)
+
+	failed, err := repo.GetExecution(ctx, executions[models.TaskFailed].ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ScheduledExecutionFailed, failed.Status)
+
+	gone, err := repo.GetExecution(ctx, missing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ScheduledExecutionFailed, gone.Status)
+
+	// 仍在执行的任务保持 running，重新启动监控并阻止重叠触发
+	running, err := repo.GetExecution(ctx, executions[models.TaskRunning].ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ScheduledExecutionRunning, running.Status)
+	manager.mu.RLock()
+	assert.True(t, manager.executingTasks["recover-running"])
+	manager.mu.RUnlock()
+}
+
 func TestScheduledTaskManager_CleanupOldExecutions(t *testing.T) {
 	manager, repo := setupScheduledTaskManager(t)
 
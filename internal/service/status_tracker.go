@@ -3,7 +3,10 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/kitsnail/ips/internal/k8s"
@@ -12,40 +15,58 @@ import (
 	"github.com/kitsnail/ips/pkg/models"
 	"github.com/sirupsen/logrus"
 	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/watch"
 )
 
 // StatusTracker 状态跟踪器
 type StatusTracker struct {
-	repo       repository.TaskRepository
-	jobCreator *k8s.JobCreator
-	logger     *logrus.Logger
+	repo                repository.TaskRepository
+	jobCreator          *k8s.JobCreator
+	logger              *logrus.Logger
+	stuckPodGracePeriod time.Duration // Pod 卡住多久后将节点标记为失败
+	informer            *k8s.PrewarmInformer
+	events              *EventBroker // 由 TaskManager 设置
 }
 
 // NewStatusTracker 创建状态跟踪器
 func NewStatusTracker(repo repository.TaskRepository, jobCreator *k8s.JobCreator, logger *logrus.Logger) *StatusTracker {
 	return &StatusTracker{
-		repo:       repo,
-		jobCreator: jobCreator,
-		logger:     logger,
+		repo:                repo,
+		jobCreator:          jobCreator,
+		logger:              logger,
+		stuckPodGracePeriod: defaultStuckPodGracePeriod,
 	}
 }
 
+// SetInformer 设置共享 Informer，设置后所有任务从其缓存中读取 Job 与 Pod 状态
+func (t *StatusTracker) SetInformer(informer *k8s.PrewarmInformer) {
+	t.informer = informer
+}
+
 // TrackTask 跟踪任务状态
-// 优先使用Watch机制，失败时降级到轮询
+// 设置了共享 Informer 时订阅其事件，否则优先使用Watch机制，失败时降级到轮询
 func (t *StatusTracker) TrackTask(ctx context.Context, taskID string) error {
 	t.logger.WithField("taskId", taskID).Info("Starting task tracking")
 
-	// 确保 NodeStatuses 已初始化
+	// 确保 NodeResults 已初始化
 	task, err := t.repo.GetTask(ctx, taskID)
-	if err == nil && task.NodeStatuses == nil {
-		task.NodeStatuses = make(map[string]map[string]int)
+	if err == nil && task.NodeResults == nil {
+		task.NodeResults = make(map[string]*models.NodeResult)
 		t.repo.UpdateTask(ctx, task)
 	}
 
+	if t.informer != nil {
+		return t.trackTaskWithInformer(ctx, taskID)
+	}
+
 	// 尝试使用Watch机制
 	err = t.trackTaskWithWatch(ctx, taskID)
+	if errors.Is(err, ErrTaskPaused) {
+		return err
+	}
 	if err != nil {
 		t.logger.WithFields(logrus.Fields{
 			"taskId": taskID,
@@ -58,6 +79,56 @@ func (t *StatusTracker) TrackTask(ctx context.Context, taskID string) error {
 	return nil
 }
 
+// trackTaskWithInformer 订阅共享 Informer 的事件跟踪任务
+func (t *StatusTracker) trackTaskWithInformer(ctx context.Context, taskID string) error {
+	events, unsubscribe := t.informer.Subscribe(taskID)
+	defer unsubscribe()
+
+	t.logger.WithField("taskId", taskID).Info("Using shared informer for task tracking")
+
+	// 定期更新任务状态（每30秒或收到事件时），用于重试延迟、超时及卡住 Pod 的判定
+	updateTicker := time.NewTicker(30 * time.Second)
+	defer updateTicker.Stop()
+
+	// 订阅前缓存中已有的变化通过首次更新处理
+	for {
+		task, err := t.repo.GetTask(ctx, taskID)
+		if err != nil {
+			t.logger.WithFields(logrus.Fields{
+				"taskId": taskID,
+				"error":  err,
+			}).Error("Failed to get task")
+		} else if t.isTaskPaused(task) {
+			return ErrTaskPaused
+		} else {
+			if !t.isTaskFinished(task) {
+				if err := t.updateTaskStatus(ctx, task); err != nil {
+					t.logger.WithFields(logrus.Fields{
+						"taskId": taskID,
+						"error":  err,
+					}).Error("Failed to update task status")
+				}
+			}
+
+			if t.isTaskFinished(task) {
+				t.logger.WithFields(logrus.Fields{
+					"taskId": taskID,
+					"status": task.Status,
+				}).Info("Task tracking completed via informer")
+				return nil
+			}
+		}
+
+		select {
+		case <-events:
+		case <-updateTicker.C:
+		case <-ctx.Done():
+			t.logger.WithField("taskId", taskID).Warn("Task tracking cancelled")
+			return ctx.Err()
+		}
+	}
+}
+
 // trackTaskWithWatch 使用Watch机制跟踪任务
 func (t *StatusTracker) trackTaskWithWatch(ctx context.Context, taskID string) error {
 	// 创建Watch
@@ -112,6 +183,9 @@ func (t *StatusTracker) trackTaskWithWatch(ctx context.Context, taskID string) e
 					}).Info("Task tracking completed via Watch")
 					return nil
 				}
+				if t.isTaskPaused(task) {
+					return ErrTaskPaused
+				}
 
 				// 更新任务状态
 				if err := t.updateTaskStatus(ctx, task); err != nil {
@@ -151,6 +225,9 @@ func (t *StatusTracker) trackTaskWithWatch(ctx context.Context, taskID string) e
 				}).Info("Task tracking completed")
 				return nil
 			}
+			if t.isTaskPaused(task) {
+				return ErrTaskPaused
+			}
 
 			// 更新任务状态
 			if err := t.updateTaskStatus(ctx, task); err != nil {
@@ -195,6 +272,9 @@ func (t *StatusTracker) trackTaskWithPolling(ctx context.Context, taskID string)
 				}).Info("Task tracking completed via polling")
 				return nil
 			}
+			if t.isTaskPaused(task) {
+				return ErrTaskPaused
+			}
 
 			// 更新任务状态
 			err = t.updateTaskStatus(ctx, task)
@@ -219,57 +299,129 @@ func (t *StatusTracker) isTaskFinished(task *models.Task) bool {
 		task.Status == models.TaskCancelled
 }
 
+// isTaskPaused 任务暂停后停止跟踪（不再重试失败节点），恢复执行时重新挂载
+func (t *StatusTracker) isTaskPaused(task *models.Task) bool {
+	if task.Status != models.TaskPaused {
+		return false
+	}
+	t.logger.WithField("taskId", task.ID).Info("Task paused, tracking stopped")
+	return true
+}
+
 // updateTaskStatus 更新任务状态
 func (t *StatusTracker) updateTaskStatus(ctx context.Context, task *models.Task) error {
 	// 获取任务相关的所有Job
-	jobs, err := t.jobCreator.ListJobsByTaskID(ctx, task.ID)
+	jobs, err := t.listJobs(ctx, task.ID)
 	if err != nil {
 		return fmt.Errorf("failed to list jobs: %w", err)
 	}
 
-	if len(jobs) == 0 {
+	// 超过任务截止时间后，未结束及未提交 Job 的节点均记为超时
+	timedOut := task.DeadlineExceeded(time.Now())
+
+	if len(jobs) == 0 && !timedOut {
 		return nil
 	}
 
 	// 初始化
-	if task.NodeStatuses == nil {
-		task.NodeStatuses = make(map[string]map[string]int)
+	if task.NodeResults == nil {
+		task.NodeResults = make(map[string]*models.NodeResult)
 	}
 	if task.Progress == nil {
 		task.Progress = &models.Progress{}
 	}
 
+	if task.NodeAttempts == nil {
+		task.NodeAttempts = make(map[string][]models.NodeAttempt)
+	}
+
 	var completed, failed, running int
 	var failedNodes []models.FailedNode
+	// 成功的节点，已存在全部镜像而跳过的节点也视为成功
+	succeeded := make(map[string]bool)
+	for nodeName, result := range task.NodeResults {
+		if result.Status == models.NodeResultSkipped {
+			succeeded[nodeName] = true
+		}
+	}
 
-	for _, job := range jobs {
-		nodeName := job.Labels["node"]
+	// 重试会为同一节点创建多个 Job，只根据最近一次尝试判断节点状态
+	// Job 已被删除（卡住后被清理或 TTL 到期）的节点以尝试记录为准
+	latest := latestJobsByNode(jobs)
+	nodeSet := make(map[string]bool, len(latest))
+	for nodeName := range latest {
+		nodeSet[nodeName] = true
+	}
+	for nodeName, attempts := range task.NodeAttempts {
+		if len(attempts) > 0 {
+			nodeSet[nodeName] = true
+		}
+	}
+	nodeNames := make([]string, 0, len(nodeSet))
+	for nodeName := range nodeSet {
+		nodeNames = append(nodeNames, nodeName)
+	}
+	sort.Strings(nodeNames)
+
+	for _, nodeName := range nodeNames {
+		job := latest[nodeName]
+		attempts := task.NodeAttempts[nodeName]
+		if job != nil && len(attempts) > 0 && attempts[len(attempts)-1].Attempt > k8s.JobAttempt(job) {
+			// 重试 Job 已创建但尚未出现在 Informer 缓存中
+			job = nil
+		}
+		var attempt *models.NodeAttempt
+		if job != nil {
+			attempt = recordAttempt(task, nodeName, job)
+		} else {
+			attempt = &attempts[len(attempts)-1]
+		}
 
-		// 检查 Job 状态
-		isSucceeded := job.Status.Succeeded > 0
-		isFailed := job.Status.Failed > 0
+		// 尝试结束时只处理一次结果
+		if attempt.FinishedAt == nil {
+			t.observeAttempt(ctx, task, nodeName, attempt, job)
+			if attempt.FinishedAt != nil {
+				t.publishNodeResult(task, nodeName, attempt)
+			}
+		}
 
-		if isSucceeded {
+		switch attempt.Status {
+		case models.NodeResultSucceeded:
 			completed++
-			// 解析详细结果 (如果尚未解析)
-			if _, processed := task.NodeStatuses[nodeName]; !processed {
-				t.handlePodDetailedResults(ctx, nodeName, job.Name, task)
+			succeeded[nodeName] = true
+		case models.NodeResultFailed:
+			// 未超过节点重试次数时为该节点创建新的 Job
+			if !timedOut && t.retryNode(ctx, task, nodeName, attempt) {
+				running++
+				continue
 			}
-		} else if isFailed {
 			failed++
-			if _, processed := task.NodeStatuses[nodeName]; !processed {
-				task.NodeStatuses[nodeName] = make(map[string]int) // 标记为已处理但失败
-				metrics.NodesProcessed.WithLabelValues("failed").Inc()
-			}
 			failedNodes = append(failedNodes, models.FailedNode{
 				NodeName:  nodeName,
-				Reason:    "JobFailed",
-				Message:   getJobFailureMessage(&job),
+				Reason:    attempt.Reason,
+				Message:   attempt.Message,
+				Attempts:  attempt.Attempt,
 				Timestamp: time.Now(),
 			})
-		} else {
-			running++
+		default:
+			if timedOut {
+				failed++
+				failedNodes = append(failedNodes, t.markNodeTimedOut(task, nodeName))
+			} else {
+				running++
+			}
+		}
+	}
+
+	if timedOut {
+		// 截止时间前尚未提交 Job 的节点
+		for _, nodeName := range task.TargetNodes {
+			if !nodeSet[nodeName] {
+				failed++
+				failedNodes = append(failedNodes, t.markNodeTimedOut(task, nodeName))
+			}
 		}
+		task.ErrorMessage = fmt.Sprintf("task timed out after %ds", task.TimeoutSeconds)
 	}
 
 	// 更新进度
@@ -279,14 +431,17 @@ func (t *StatusTracker) updateTaskStatus(ctx context.Context, task *models.Task)
 	task.CalculateProgress()
 
 	// 判断是否结束
-	if (completed+failed) >= task.Progress.TotalNodes && task.Progress.TotalNodes > 0 {
+	previousStatus := task.Status
+	if ((completed+failed) >= task.Progress.TotalNodes && task.Progress.TotalNodes > 0) || timedOut {
 		now := time.Now()
 		task.FinishedAt = &now
-		successRate := float64(completed) / float64(task.Progress.TotalNodes)
-		if successRate >= 0.9 {
+		if reason := evaluateSuccess(task, succeeded, completed); reason == "" {
 			task.Status = models.TaskCompleted
 		} else {
 			task.Status = models.TaskFailed
+			if task.ErrorMessage == "" {
+				task.ErrorMessage = reason
+			}
 		}
 
 		// 上报总指标
@@ -306,37 +461,48 @@ func (t *StatusTracker) updateTaskStatus(ctx context.Context, task *models.Task)
 		task.StartedAt = &now
 	}
 
-	return t.repo.UpdateTask(ctx, task)
+	if err := t.repo.UpdateTask(ctx, task); err != nil {
+		return err
+	}
+	if task.Status != previousStatus {
+		t.events.publishStatus(task, task.ErrorMessage)
+	}
+	return nil
 }
 
 // handlePodDetailedResults 解析 Pod 的终止消息并上报指标
 func (t *StatusTracker) handlePodDetailedResults(ctx context.Context, nodeName, jobName string, task *models.Task) {
-	podList, err := t.jobCreator.GetK8sClient().Clientset.CoreV1().Pods(t.jobCreator.GetK8sClient().Namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: fmt.Sprintf("job-name=%s", jobName),
-	})
-	if err != nil || len(podList.Items) == 0 {
+	pods, err := t.listPods(ctx, jobName)
+	if err != nil || len(pods) == 0 {
 		return
 	}
 
-	pod := podList.Items[0]
+	pod := pods[0]
 	for _, cs := range pod.Status.ContainerStatuses {
 		if cs.Name == "puller" && cs.State.Terminated != nil && cs.State.Terminated.Message != "" {
-			var results map[string]int
-			if err := json.Unmarshal([]byte(cs.State.Terminated.Message), &results); err == nil {
-				task.NodeStatuses[nodeName] = results
-				// 标记节点成功指标
-				metrics.NodesProcessed.WithLabelValues("success").Inc()
-				// 标记详细镜像指标
-				for img, status := range results {
-					if status == 1 {
-						// 成功：success=1, failed=0
-						metrics.ImagePrewarmStatus.WithLabelValues(nodeName, img, "success").Set(1.0)
-						metrics.ImagePrewarmStatus.WithLabelValues(nodeName, img, "failed").Set(0.0)
-					} else {
-						// 失败：success=0, failed=1
-						metrics.ImagePrewarmStatus.WithLabelValues(nodeName, img, "success").Set(0.0)
-						metrics.ImagePrewarmStatus.WithLabelValues(nodeName, img, "failed").Set(1.0)
-					}
+			results, err := parsePullResults(cs.State.Terminated.Message)
+			if err != nil {
+				t.logger.WithFields(logrus.Fields{
+					"taskId":   task.ID,
+					"nodeName": nodeName,
+					"error":    err,
+				}).Warn("Failed to parse puller results")
+				return
+			}
+
+			task.NodeResults[nodeName] = models.NewNodeResult(nodeName, results)
+			// 标记节点成功指标
+			metrics.NodesProcessed.WithLabelValues("success").Inc()
+			// 标记详细镜像指标
+			for _, result := range results {
+				if result.Succeeded() {
+					// 成功：success=1, failed=0
+					metrics.ImagePrewarmStatus.WithLabelValues(nodeName, result.Image, "success").Set(1.0)
+					metrics.ImagePrewarmStatus.WithLabelValues(nodeName, result.Image, "failed").Set(0.0)
+				} else {
+					// 失败：success=0, failed=1
+					metrics.ImagePrewarmStatus.WithLabelValues(nodeName, result.Image, "success").Set(0.0)
+					metrics.ImagePrewarmStatus.WithLabelValues(nodeName, result.Image, "failed").Set(1.0)
 				}
 			}
 			return
@@ -344,6 +510,275 @@ func (t *StatusTracker) handlePodDetailedResults(ctx context.Context, nodeName,
 	}
 }
 
+// parsePullResults 解析 puller 写入的终止消息
+// 兼容旧版本 puller 输出的 imageName -> 1/0 格式
+func parsePullResults(message string) ([]models.ImagePullResult, error) {
+	var results []models.ImagePullResult
+	if err := json.Unmarshal([]byte(message), &results); err == nil {
+		return results, nil
+	}
+
+	var legacy map[string]int
+	if err := json.Unmarshal([]byte(message), &legacy); err != nil {
+		return nil, fmt.Errorf("invalid termination message: %w", err)
+	}
+	for image, status := range legacy {
+		result := models.ImagePullResult{Image: image, Status: models.ImagePullSucceeded}
+		if status != 1 {
+			result.Status = models.ImagePullFailed
+		}
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Image < results[j].Image
+	})
+	return results, nil
+}
+
+// observeAttempt 根据 Job 及其 Pod 的状态更新尚未结束的尝试
+func (t *StatusTracker) observeAttempt(ctx context.Context, task *models.Task, nodeName string, attempt *models.NodeAttempt, job *batchv1.Job) {
+	switch {
+	case job == nil:
+		// 刚创建的 Job 可能尚未同步到 Informer 缓存
+		if time.Since(attempt.StartedAt) < jobNotFoundGracePeriod {
+			return
+		}
+		t.failAttempt(task, nodeName, attempt, FailureReasonJobNotFound, "job was deleted before it finished")
+
+	case job.Status.Succeeded > 0:
+		// 解析详细结果，覆盖之前失败尝试的结果
+		delete(task.NodeResults, nodeName)
+		t.handlePodDetailedResults(ctx, nodeName, job.Name, task)
+
+		// 成功条件要求镜像全部就绪时，Job 正常退出但有镜像拉取失败的节点按失败处理（可被重试）
+		if task.SuccessPolicy.FailsOnImageFailure() {
+			if images := failedImages(task.NodeResults[nodeName]); len(images) > 0 {
+				message := fmt.Sprintf("failed to pull images: %s", strings.Join(images, ", "))
+				finishAttempt(attempt, models.NodeResultFailed, FailureReasonImagePullFailed, message)
+				task.NodeResults[nodeName].Message = message
+				return
+			}
+		}
+		finishAttempt(attempt, models.NodeResultSucceeded, "", "")
+
+	case job.Status.Failed > 0:
+		reason, message := t.jobFailureReason(ctx, job)
+		t.failAttempt(task, nodeName, attempt, reason, message)
+		t.captureNodeLogs(ctx, task, nodeName, attempt, job)
+
+	default:
+		// Pod 无法调度或拉取 puller 镜像失败时 Job 不会自行结束
+		reason, message, stuck := t.detectStuckJob(ctx, job)
+		if !stuck {
+			return
+		}
+		t.logger.WithFields(logrus.Fields{
+			"taskId":   task.ID,
+			"nodeName": nodeName,
+			"jobName":  job.Name,
+			"reason":   reason,
+		}).Warn("Puller pod is stuck, marking node as failed")
+		t.failAttempt(task, nodeName, attempt, reason, message)
+		// 删除 Job 前采集日志（容器未启动时没有日志）
+		t.captureNodeLogs(ctx, task, nodeName, attempt, job)
+
+		if err := t.jobCreator.DeleteJob(ctx, job.Name); err != nil {
+			t.logger.WithFields(logrus.Fields{
+				"taskId":  task.ID,
+				"jobName": job.Name,
+				"error":   err,
+			}).Warn("Failed to delete stuck job")
+		}
+	}
+}
+
+// failAttempt 记录尝试失败及节点失败结果
+func (t *StatusTracker) failAttempt(task *models.Task, nodeName string, attempt *models.NodeAttempt, reason, message string) {
+	finishAttempt(attempt, models.NodeResultFailed, reason, message)
+	task.NodeResults[nodeName] = &models.NodeResult{
+		NodeName:  nodeName,
+		Status:    models.NodeResultFailed,
+		Message:   message,
+		UpdatedAt: time.Now(),
+	}
+	metrics.NodesProcessed.WithLabelValues("failed").Inc()
+}
+
+// listJobs 列出任务的所有 Job，设置了共享 Informer 时从缓存读取
+func (t *StatusTracker) listJobs(ctx context.Context, taskID string) ([]batchv1.Job, error) {
+	if t.informer != nil {
+		return t.informer.ListJobsByTaskID(taskID)
+	}
+	return t.jobCreator.ListJobsByTaskID(ctx, taskID)
+}
+
+// listPods 列出 Job 创建的 Pod，设置了共享 Informer 时从缓存读取
+func (t *StatusTracker) listPods(ctx context.Context, jobName string) ([]corev1.Pod, error) {
+	if t.informer != nil {
+		return t.informer.ListPodsByJobName(jobName)
+	}
+	client := t.jobCreator.GetK8sClient()
+	podList, err := client.Clientset.CoreV1().Pods(client.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("job-name=%s", jobName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods for job %s: %w", jobName, err)
+	}
+	return podList.Items, nil
+}
+
+// publishNodeResult 发布节点一次尝试结束的事件
+func (t *StatusTracker) publishNodeResult(task *models.Task, nodeName string, attempt *models.NodeAttempt) {
+	t.events.Publish(models.TaskEvent{
+		TaskID:   task.ID,
+		Type:     models.TaskEventNodeResult,
+		NodeName: nodeName,
+		Result:   attempt.Status,
+		Attempt:  attempt.Attempt,
+		Reason:   attempt.Reason,
+		Message:  attempt.Message,
+	})
+}
+
+// latestJobsByNode 返回每个节点最近一次尝试的 Job
+func latestJobsByNode(jobs []batchv1.Job) map[string]*batchv1.Job {
+	latest := make(map[string]*batchv1.Job, len(jobs))
+	for i := range jobs {
+		nodeName := jobs[i].Labels["node"]
+		if current, ok := latest[nodeName]; !ok || k8s.JobAttempt(&jobs[i]) > k8s.JobAttempt(current) {
+			latest[nodeName] = &jobs[i]
+		}
+	}
+	return latest
+}
+
+// recordAttempt 返回 Job 对应的尝试记录，不存在时（如重启前创建的 Job）补充记录
+func recordAttempt(task *models.Task, nodeName string, job *batchv1.Job) *models.NodeAttempt {
+	number := k8s.JobAttempt(job)
+	if attempt := task.FindNodeAttempt(nodeName, number); attempt != nil {
+		return attempt
+	}
+
+	task.NodeAttempts[nodeName] = append(task.NodeAttempts[nodeName], models.NodeAttempt{
+		Attempt:   number,
+		JobName:   job.Name,
+		Status:    models.NodeResultPending,
+		StartedAt: job.CreationTimestamp.Time,
+	})
+	attempts := task.NodeAttempts[nodeName]
+	sort.Slice(attempts, func(i, j int) bool { return attempts[i].Attempt < attempts[j].Attempt })
+	return task.FindNodeAttempt(nodeName, number)
+}
+
+// finishAttempt 记录尝试结束
+func finishAttempt(attempt *models.NodeAttempt, status models.NodeResultStatus, reason, message string) {
+	now := time.Now()
+	attempt.Status = status
+	attempt.Reason = reason
+	attempt.Message = message
+	attempt.FinishedAt = &now
+}
+
+// retryNode 节点失败且未超过重试次数时，按重试策略延迟后为其创建下一次尝试的 Job
+// 返回 true 表示节点仍在重试中（等待重试延迟或新 Job 已创建）
+func (t *StatusTracker) retryNode(ctx context.Context, task *models.Task, nodeName string, last *models.NodeAttempt) bool {
+	if last.Attempt > task.MaxRetries {
+		return false
+	}
+
+	delay := GetRetryStrategy(task.RetryStrategy, t.logger).CalculateDelay(last.Attempt, task.RetryDelay)
+	if time.Since(*last.FinishedAt) < delay {
+		return true
+	}
+
+	opts := jobOptions(task)
+	opts.SecretName = credsSecretName(task)
+	opts.Attempt = last.Attempt + 1
+	if err := t.jobCreator.CreateJob(ctx, task.ID, nodeName, task.Images, opts); err != nil && !apierrors.IsAlreadyExists(err) {
+		t.logger.WithFields(logrus.Fields{
+			"taskId":   task.ID,
+			"nodeName": nodeName,
+			"attempt":  opts.Attempt,
+			"error":    err,
+		}).Error("Failed to create retry job")
+		metrics.JobCreationTotal.WithLabelValues("failed").Inc()
+		return false
+	}
+	metrics.JobCreationTotal.WithLabelValues("success").Inc()
+
+	task.NodeAttempts[nodeName] = append(task.NodeAttempts[nodeName], models.NodeAttempt{
+		Attempt:   opts.Attempt,
+		JobName:   k8s.JobName(task.ID, nodeName, opts.Attempt),
+		Status:    models.NodeResultPending,
+		StartedAt: time.Now(),
+	})
+
+	t.logger.WithFields(logrus.Fields{
+		"taskId":     task.ID,
+		"nodeName":   nodeName,
+		"attempt":    opts.Attempt,
+		"maxRetries": task.MaxRetries,
+	}).Info("Retrying failed node")
+	t.events.Publish(models.TaskEvent{
+		TaskID:   task.ID,
+		Type:     models.TaskEventNodeRetry,
+		NodeName: nodeName,
+		Attempt:  opts.Attempt,
+		Message:  fmt.Sprintf("previous attempt failed: %s", last.Reason),
+	})
+	return true
+}
+
+// credsSecretName 返回任务使用的凭据 Secret 名称
+// 任务从存储中重新加载后不包含 SecretName，根据认证配置推断
+func credsSecretName(task *models.Task) string {
+	if task.SecretName != "" {
+		return task.SecretName
+	}
+	if task.SecretID > 0 || (task.Registry != "" && task.Username != "" && task.Password != "") {
+		return k8s.CredsSecretName(task.ID)
+	}
+	return ""
+}
+
+// markNodeTimedOut 将任务超时时仍未结束的节点记为失败
+func (t *StatusTracker) markNodeTimedOut(task *models.Task, nodeName string) models.FailedNode {
+	message := fmt.Sprintf("task deadline of %ds exceeded before the node finished", task.TimeoutSeconds)
+	if _, processed := task.NodeResults[nodeName]; !processed {
+		task.NodeResults[nodeName] = &models.NodeResult{
+			NodeName:  nodeName,
+			Status:    models.NodeResultFailed,
+			Message:   message,
+			UpdatedAt: time.Now(),
+		}
+		metrics.NodesProcessed.WithLabelValues("failed").Inc()
+		t.events.Publish(models.TaskEvent{
+			TaskID:   task.ID,
+			Type:     models.TaskEventNodeResult,
+			NodeName: nodeName,
+			Result:   models.NodeResultFailed,
+			Reason:   FailureReasonTimeout,
+			Message:  message,
+		})
+	}
+	return models.FailedNode{
+		NodeName:  nodeName,
+		Reason:    FailureReasonTimeout,
+		Message:   message,
+		Timestamp: time.Now(),
+	}
+}
+
+// isJobDeadlineExceeded Job 是否因超过 ActiveDeadlineSeconds 而失败
+func isJobDeadlineExceeded(job *batchv1.Job) bool {
+	for _, cond := range job.Status.Conditions {
+		if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue && cond.Reason == "DeadlineExceeded" {
+			return true
+		}
+	}
+	return false
+}
+
 // getJobFailureMessage 获取Job失败原因
 func getJobFailureMessage(job *batchv1.Job) string {
 	if len(job.Status.Conditions) > 0 {
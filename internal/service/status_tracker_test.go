@@ -0,0 +1,398 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kitsnail/ips/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParsePullResults(t *testing.T) {
+	results, err := parsePullResults(`[{"image":"nginx:latest","status":"succeeded","durationMs":1200,"digest":"sha256:abc","sizeBytes":1024},{"image":"redis:7","status":"failed","error":"not found","durationMs":30}]`)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, models.ImagePullSucceeded, results[0].Status)
+	assert.Equal(t, "sha256:abc", results[0].Digest)
+	assert.Equal(t, uint64(1024), results[0].SizeBytes)
+	assert.Equal(t, "not found", results[1].Error)
+
+	// 兼容旧版本 puller 的输出
+	results, err = parsePullResults(`{"redis:7":0,"nginx:latest":1}`)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, models.ImagePullResult{Image: "nginx:latest", Status: models.ImagePullSucceeded}, results[0])
+	assert.Equal(t, models.ImagePullResult{Image: "redis:7", Status: models.ImagePullFailed}, results[1])
+
+	_, err = parsePullResults("not json")
+	assert.Error(t, err)
+}
+
+func TestStatusTracker_HandlePodDetailedResults(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "prewarm-task-1-node-1-abcde",
+			Namespace: "default",
+			Labels:    map[string]string{"job-name": "prewarm-task-1-node-1"},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name: "puller",
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+					Message: `[{"image":"nginx:latest","status":"already_present"},{"image":"redis:7","status":"failed","error":"unauthorized"}]`,
+				}},
+			}},
+		},
+	}
+	taskManager, _, _ := setupTaskManager(t, pod)
+
+	task := &models.Task{ID: "task-1", NodeResults: make(map[string]*models.NodeResult)}
+	taskManager.statusTracker.handlePodDetailedResults(context.Background(), "node-1", "prewarm-task-1-node-1", task)
+
+	result := task.NodeResults["node-1"]
+	require.NotNil(t, result)
+	assert.Equal(t, models.NodeResultFailed, result.Status)
+	assert.Equal(t, models.ImagePullAlreadyPresent, result.ImageResult("nginx:latest").Status)
+	assert.Equal(t, "unauthorized", result.ImageResult("redis:7").Error)
+}
+
+func TestTaskManager_GetNodeResult(t *testing.T) {
+	taskManager, repo, _ := setupTaskManager(t)
+
+	ctx := context.Background()
+	require.NoError(t, repo.CreateTask(ctx, &models.Task{
+		ID:          "task-1",
+		Status:      models.TaskRunning,
+		TargetNodes: []string{"node-1", "node-2"},
+		NodeResults: map[string]*models.NodeResult{
+			"node-1": models.NewNodeResult("node-1", []models.ImagePullResult{{Image: "nginx:latest", Status: models.ImagePullSucceeded}}),
+		},
+	}))
+
+	result, err := taskManager.GetNodeResult(ctx, "task-1", "node-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.NodeResultSucceeded, result.Status)
+
+	result, err = taskManager.GetNodeResult(ctx, "task-1", "node-2")
+	require.NoError(t, err)
+	assert.Equal(t, models.NodeResultPending, result.Status)
+
+	_, err = taskManager.GetNodeResult(ctx, "task-1", "node-3")
+	assert.ErrorIs(t, err, ErrNodeNotInTask)
+}
+
+// newPrewarmJob 构造指定任务和节点的预热 Job
+func newPrewarmJob(taskID, nodeName string, status batchv1.JobStatus) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "prewarm-" + taskID + "-" + nodeName,
+			Namespace: "default",
+			Labels: map[string]string{
+				"app":     "image-prewarm",
+				"task-id": taskID,
+				"node":    nodeName,
+			},
+		},
+		Status: status,
+	}
+}
+
+func TestStatusTracker_UpdateTaskStatus_Timeout(t *testing.T) {
+	taskManager, repo, _ := setupTaskManager(t,
+		newPrewarmJob("task-1", "node-1", batchv1.JobStatus{Succeeded: 1}),
+		newPrewarmJob("task-1", "node-2", batchv1.JobStatus{Active: 1}),
+		newPrewarmJob("task-1", "node-3", batchv1.JobStatus{
+			Failed: 1,
+			Conditions: []batchv1.JobCondition{{
+				Type:    batchv1.JobFailed,
+				Status:  corev1.ConditionTrue,
+				Reason:  "DeadlineExceeded",
+				Message: "Job was active longer than specified deadline",
+			}},
+		}),
+	)
+
+	ctx := context.Background()
+	startedAt := time.Now().Add(-2 * time.Minute)
+	task := &models.Task{
+		ID:             "task-1",
+		Status:         models.TaskRunning,
+		Images:         []string{"nginx:latest"},
+		TimeoutSeconds: 60,
+		TargetNodes:    []string{"node-1", "node-2", "node-3", "node-4"},
+		Progress:       &models.Progress{TotalNodes: 4},
+		StartedAt:      &startedAt,
+	}
+	require.NoError(t, repo.CreateTask(ctx, task))
+
+	require.NoError(t, taskManager.statusTracker.updateTaskStatus(ctx, task))
+
+	stored, err := repo.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TaskFailed, stored.Status)
+	assert.NotNil(t, stored.FinishedAt)
+	assert.Equal(t, 1, stored.Progress.CompletedNodes)
+	assert.Equal(t, 3, stored.Progress.FailedNodes)
+	assert.Contains(t, stored.ErrorMessage, "timed out")
+
+	// 运行中、已超时失败及未提交的节点均记为 Timeout
+	reasons := map[string]string{}
+	for _, node := range stored.FailedNodes {
+		reasons[node.NodeName] = node.Reason
+	}
+	assert.Equal(t, map[string]string{"node-2": "Timeout", "node-3": "Timeout", "node-4": "Timeout"}, reasons)
+	assert.Equal(t, models.NodeResultFailed, stored.NodeResults["node-4"].Status)
+}
+
+func TestStatusTracker_UpdateTaskStatus_BeforeDeadline(t *testing.T) {
+	taskManager, repo, _ := setupTaskManager(t,
+		newPrewarmJob("task-1", "node-1", batchv1.JobStatus{Active: 1}),
+	)
+
+	ctx := context.Background()
+	startedAt := time.Now()
+	task := &models.Task{
+		ID:             "task-1",
+		Status:         models.TaskRunning,
+		TimeoutSeconds: 600,
+		TargetNodes:    []string{"node-1"},
+		Progress:       &models.Progress{TotalNodes: 1},
+		StartedAt:      &startedAt,
+	}
+	require.NoError(t, repo.CreateTask(ctx, task))
+
+	require.NoError(t, taskManager.statusTracker.updateTaskStatus(ctx, task))
+	assert.Equal(t, models.TaskRunning, task.Status)
+	assert.Empty(t, task.FailedNodes)
+}
+
+func TestStatusTracker_UpdateTaskStatus_RetriesFailedNodes(t *testing.T) {
+	failedStatus := batchv1.JobStatus{
+		Failed: 1,
+		Conditions: []batchv1.JobCondition{{
+			Type:    batchv1.JobFailed,
+			Status:  corev1.ConditionTrue,
+			Reason:  "BackoffLimitExceeded",
+			Message: "Job has reached the specified backoff limit",
+		}},
+	}
+	taskManager, repo, k8sClient := setupTaskManager(t,
+		newPrewarmJob("task-1", "node-1", failedStatus),
+		newPrewarmJob("task-1", "node-2", batchv1.JobStatus{Succeeded: 1}),
+	)
+
+	ctx := context.Background()
+	task := &models.Task{
+		ID:            "task-1",
+		Status:        models.TaskRunning,
+		Images:        []string{"nginx:latest"},
+		MaxRetries:    2,
+		RetryStrategy: "linear",
+		TargetNodes:   []string{"node-1", "node-2"},
+		Progress:      &models.Progress{TotalNodes: 2},
+	}
+	require.NoError(t, repo.CreateTask(ctx, task))
+	tracker := taskManager.statusTracker
+
+	// 只为失败的节点创建带尝试序号的 Job
+	require.NoError(t, tracker.updateTaskStatus(ctx, task))
+	assert.Equal(t, models.TaskRunning, task.Status)
+	jobs := waitForJobs(t, k8sClient, task.ID, 3)
+	retryJob := findJob(jobs, "prewarm-task-1-node-1-a2")
+	require.NotNil(t, retryJob)
+	assert.Equal(t, "2", retryJob.Labels["attempt"])
+	assert.Equal(t, 1, task.NodeRetryCount("node-1"))
+	assert.Equal(t, 0, task.NodeRetryCount("node-2"))
+
+	markJobFinished(t, k8sClient, *retryJob, false)
+	require.NoError(t, tracker.updateTaskStatus(ctx, task))
+	jobs = waitForJobs(t, k8sClient, task.ID, 4)
+
+	// 达到重试次数后节点失败，任务结束
+	markJobFinished(t, k8sClient, *findJob(jobs, "prewarm-task-1-node-1-a3"), false)
+	require.NoError(t, tracker.updateTaskStatus(ctx, task))
+	waitForJobs(t, k8sClient, task.ID, 4)
+
+	stored, err := repo.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TaskFailed, stored.Status)
+	require.Len(t, stored.FailedNodes, 1)
+	assert.Equal(t, "node-1", stored.FailedNodes[0].NodeName)
+	assert.Equal(t, 3, stored.FailedNodes[0].Attempts)
+
+	attempts := stored.NodeAttempts["node-1"]
+	require.Len(t, attempts, 3)
+	for i, attempt := range attempts {
+		assert.Equal(t, i+1, attempt.Attempt)
+		assert.Equal(t, models.NodeResultFailed, attempt.Status)
+		assert.NotNil(t, attempt.FinishedAt)
+	}
+	assert.Equal(t, "prewarm-task-1-node-1", attempts[0].JobName)
+	assert.Equal(t, "JobFailed", attempts[0].Reason)
+	assert.Equal(t, "Job has reached the specified backoff limit", attempts[0].Message)
+}
+
+func TestStatusTracker_UpdateTaskStatus_RetrySucceeds(t *testing.T) {
+	taskManager, repo, k8sClient := setupTaskManager(t,
+		newPrewarmJob("task-1", "node-1", batchv1.JobStatus{Failed: 1}),
+	)
+
+	ctx := context.Background()
+	task := &models.Task{
+		ID:          "task-1",
+		Status:      models.TaskRunning,
+		Images:      []string{"nginx:latest"},
+		MaxRetries:  1,
+		TargetNodes: []string{"node-1"},
+		Progress:    &models.Progress{TotalNodes: 1},
+	}
+	require.NoError(t, repo.CreateTask(ctx, task))
+	tracker := taskManager.statusTracker
+
+	require.NoError(t, tracker.updateTaskStatus(ctx, task))
+	jobs := waitForJobs(t, k8sClient, task.ID, 2)
+	markJobFinished(t, k8sClient, *findJob(jobs, "prewarm-task-1-node-1-a2"), true)
+
+	require.NoError(t, tracker.updateTaskStatus(ctx, task))
+	assert.Equal(t, models.TaskCompleted, task.Status)
+	assert.Empty(t, task.FailedNodes)
+	assert.Equal(t, 1, task.Progress.CompletedNodes)
+	assert.Equal(t, models.NodeResultSucceeded, task.NodeAttempts["node-1"][1].Status)
+	// 上一次尝试的失败结果不再保留
+	assert.NotContains(t, task.NodeResults, "node-1")
+}
+
+// findJob 按名称查找 Job
+func findJob(jobs []batchv1.Job, name string) *batchv1.Job {
+	for i := range jobs {
+		if jobs[i].Name == name {
+			return &jobs[i]
+		}
+	}
+	return nil
+}
+
+func TestStatusTracker_TrackTask_Informer(t *testing.T) {
+	taskManager, repo, k8sClient := setupTaskManager(t,
+		newPrewarmJob("task-1", "node-1", batchv1.JobStatus{Active: 1}),
+	)
+	tracker := taskManager.statusTracker
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	informer, err := k8sClient.NewPrewarmInformer(0)
+	require.NoError(t, err)
+	informer.Start(ctx)
+	require.NoError(t, informer.WaitForCacheSync(ctx))
+	tracker.SetInformer(informer)
+
+	task := &models.Task{
+		ID:          "task-1",
+		Status:      models.TaskRunning,
+		Images:      []string{"nginx:latest"},
+		TargetNodes: []string{"node-1"},
+		Progress:    &models.Progress{TotalNodes: 1},
+	}
+	require.NoError(t, repo.CreateTask(ctx, task))
+
+	done := make(chan error, 1)
+	go func() { done <- tracker.TrackTask(ctx, task.ID) }()
+
+	// Job 状态变更通过 Informer 事件驱动任务更新
+	job, err := k8sClient.Clientset.BatchV1().Jobs("default").Get(ctx, "prewarm-task-1-node-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	job.Status = batchv1.JobStatus{Succeeded: 1}
+	_, err = k8sClient.Clientset.BatchV1().Jobs("default").UpdateStatus(ctx, job, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("task tracking did not finish")
+	}
+
+	got, err := repo.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TaskCompleted, got.Status)
+	assert.Equal(t, 1, got.Progress.CompletedNodes)
+}
+
+func TestStatusTracker_UpdateTaskStatus_CapturesNodeLogs(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "prewarm-task-1-node-1-abcde",
+			Namespace: "default",
+			Labels:    map[string]string{"job-name": "prewarm-task-1-node-1"},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodFailed},
+	}
+	taskManager, repo, _ := setupTaskManager(t,
+		newPrewarmJob("task-1", "node-1", batchv1.JobStatus{Failed: 1}),
+		newPrewarmJob("task-1", "node-2", batchv1.JobStatus{Succeeded: 1}),
+		pod,
+	)
+
+	ctx := context.Background()
+	task := &models.Task{
+		ID:          "task-1",
+		Status:      models.TaskRunning,
+		Images:      []string{"nginx:latest"},
+		TargetNodes: []string{"node-1", "node-2"},
+		Progress:    &models.Progress{TotalNodes: 2},
+	}
+	require.NoError(t, repo.CreateTask(ctx, task))
+
+	require.NoError(t, taskManager.statusTracker.updateTaskStatus(ctx, task))
+
+	nodeLog, err := taskManager.GetNodeLogs(ctx, "task-1", "node-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, nodeLog.Attempt)
+	assert.Equal(t, "prewarm-task-1-node-1", nodeLog.JobName)
+	assert.Equal(t, pod.Name, nodeLog.PodName)
+	assert.Equal(t, "fake logs", nodeLog.Logs) // fake clientset 固定返回的日志
+	assert.False(t, nodeLog.Truncated)
+
+	// 日志单独保存，不随任务记录写入
+	stored, err := repo.GetNodeLog(ctx, "task-1", "node-1")
+	require.NoError(t, err)
+	assert.Equal(t, nodeLog, stored)
+
+	// 成功的节点不采集日志
+	_, err = taskManager.GetNodeLogs(ctx, "task-1", "node-2")
+	assert.ErrorIs(t, err, ErrNodeLogsNotFound)
+	_, err = taskManager.GetNodeLogs(ctx, "task-1", "node-3")
+	assert.ErrorIs(t, err, ErrNodeNotInTask)
+}
+
+func TestTailLogLines(t *testing.T) {
+	// 请求 limit+1 行，恰好 limit 行时未截断
+	logs, clipped := tailLogLines("line1\nline2\n", 2)
+	assert.Equal(t, "line1\nline2\n", logs)
+	assert.False(t, clipped)
+
+	logs, clipped = tailLogLines("line1\nline2\nline3", 2)
+	assert.Equal(t, "line2\nline3", logs)
+	assert.True(t, clipped)
+
+	logs, clipped = tailLogLines("", 2)
+	assert.Equal(t, "", logs)
+	assert.False(t, clipped)
+}
+
+func TestTruncateLogs(t *testing.T) {
+	logs, truncated := truncateLogs("line1\nline2\n", 64)
+	assert.Equal(t, "line1\nline2\n", logs)
+	assert.False(t, truncated)
+
+	// 只保留末尾的完整行
+	logs, truncated = truncateLogs("line1\nline2\nline3\n", 10)
+	assert.Equal(t, "line3\n", logs)
+	assert.True(t, truncated)
+}
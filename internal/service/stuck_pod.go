@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// 节点失败原因（FailedNode.Reason / NodeAttempt.Reason）
+const (
+	FailureReasonJobFailed                  = "JobFailed"
+	FailureReasonTimeout                    = "Timeout"
+	FailureReasonJobNotFound                = "JobNotFound"
+	FailureReasonUnschedulable              = "Unschedulable"
+	FailureReasonErrImagePull               = "ErrImagePull"
+	FailureReasonCreateContainerConfigError = "CreateContainerConfigError"
+	FailureReasonEvicted                    = "Evicted"
+)
+
+// defaultStuckPodGracePeriod Pod 处于卡住状态超过该时间后将节点标记为失败
+const defaultStuckPodGracePeriod = 5 * time.Minute
+
+// jobNotFoundGracePeriod 尝试开始后超过该时间仍找不到 Job 才将节点标记为失败
+const jobNotFoundGracePeriod = time.Minute
+
+// stuckWaitingReasons 容器等待原因 -> 节点失败原因
+var stuckWaitingReasons = map[string]string{
+	"ErrImagePull":               FailureReasonErrImagePull,
+	"ImagePullBackOff":           FailureReasonErrImagePull,
+	"InvalidImageName":           FailureReasonErrImagePull,
+	"CreateContainerConfigError": FailureReasonCreateContainerConfigError,
+	"CreateContainerError":       FailureReasonCreateContainerConfigError,
+}
+
+// classifyStuckPod 判断 puller Pod 是否卡住，返回失败原因与详细信息
+// 被驱逐的 Pod 立即判定；无法调度或镜像/容器配置错误的 Pod 进入该状态超过 gracePeriod 后判定
+func classifyStuckPod(pod *corev1.Pod, gracePeriod time.Duration, now time.Time) (reason, message string, stuck bool) {
+	if pod.Status.Phase == corev1.PodFailed && pod.Status.Reason == "Evicted" {
+		return FailureReasonEvicted, pod.Status.Message, true
+	}
+
+	if pod.Status.Phase == corev1.PodPending {
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionFalse && cond.Reason == corev1.PodReasonUnschedulable {
+				// 从 Pod 变为无法调度时开始计算，而不是从创建时开始
+				since := cond.LastTransitionTime.Time
+				if since.IsZero() {
+					since = pod.CreationTimestamp.Time
+				}
+				if now.Sub(since) < gracePeriod {
+					return "", "", false
+				}
+				return FailureReasonUnschedulable, cond.Message, true
+			}
+		}
+	}
+
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for i := range statuses {
+		cs := &statuses[i]
+		if cs.State.Waiting == nil {
+			continue
+		}
+		reason, ok := stuckWaitingReasons[cs.State.Waiting.Reason]
+		if !ok || now.Sub(containerWaitingSince(pod, cs)) < gracePeriod {
+			continue
+		}
+		return reason, fmt.Sprintf("%s: %s", cs.State.Waiting.Reason, cs.State.Waiting.Message), true
+	}
+
+	return "", "", false
+}
+
+// containerWaitingSince 估算容器进入等待状态的时间
+// 等待状态没有时间戳，取容器上次退出与 Pod 调度、初始化、容器就绪状态最近一次变化中最晚的时间，
+// 避免把等待调度或上次运行的时间计入卡住时长
+func containerWaitingSince(pod *corev1.Pod, cs *corev1.ContainerStatus) time.Time {
+	since := pod.CreationTimestamp.Time
+	for _, cond := range pod.Status.Conditions {
+		switch cond.Type {
+		case corev1.PodScheduled, corev1.PodInitialized, corev1.ContainersReady:
+			if cond.LastTransitionTime.After(since) {
+				since = cond.LastTransitionTime.Time
+			}
+		}
+	}
+	if terminated := cs.LastTerminationState.Terminated; terminated != nil && terminated.FinishedAt.After(since) {
+		since = terminated.FinishedAt.Time
+	}
+	return since
+}
+
+// SetStuckPodGracePeriod 设置 Pod 卡住多久后将节点标记为失败
+func (t *StatusTracker) SetStuckPodGracePeriod(gracePeriod time.Duration) {
+	if gracePeriod > 0 {
+		t.stuckPodGracePeriod = gracePeriod
+	}
+}
+
+// detectStuckJob 检查运行中 Job 的 Pod 是否卡住
+func (t *StatusTracker) detectStuckJob(ctx context.Context, job *batchv1.Job) (reason, message string, stuck bool) {
+	pods, err := t.listPods(ctx, job.Name)
+	if err != nil {
+		return "", "", false
+	}
+
+	now := time.Now()
+	for i := range pods {
+		if reason, message, stuck := classifyStuckPod(&pods[i], t.stuckPodGracePeriod, now); stuck {
+			return reason, message, true
+		}
+	}
+	return "", "", false
+}
+
+// jobFailureReason 返回失败 Job 的失败原因，Pod 被驱逐时优先返回 Evicted
+func (t *StatusTracker) jobFailureReason(ctx context.Context, job *batchv1.Job) (reason, message string) {
+	if isJobDeadlineExceeded(job) {
+		return FailureReasonTimeout, getJobFailureMessage(job)
+	}
+
+	if pods, err := t.listPods(ctx, job.Name); err == nil {
+		for _, pod := range pods {
+			if pod.Status.Phase == corev1.PodFailed && pod.Status.Reason == "Evicted" {
+				return FailureReasonEvicted, pod.Status.Message
+			}
+		}
+	}
+
+	return FailureReasonJobFailed, getJobFailureMessage(job)
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kitsnail/ips/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newPullerPod 构造预热 Job 创建的 puller Pod
+func newPullerPod(jobName string, createdAt time.Time, status corev1.PodStatus) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              jobName + "-abcde",
+			Namespace:         "default",
+			Labels:            map[string]string{"job-name": jobName},
+			CreationTimestamp: metav1.NewTime(createdAt),
+		},
+		Status: status,
+	}
+}
+
+func waitingStatus(reason, message string) corev1.PodStatus {
+	return corev1.PodStatus{
+		Phase: corev1.PodPending,
+		ContainerStatuses: []corev1.ContainerStatus{{
+			Name:  "puller",
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason, Message: message}},
+		}},
+	}
+}
+
+func TestClassifyStuckPod(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-10 * time.Minute)
+	grace := 5 * time.Minute
+
+	tests := []struct {
+		name       string
+		pod        *corev1.Pod
+		wantReason string
+		wantStuck  bool
+	}{
+		{
+			name: "无法调度",
+			pod: newPullerPod("job", old, corev1.PodStatus{
+				Phase: corev1.PodPending,
+				Conditions: []corev1.PodCondition{{
+					Type:    corev1.PodScheduled,
+					Status:  corev1.ConditionFalse,
+					Reason:  corev1.PodReasonUnschedulable,
+					Message: "0/3 nodes are available: 1 node(s) had untolerated taint",
+				}},
+			}),
+			wantReason: FailureReasonUnschedulable,
+			wantStuck:  true,
+		},
+		{
+			name:       "puller 镜像拉取失败",
+			pod:        newPullerPod("job", old, waitingStatus("ImagePullBackOff", "Back-off pulling image")),
+			wantReason: FailureReasonErrImagePull,
+			wantStuck:  true,
+		},
+		{
+			name:       "容器配置错误",
+			pod:        newPullerPod("job", old, waitingStatus("CreateContainerConfigError", `secret "registry-creds-task" not found`)),
+			wantReason: FailureReasonCreateContainerConfigError,
+			wantStuck:  true,
+		},
+		{
+			name: "被驱逐的 Pod 立即判定",
+			pod: newPullerPod("job", now, corev1.PodStatus{
+				Phase:   corev1.PodFailed,
+				Reason:  "Evicted",
+				Message: "The node was low on resource: ephemeral-storage.",
+			}),
+			wantReason: FailureReasonEvicted,
+			wantStuck:  true,
+		},
+		{
+			name:      "未超过宽限期",
+			pod:       newPullerPod("job", now.Add(-time.Minute), waitingStatus("ErrImagePull", "timeout")),
+			wantStuck: false,
+		},
+		{
+			name: "刚变为无法调度",
+			pod: newPullerPod("job", old, corev1.PodStatus{
+				Phase: corev1.PodPending,
+				Conditions: []corev1.PodCondition{{
+					Type:               corev1.PodScheduled,
+					Status:             corev1.ConditionFalse,
+					Reason:             corev1.PodReasonUnschedulable,
+					LastTransitionTime: metav1.NewTime(now.Add(-time.Minute)),
+				}},
+			}),
+			wantStuck: false,
+		},
+		{
+			name: "长时间等待调度后刚开始拉取",
+			pod: func() *corev1.Pod {
+				pod := newPullerPod("job", old, waitingStatus("ErrImagePull", "timeout"))
+				pod.Status.Conditions = []corev1.PodCondition{{
+					Type:               corev1.PodScheduled,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(now.Add(-time.Minute)),
+				}}
+				return pod
+			}(),
+			wantStuck: false,
+		},
+		{
+			name: "容器刚退出后等待重启",
+			pod: func() *corev1.Pod {
+				pod := newPullerPod("job", old, waitingStatus("CreateContainerError", "context deadline exceeded"))
+				pod.Status.ContainerStatuses[0].LastTerminationState.Terminated = &corev1.ContainerStateTerminated{
+					FinishedAt: metav1.NewTime(now.Add(-time.Minute)),
+				}
+				return pod
+			}(),
+			wantStuck: false,
+		},
+		{
+			name: "调度后等待超过宽限期",
+			pod: func() *corev1.Pod {
+				pod := newPullerPod("job", now.Add(-time.Hour), waitingStatus("ImagePullBackOff", "Back-off pulling image"))
+				pod.Status.Conditions = []corev1.PodCondition{{
+					Type:               corev1.PodScheduled,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(old),
+				}}
+				return pod
+			}(),
+			wantReason: FailureReasonErrImagePull,
+			wantStuck:  true,
+		},
+		{
+			name:      "正在创建容器",
+			pod:       newPullerPod("job", old, waitingStatus("ContainerCreating", "")),
+			wantStuck: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, _, stuck := classifyStuckPod(tt.pod, grace, now)
+			assert.Equal(t, tt.wantStuck, stuck)
+			assert.Equal(t, tt.wantReason, reason)
+		})
+	}
+}
+
+func TestStatusTracker_UpdateTaskStatus_StuckPod(t *testing.T) {
+	stuckPod := newPullerPod("prewarm-task-1-node-1", time.Now().Add(-time.Minute), corev1.PodStatus{
+		Phase: corev1.PodPending,
+		Conditions: []corev1.PodCondition{{
+			Type:    corev1.PodScheduled,
+			Status:  corev1.ConditionFalse,
+			Reason:  corev1.PodReasonUnschedulable,
+			Message: "node(s) had disk pressure",
+		}},
+	})
+	taskManager, repo, k8sClient := setupTaskManager(t,
+		newPrewarmJob("task-1", "node-1", batchv1.JobStatus{Active: 1}),
+		newPrewarmJob("task-1", "node-2", batchv1.JobStatus{Succeeded: 1}),
+		stuckPod,
+	)
+	tracker := taskManager.statusTracker
+
+	ctx := context.Background()
+	task := &models.Task{
+		ID:          "task-1",
+		Status:      models.TaskRunning,
+		Images:      []string{"nginx:latest"},
+		TargetNodes: []string{"node-1", "node-2"},
+		Progress:    &models.Progress{TotalNodes: 2},
+	}
+	require.NoError(t, repo.CreateTask(ctx, task))
+
+	// 宽限期内视为运行中
+	require.NoError(t, tracker.updateTaskStatus(ctx, task))
+	assert.Equal(t, models.TaskRunning, task.Status)
+
+	tracker.SetStuckPodGracePeriod(30 * time.Second)
+	require.NoError(t, tracker.updateTaskStatus(ctx, task))
+
+	require.Len(t, task.FailedNodes, 1)
+	assert.Equal(t, "node-1", task.FailedNodes[0].NodeName)
+	assert.Equal(t, FailureReasonUnschedulable, task.FailedNodes[0].Reason)
+	assert.Equal(t, "node(s) had disk pressure", task.FailedNodes[0].Message)
+	assert.Equal(t, models.TaskFailed, task.Status)
+
+	// 卡住的 Job 被删除
+	waitForJobs(t, k8sClient, task.ID, 1)
+
+	// Job 删除后仍以尝试记录为准
+	require.NoError(t, tracker.updateTaskStatus(ctx, task))
+	assert.Equal(t, 1, task.Progress.FailedNodes)
+	assert.Equal(t, 1, task.Progress.CompletedNodes)
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kitsnail/ips/pkg/models"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ErrInvalidSuccessPolicy 任务成功条件无效
+var ErrInvalidSuccessPolicy = errors.New("invalid success policy")
+
+// FailureReasonImagePullFailed Job 正常结束但有镜像拉取失败（成功条件要求镜像全部成功时）
+const FailureReasonImagePullFailed = "ImagePullFailed"
+
+// ValidateSuccessPolicy 校验任务成功条件，创建任务前调用
+func ValidateSuccessPolicy(policy *models.SuccessPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSuccessPolicy, err)
+	}
+	if policy == nil {
+		return nil
+	}
+	if _, err := labels.ValidatedSelectorFromSet(policy.RequiredNodeSelector); err != nil {
+		return fmt.Errorf("%w: requiredNodeSelector: %v", ErrInvalidSuccessPolicy, err)
+	}
+	return nil
+}
+
+// resolveRequiredNodes 确定目标节点后解析必须成功的节点
+// 指定的节点名称全部保留（未被选中的节点无法成功，任务将失败），标签只匹配 candidates 中的节点
+func (m *TaskManager) resolveRequiredNodes(ctx context.Context, task *models.Task, candidates []string) error {
+	policy := task.SuccessPolicy
+	if policy == nil || (len(policy.RequiredNodes) == 0 && len(policy.RequiredNodeSelector) == 0) {
+		return nil
+	}
+
+	required := make(map[string]bool)
+	for _, nodeName := range policy.RequiredNodes {
+		required[nodeName] = true
+	}
+
+	if len(policy.RequiredNodeSelector) > 0 {
+		selector, err := labels.ValidatedSelectorFromSet(policy.RequiredNodeSelector)
+		if err != nil {
+			return fmt.Errorf("%w: requiredNodeSelector: %v", ErrInvalidSuccessPolicy, err)
+		}
+		nodes, err := m.nodeFilter.k8sClient.GetNodesBySelector(ctx, selector)
+		if err != nil {
+			return fmt.Errorf("failed to list required nodes: %w", err)
+		}
+		isCandidate := make(map[string]bool, len(candidates))
+		for _, nodeName := range candidates {
+			isCandidate[nodeName] = true
+		}
+		for _, node := range nodes {
+			if isCandidate[node.Name] {
+				required[node.Name] = true
+			}
+		}
+	}
+
+	task.RequiredNodes = make([]string, 0, len(required))
+	for nodeName := range required {
+		task.RequiredNodes = append(task.RequiredNodes, nodeName)
+	}
+	sort.Strings(task.RequiredNodes)
+	return nil
+}
+
+// evaluateSuccess 按任务成功条件判定结束的任务，返回未满足的原因，满足时返回空字符串
+// succeeded 为成功的节点（包括已存在全部镜像而跳过的节点）
+func evaluateSuccess(task *models.Task, succeeded map[string]bool, completed int) string {
+	var reasons []string
+
+	minPercent := task.SuccessPolicy.MinPercent()
+	if total := task.Progress.TotalNodes; total > 0 {
+		if percent := float64(completed) / float64(total) * 100; percent < minPercent {
+			reasons = append(reasons, fmt.Sprintf("%.1f%% of nodes succeeded, %.1f%% required", percent, minPercent))
+		}
+	}
+
+	var missing []string
+	for _, nodeName := range task.RequiredNodes {
+		if !succeeded[nodeName] {
+			missing = append(missing, nodeName)
+		}
+	}
+	if len(missing) > 0 {
+		reasons = append(reasons, fmt.Sprintf("required nodes did not succeed: %s", strings.Join(missing, ", ")))
+	}
+
+	return strings.Join(reasons, "; ")
+}
+
+// failedImages 返回节点结果中拉取失败的镜像
+func failedImages(result *models.NodeResult) []string {
+	if result == nil {
+		return nil
+	}
+	var images []string
+	for i := range result.Images {
+		if !result.Images[i].Succeeded() {
+			images = append(images, result.Images[i].Image)
+		}
+	}
+	return images
+}
+
+// imagesFailed 成功条件要求镜像全部就绪时，检查正常结束的 Job 是否有镜像拉取失败
+// 用于评估金丝雀节点，与状态跟踪器对节点的判定保持一致
+func (s *BatchScheduler) imagesFailed(ctx context.Context, task *models.Task, jobName string) bool {
+	if !task.SuccessPolicy.FailsOnImageFailure() {
+		return false
+	}
+
+	pods, err := s.listPods(ctx, jobName)
+	if err != nil {
+		return false
+	}
+	for _, pod := range pods {
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.Name != "puller" || cs.State.Terminated == nil || cs.State.Terminated.Message == "" {
+				continue
+			}
+			results, err := parsePullResults(cs.State.Terminated.Message)
+			if err != nil {
+				return false
+			}
+			return len(failedImages(models.NewNodeResult("", results))) > 0
+		}
+	}
+	return false
+}
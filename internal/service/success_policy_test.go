@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kitsnail/ips/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateSuccessPolicy(t *testing.T) {
+	assert.NoError(t, ValidateSuccessPolicy(nil))
+	assert.NoError(t, ValidateSuccessPolicy(&models.SuccessPolicy{
+		MinSuccessPercent:    100,
+		RequiredNodes:        []string{"node-1"},
+		RequiredNodeSelector: map[string]string{"node-role.kubernetes.io/gpu": "true"},
+	}))
+
+	for _, policy := range []*models.SuccessPolicy{
+		{MinSuccessPercent: 101},
+		{MinSuccessPercent: -1},
+		{RequiredNodes: []string{""}},
+		{RequiredNodeSelector: map[string]string{"bad key!": "true"}},
+	} {
+		assert.ErrorIs(t, ValidateSuccessPolicy(policy), ErrInvalidSuccessPolicy)
+	}
+}
+
+func TestStatusTracker_UpdateTaskStatus_MinSuccessPercent(t *testing.T) {
+	taskManager, repo, _ := setupTaskManager(t,
+		newPrewarmJob("task-1", "node-1", batchv1.JobStatus{Succeeded: 1}),
+		newPrewarmJob("task-1", "node-2", batchv1.JobStatus{Succeeded: 1}),
+		newPrewarmJob("task-1", "node-3", batchv1.JobStatus{Succeeded: 1}),
+		newPrewarmJob("task-1", "node-4", batchv1.JobStatus{Failed: 1}),
+	)
+
+	ctx := context.Background()
+	task := &models.Task{
+		ID:            "task-1",
+		Status:        models.TaskRunning,
+		TargetNodes:   []string{"node-1", "node-2", "node-3", "node-4"},
+		Progress:      &models.Progress{TotalNodes: 4},
+		SuccessPolicy: &models.SuccessPolicy{MinSuccessPercent: 75},
+	}
+	require.NoError(t, repo.CreateTask(ctx, task))
+
+	// 默认 90% 时 3/4 的成功率判定为失败，成功条件放宽到 75% 后任务完成
+	require.NoError(t, taskManager.statusTracker.updateTaskStatus(ctx, task))
+	assert.Equal(t, models.TaskCompleted, task.Status)
+	assert.Empty(t, task.ErrorMessage)
+}
+
+func TestStatusTracker_UpdateTaskStatus_RequiredNodeImageFailure(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "prewarm-task-1-node-1-abcde",
+			Namespace: "default",
+			Labels:    map[string]string{"job-name": "prewarm-task-1-node-1"},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name: "puller",
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+					Message: `[{"image":"nginx:latest","status":"succeeded"},{"image":"redis:7","status":"failed","error":"unauthorized"}]`,
+				}},
+			}},
+		},
+	}
+	taskManager, repo, _ := setupTaskManager(t,
+		pod,
+		newPrewarmJob("task-1", "node-1", batchv1.JobStatus{Succeeded: 1}),
+		newPrewarmJob("task-1", "node-2", batchv1.JobStatus{Succeeded: 1}),
+	)
+
+	ctx := context.Background()
+	task := &models.Task{
+		ID:            "task-1",
+		Status:        models.TaskRunning,
+		Images:        []string{"nginx:latest", "redis:7"},
+		TargetNodes:   []string{"node-1", "node-2"},
+		Progress:      &models.Progress{TotalNodes: 2},
+		SuccessPolicy: &models.SuccessPolicy{MinSuccessPercent: 50, RequiredNodes: []string{"node-1"}, ImageFailureFailsNode: true},
+		RequiredNodes: []string{"node-1"},
+	}
+	require.NoError(t, repo.CreateTask(ctx, task))
+
+	require.NoError(t, taskManager.statusTracker.updateTaskStatus(ctx, task))
+
+	// Job 正常退出但 redis:7 拉取失败，node-1 记为失败；成功率满足 50%，但必须成功的节点失败
+	stored, err := repo.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TaskFailed, stored.Status)
+	assert.Equal(t, 1, stored.Progress.CompletedNodes)
+	assert.Contains(t, stored.ErrorMessage, "required nodes did not succeed: node-1")
+	require.Len(t, stored.FailedNodes, 1)
+	assert.Equal(t, FailureReasonImagePullFailed, stored.FailedNodes[0].Reason)
+	assert.Contains(t, stored.FailedNodes[0].Message, "redis:7")
+	assert.Equal(t, models.NodeResultFailed, stored.NodeAttempts["node-1"][0].Status)
+}
+
+func TestTaskManager_ResolveRequiredNodes(t *testing.T) {
+	gpuNode := func(name string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"gpu": "true"}}}
+	}
+	taskManager, _, _ := setupTaskManager(t, gpuNode("node-1"), gpuNode("node-3"))
+
+	task := &models.Task{SuccessPolicy: &models.SuccessPolicy{
+		RequiredNodes:        []string{"node-9"},
+		RequiredNodeSelector: map[string]string{"gpu": "true"},
+	}}
+	require.NoError(t, taskManager.resolveRequiredNodes(context.Background(), task, []string{"node-1", "node-2"}))
+
+	// 标签只匹配目标节点，指定的节点名称全部保留
+	assert.Equal(t, []string{"node-1", "node-9"}, task.RequiredNodes)
+}
@@ -2,17 +2,38 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/kitsnail/ips/internal/k8s"
+	"github.com/kitsnail/ips/internal/registry"
 	"github.com/kitsnail/ips/internal/repository"
 	"github.com/kitsnail/ips/pkg/metrics"
 	"github.com/kitsnail/ips/pkg/models"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/sync/semaphore"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 )
 
+// ErrNodeNotInTask 节点不属于该任务
+var ErrNodeNotInTask = errors.New("node not in task")
+
+// ErrNodeLogsNotFound 节点没有采集到日志（未失败或采集失败）
+var ErrNodeLogsNotFound = errors.New("node logs not found")
+
+// ErrTaskPaused 任务已被暂停，执行器停止提交 Job 并退出
+var ErrTaskPaused = errors.New("task paused")
+
+// ErrTaskNotPausable 只有排队中或执行中的任务可以暂停
+var ErrTaskNotPausable = errors.New("task cannot be paused")
+
+// ErrTaskNotPaused 只有已暂停的任务可以恢复
+var ErrTaskNotPaused = errors.New("task is not paused")
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -28,52 +49,269 @@ type TaskManager struct {
 	batchScheduler  *BatchScheduler
 	statusTracker   *StatusTracker
 	webhookNotifier *WebhookNotifier
+	events          *EventBroker
 	logger          *logrus.Logger
-	concurrencySem  *semaphore.Weighted // 并发控制信号量
-	maxConcurrency  int64               // 最大并发任务数
+	settingsRepo    repository.SettingsRepository
+	eventRepo       repository.TaskEventRepository
+	registryClient  *registry.Client // 解析镜像 digest
+
+	// 待执行任务按优先级排队，有空闲槽位时由 dispatch 出队执行
+	// 以下字段受 mu 保护
+	queue            *PriorityQueue
+	limits           models.ConcurrencyLimits // 并发限制
+	running          int                      // 正在执行的任务数
+	runningByCreator map[string]int           // 按创建者统计的执行中任务数
+	runningByGroup   map[string]int           // 按节点选择器分组统计的执行中任务数
 
 	// 用于存储任务的取消函数
 	taskContexts sync.Map // map[string]context.CancelFunc
+
+	// 多副本部署时仅 Leader 执行任务，Follower 只负责落库排队
+	leader            atomic.Bool
+	mu                sync.Mutex
+	stopLoop          context.CancelFunc
+	reconcileInterval time.Duration
+	eventRetention    time.Duration // 任务事件的保留时间
 }
 
 // NewTaskManager 创建任务管理器
 func NewTaskManager(
 	repo repository.TaskRepository,
 	secretRepo repository.SecretRegistryRepository,
+	settingsRepo repository.SettingsRepository,
+	eventRepo repository.TaskEventRepository,
 	nodeFilter *NodeFilter,
 	batchScheduler *BatchScheduler,
 	statusTracker *StatusTracker,
 	logger *logrus.Logger,
 ) *TaskManager {
-	// 默认最大并发任务数为 3
-	maxConcurrency := int64(3)
-	// 可以通过环境变量配置
-	// if envMax := os.Getenv("MAX_CONCURRENT_TASKS"); envMax != "" {
-	//     if max, err := strconv.ParseInt(envMax, 10, 64); err == nil && max > 0 {
-	//         maxConcurrency = max
-	//     }
-	// }
-
-	return &TaskManager{
-		repo:            repo,
-		secretRepo:      secretRepo,
-		nodeFilter:      nodeFilter,
-		batchScheduler:  batchScheduler,
-		statusTracker:   statusTracker,
-		webhookNotifier: NewWebhookNotifier(logger),
-		logger:          logger,
-		concurrencySem:  semaphore.NewWeighted(maxConcurrency),
-		maxConcurrency:  maxConcurrency,
+	// 默认最大并发任务数为 3，可以通过环境变量配置
+	maxConcurrency := 3
+	if envMax := os.Getenv("MAX_CONCURRENT_TASKS"); envMax != "" {
+		if max, err := strconv.Atoi(envMax); err == nil && max > 0 {
+			maxConcurrency = max
+		}
+	}
+	// 默认每等待 1 分钟有效优先级加 1
+	agingInterval := time.Minute
+
+	// 任务事件由 TaskManager、BatchScheduler 与 StatusTracker 共同发布
+	events := NewEventBroker(defaultEventHistorySize, eventRepo, logger)
+	if batchScheduler != nil {
+		batchScheduler.events = events
+	}
+	if statusTracker != nil {
+		statusTracker.events = events
+	}
+
+	m := &TaskManager{
+		repo:              repo,
+		secretRepo:        secretRepo,
+		settingsRepo:      settingsRepo,
+		eventRepo:         eventRepo,
+		nodeFilter:        nodeFilter,
+		batchScheduler:    batchScheduler,
+		statusTracker:     statusTracker,
+		webhookNotifier:   NewWebhookNotifier(logger),
+		registryClient:    registry.NewClient(nil),
+		events:            events,
+		logger:            logger,
+		queue:             NewPriorityQueueWithAging(agingInterval),
+		limits:            models.ConcurrencyLimits{Global: maxConcurrency},
+		runningByCreator:  make(map[string]int),
+		runningByGroup:    make(map[string]int),
+		reconcileInterval: 10 * time.Second,
+		eventRetention:    defaultEventRetention,
+	}
+
+	if batchScheduler != nil {
+		batchScheduler.pauseCheck = m.checkPaused
+	}
+
+	// 已持久化的并发限制优先于环境变量
+	if err := m.loadConcurrencyLimits(context.Background()); err != nil {
+		logger.WithField("error", err).Warn("Failed to load concurrency limits, using defaults")
+	}
+
+	return m
+}
+
+// Start 启动任务执行器（仅在 Leader 上调用）
+// 恢复未结束的任务，并定期接管 Follower 排队的任务
+func (m *TaskManager) Start(ctx context.Context) {
+	m.leader.Store(true)
+
+	if err := m.RecoverTasks(ctx); err != nil {
+		m.logger.WithField("error", err).Error("Failed to recover unfinished tasks")
+	}
+
+	loopCtx, cancel := context.WithCancel(ctx)
+	m.mu.Lock()
+	m.stopLoop = cancel
+	m.mu.Unlock()
+
+	go m.reconcileLoop(loopCtx)
+
+	m.logger.Info("Task executor started")
+}
+
+// Stop 停止任务执行器（失去 Leader 身份或服务关闭时调用）
+// 仅取消本地执行上下文，任务状态保持不变，由新的 Leader 接管
+func (m *TaskManager) Stop() {
+	m.leader.Store(false)
+
+	m.mu.Lock()
+	if m.stopLoop != nil {
+		m.stopLoop()
+		m.stopLoop = nil
+	}
+	m.mu.Unlock()
+
+	// 排队中的任务保持 pending 状态，由新的 Leader 重新排队
+	m.queue.Clear()
+
+	m.taskContexts.Range(func(key, value interface{}) bool {
+		if cancel, ok := value.(context.CancelFunc); ok {
+			cancel()
+		}
+		return true
+	})
+
+	m.logger.Info("Task executor stopped")
+}
+
+// Events 返回任务事件发布器
+func (m *TaskManager) Events() *EventBroker {
+	return m.events
+}
+
+// IsLeader 当前副本是否负责执行任务
+func (m *TaskManager) IsLeader() bool {
+	return m.leader.Load()
+}
+
+// reconcileLoop 定期同步其他副本写入的任务变更，并清理过期的任务事件
+func (m *TaskManager) reconcileLoop(ctx context.Context) {
+	ticker := time.NewTicker(m.reconcileInterval)
+	defer ticker.Stop()
+	cleanup := time.NewTicker(eventCleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-cleanup.C:
+			if _, err := m.CleanupOldTaskEvents(ctx); err != nil {
+				m.logger.WithField("error", err).Warn("Failed to clean up old task events")
+			}
+		case <-ticker.C:
+			// 其他副本可能修改了并发限制，调高后排队的任务可以执行
+			if err := m.loadConcurrencyLimits(ctx); err != nil {
+				m.logger.WithField("error", err).Warn("Failed to reload concurrency limits")
+			} else {
+				m.dispatch()
+			}
+			m.adoptQueuedTasks(ctx)
+			m.syncCancelledTasks(ctx)
+		case <-ctx.Done():
+			return
+		}
 	}
 }
 
+// adoptQueuedTasks 接管 Follower 创建的待执行任务
+func (m *TaskManager) adoptQueuedTasks(ctx context.Context) {
+	tasks, err := m.repo.ListTasksByStatus(ctx, models.TaskPending)
+	if err != nil {
+		m.logger.WithField("error", err).Error("Failed to list queued tasks")
+		return
+	}
+
+	for _, task := range tasks {
+		// 正在本地排队或执行的任务不重复接管
+		if m.isTaskTracked(task.ID) {
+			continue
+		}
+
+		m.logger.WithField("taskId", task.ID).Info("Adopting queued task")
+		metrics.ActiveTasks.Inc()
+		m.enqueueTask(task)
+	}
+}
+
+// syncCancelledTasks 取消在其他副本上被取消的本地任务，并将其他副本上暂停的任务移出队列
+// 执行中的任务由批次调度器在下一批次前检查暂停状态
+func (m *TaskManager) syncCancelledTasks(ctx context.Context) {
+	for _, queued := range m.queue.List() {
+		task, err := m.repo.GetTask(ctx, queued.ID)
+		if err != nil || (task.Status != models.TaskCancelled && task.Status != models.TaskPaused) {
+			continue
+		}
+		if m.queue.Remove(queued.ID) {
+			m.logger.WithFields(logrus.Fields{
+				"taskId": queued.ID,
+				"status": task.Status,
+			}).Info("Task stopped by another replica, removed from queue")
+		}
+	}
+
+	m.taskContexts.Range(func(key, value interface{}) bool {
+		taskID := key.(string)
+		task, err := m.repo.GetTask(ctx, taskID)
+		if err != nil || task.Status != models.TaskCancelled {
+			return true
+		}
+
+		if cancel, ok := value.(context.CancelFunc); ok {
+			m.logger.WithField("taskId", taskID).Info("Task cancelled by another replica, stopping local execution")
+			cancel()
+		}
+		return true
+	})
+}
+
 // CreateTask 创建任务
 func (m *TaskManager) CreateTask(ctx context.Context, req *models.CreateTaskRequest) (*models.Task, error) {
+	// 可选：从工作负载解析镜像
+	req, err := m.resolveWorkloads(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
 	// 校验镜像数量
+	if len(req.Images) == 0 {
+		return nil, fmt.Errorf("%w: no images specified", ErrInvalidImage)
+	}
 	if len(req.Images) > 50 {
 		return nil, fmt.Errorf("too many images: max 50 images allowed per task")
 	}
 
+	refs, err := parseImages(req.Images)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ValidateNodeSelection(req.NodeSelector, req.NodeSelection); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateRollout(req.Rollout); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateSuccessPolicy(req.SuccessPolicy); err != nil {
+		return nil, err
+	}
+
+	// 可选：将 tag 固定为 digest
+	images := req.Images
+	var imageDigests map[string]string
+	if req.ResolveDigests {
+		images, imageDigests, err = m.resolveImageDigests(ctx, req, refs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// 生成任务ID
 	var taskID string
 	if req.ID != "" {
@@ -101,35 +339,49 @@ func (m *TaskManager) CreateTask(ctx context.Context, req *models.CreateTaskRequ
 		retryDelay = 30 // 默认30秒
 	}
 
+	batchMode, batchRatio, windowSize := batchSettings(req)
+
 	// 创建任务对象
 	task := &models.Task{
-		ID:            taskID,
-		Status:        models.TaskPending,
-		Priority:      priority,
-		Images:        req.Images,
-		BatchSize:     req.BatchSize,
-		NodeSelector:  req.NodeSelector,
-		MaxRetries:    req.MaxRetries,
-		RetryCount:    0,
-		RetryStrategy: retryStrategy,
-		RetryDelay:    retryDelay,
-		WebhookURL:    req.WebhookURL,
-		Registry:      req.Registry,
-		Username:      req.Username,
-		Password:      req.Password,
-		SecretID:      req.SecretID,
-		CreatedAt:     time.Now(),
+		ID:               taskID,
+		Status:           models.TaskPending,
+		Priority:         priority,
+		Images:           images,
+		ImageDigests:     imageDigests,
+		BatchSize:        req.BatchSize,
+		BatchMode:        batchMode,
+		BatchRatio:       batchRatio,
+		WindowSize:       windowSize,
+		PullParallelism:  req.PullParallelism,
+		ImagePullTimeout: req.ImagePullTimeout,
+		TimeoutSeconds:   req.TimeoutSeconds,
+		NodeSelector:     req.NodeSelector,
+		NodeSelection:    req.NodeSelection,
+		SkipPresent:      req.SkipPresent,
+		Rollout:          req.Rollout,
+		SuccessPolicy:    req.SuccessPolicy,
+		Workloads:        req.Workloads,
+		MaxRetries:       req.MaxRetries,
+		RetryCount:       0,
+		RetryStrategy:    retryStrategy,
+		RetryDelay:       retryDelay,
+		WebhookURL:       req.WebhookURL,
+		Registry:         req.Registry,
+		Username:         req.Username,
+		Password:         req.Password,
+		SecretID:         req.SecretID,
+		CreatedBy:        req.CreatedBy,
+		CreatedAt:        time.Now(),
 	}
 
 	// 保存任务
-	err := m.repo.CreateTask(ctx, task)
-	if err != nil {
+	if err := m.repo.CreateTask(ctx, task); err != nil {
 		return nil, fmt.Errorf("failed to create task: %w", err)
 	}
 
 	// 记录指标
 	metrics.TasksTotal.WithLabelValues(string(models.TaskPending)).Inc()
-	metrics.ActiveTasks.Inc()
+	m.events.publishStatus(task, "task created")
 
 	m.logger.WithFields(logrus.Fields{
 		"taskId":        task.ID,
@@ -140,129 +392,363 @@ func (m *TaskManager) CreateTask(ctx context.Context, req *models.CreateTaskRequ
 		"retryStrategy": task.RetryStrategy,
 	}).Info("Task created")
 
-	// 在后台执行任务
-	go func() {
-		// Create context and store it immediately so the task can be cancelled while pending
+	// Follower 只负责落库，任务由 Leader 接管执行
+	if !m.IsLeader() {
+		m.logger.WithField("taskId", task.ID).Info("Task queued for leader execution")
+		return task, nil
+	}
+
+	// 进入优先级队列等待执行
+	// 执行器使用任务副本，避免与返回给调用方的任务对象共享
+	metrics.ActiveTasks.Inc()
+	m.enqueueTask(task.Clone())
+
+	return task, nil
+}
+
+// RecoverTasks 恢复 apiserver 重启前未结束的任务
+// pending 状态的任务重新进入执行流程；running 状态的任务重新接管：
+// 已存在的 Job（通过 task-id 标签匹配）交由状态跟踪器继续跟踪，尚未提交的批次继续提交
+func (m *TaskManager) RecoverTasks(ctx context.Context) error {
+	tasks, err := m.repo.ListTasksByStatus(ctx, models.TaskPending, models.TaskRunning)
+	if err != nil {
+		return fmt.Errorf("failed to list unfinished tasks: %w", err)
+	}
+
+	recovered := 0
+	for _, task := range tasks {
+		// 已由当前进程接管的任务无需重复恢复
+		if m.isTaskTracked(task.ID) {
+			continue
+		}
+
+		m.logger.WithFields(logrus.Fields{
+			"taskId": task.ID,
+			"status": task.Status,
+		}).Info("Recovering unfinished task")
+
+		metrics.ActiveTasks.Inc()
+		m.enqueueTask(task)
+		recovered++
+	}
+
+	m.logger.WithField("recovered", recovered).Info("Task recovery finished")
+	return nil
+}
+
+// enqueueTask 将任务加入优先级队列并尝试调度
+func (m *TaskManager) enqueueTask(task *models.Task) {
+	if !m.queue.Contains(task.ID) {
+		m.queue.Enqueue(task)
+	}
+	m.dispatch()
+}
+
+// dispatch 在有空闲并发槽位时按优先级出队并执行任务
+// 创建者或节点选择器分组已达上限的任务留在队列中，不阻塞其他任务
+func (m *TaskManager) dispatch() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for m.running < m.limits.Global {
+		task := m.queue.DequeueFunc(m.canRunLocked)
+		if task == nil {
+			return
+		}
+
+		// Store the cancel func before the goroutine starts so the task can be cancelled right away
 		ctx, cancel := context.WithCancel(context.Background())
 		m.taskContexts.Store(task.ID, cancel)
+		m.acquireSlotLocked(task)
 
-		// Ensure cleanup happens when the goroutine exits
-		defer m.taskContexts.Delete(task.ID)
-		defer cancel()
-
-		// 如果提供了私有仓库凭证，创建 K8s Secret 存储凭据
-		// 使用 Secret + secretKeyRef 方式注入环境变量，避免明文暴露密码
-		var secretName string
-		if req.Registry != "" && req.Username != "" && req.Password != "" {
-			task.Username = req.Username
-			task.Password = req.Password
-			createdSecretName, err := m.batchScheduler.jobCreator.CreateCredsSecret(ctx, task.ID, req.Username, req.Password)
-			if err != nil {
-				m.logger.WithFields(logrus.Fields{
-					"taskId":   task.ID,
-					"registry": req.Registry,
-					"error":    err,
-				}).Error("Failed to create credentials secret")
-				_ = m.markTaskFailed(ctx, task, fmt.Errorf("failed to create credentials secret: %w", err), time.Now())
+		m.logger.WithFields(logrus.Fields{
+			"taskId":         task.ID,
+			"priority":       task.Priority,
+			"createdBy":      task.CreatedBy,
+			"running":        m.running,
+			"maxConcurrency": m.limits.Global,
+			"queued":         m.queue.Size(),
+		}).Info("Task acquired execution slot")
+
+		go m.runTask(ctx, cancel, task)
+	}
+}
+
+// isTaskTracked 任务是否已在本地排队或执行
+func (m *TaskManager) isTaskTracked(taskID string) bool {
+	if _, exists := m.taskContexts.Load(taskID); exists {
+		return true
+	}
+	return m.queue.Contains(taskID)
+}
+
+// runTask 执行已获得槽位的任务，结束后释放槽位
+// running 状态的任务为重启或切主后接管，从已提交的进度继续执行
+func (m *TaskManager) runTask(ctx context.Context, cancel context.CancelFunc, task *models.Task) {
+	defer func() {
+		m.mu.Lock()
+		m.releaseSlotLocked(task)
+		m.mu.Unlock()
+		m.dispatch()
+	}()
+	defer m.taskContexts.Delete(task.ID)
+	defer cancel()
+
+	// 如果提供了私有仓库凭证，创建 K8s Secret 存储凭据
+	// 使用 Secret + secretKeyRef 方式注入环境变量，避免明文暴露密码
+	secretName, err := m.prepareCredentials(ctx, task)
+	if err != nil {
+		_ = m.markTaskFailed(ctx, task, err, time.Now())
+		return
+	}
+
+	// 如果创建了 Secret，在任务结束时清理
+	// 暂停或 Leader 切换时已创建的 Job 仍在使用 Secret，保留到任务结束（恢复时复用）
+	if secretName != "" {
+		defer func() {
+			latest, err := m.repo.GetTask(context.Background(), task.ID)
+			if err == nil && !latest.Status.IsTerminal() {
 				return
 			}
-			secretName = createdSecretName
-			task.SecretName = secretName
+			m.deleteCredsSecret(task.ID, secretName)
+		}()
+	}
+
+	if ctx.Err() != nil || m.checkPaused(ctx, task) {
+		return
+	}
+
+	// 已确定目标节点的任务（重启恢复或执行出错后重试）只为尚未提交的节点补充 Job，
+	// 避免重新筛选节点并与已存在的 Job 重名
+	if task.Status == models.TaskRunning || len(task.TargetNodes) > 0 {
+		err = m.resumeTask(ctx, task)
+	} else {
+		err = m.executeTask(ctx, task)
+	}
+	if err != nil {
+		m.logger.WithFields(logrus.Fields{
+			"taskId": task.ID,
+			"error":  err,
+		}).Error("Task execution failed")
+	}
+}
+
+// deleteCredsSecret 删除任务的凭据 Secret，Secret 已被删除时忽略
+func (m *TaskManager) deleteCredsSecret(taskID, secretName string) {
+	err := m.batchScheduler.jobCreator.DeleteSecret(context.Background(), secretName)
+	if err != nil && !apierrors.IsNotFound(err) {
+		m.logger.WithFields(logrus.Fields{
+			"taskId":     taskID,
+			"secretName": secretName,
+			"error":      err,
+		}).Error("Failed to delete credentials secret during cleanup")
+		return
+	}
+	if err == nil {
+		m.logger.WithFields(logrus.Fields{
+			"taskId":     taskID,
+			"secretName": secretName,
+		}).Info("Deleted credentials secret during cleanup")
+	}
+}
+
+// prepareCredentials 根据任务中的认证信息创建凭据 Secret
+// 返回创建的 Secret 名称，未配置认证时返回空字符串
+func (m *TaskManager) prepareCredentials(ctx context.Context, task *models.Task) (string, error) {
+	if task.Registry != "" && task.Username != "" && task.Password != "" {
+		secretName, err := m.batchScheduler.jobCreator.CreateCredsSecret(ctx, task.ID, task.Username, task.Password)
+		if err != nil {
 			m.logger.WithFields(logrus.Fields{
-				"taskId":     task.ID,
-				"secretName": secretName,
-				"registry":   req.Registry,
-			}).Info("Created credentials secret for private registry authentication (manual credentials)")
-		} else if req.SecretID > 0 {
-			secretCreds, err := m.secretRepo.GetSecretCredentials(ctx, req.SecretID)
-			if err != nil {
-				m.logger.WithFields(logrus.Fields{
-					"taskId":   task.ID,
-					"secretId": req.SecretID,
-				}).Error("Failed to get secret credentials from database")
-				_ = m.markTaskFailed(ctx, task, fmt.Errorf("failed to get secret credentials: %w", err), time.Now())
-				return
-			}
+				"taskId":   task.ID,
+				"registry": task.Registry,
+				"error":    err,
+			}).Error("Failed to create credentials secret")
+			return "", fmt.Errorf("failed to create credentials secret: %w", err)
+		}
+		task.SecretName = secretName
+		m.logger.WithFields(logrus.Fields{
+			"taskId":     task.ID,
+			"secretName": secretName,
+			"registry":   task.Registry,
+		}).Info("Created credentials secret for private registry authentication (manual credentials)")
+		return secretName, nil
+	}
 
+	if task.SecretID > 0 {
+		secretCreds, err := m.secretRepo.GetSecretCredentials(ctx, task.SecretID)
+		if err != nil {
 			m.logger.WithFields(logrus.Fields{
-				"taskId":         task.ID,
-				"secretId":       req.SecretID,
-				"registry":       secretCreds.Registry,
-				"passwordLen":    len(secretCreds.Password),
-				"passwordPrefix": secretCreds.Password[:min(len(secretCreds.Password), 5)],
-			}).Info("Fetched saved credentials for private registry authentication")
-
-			task.Username = secretCreds.Username
-			task.Password = secretCreds.Password
-			createdSecretName, err := m.batchScheduler.jobCreator.CreateCredsSecret(ctx, task.ID, secretCreds.Username, secretCreds.Password)
-			if err != nil {
-				m.logger.WithFields(logrus.Fields{
-					"taskId":   task.ID,
-					"secretId": req.SecretID,
-					"registry": secretCreds.Registry,
-					"error":    err,
-				}).Error("Failed to create credentials secret")
-				_ = m.markTaskFailed(ctx, task, fmt.Errorf("failed to create credentials secret: %w", err), time.Now())
-				return
-			}
-			secretName = createdSecretName
-			task.SecretName = secretName
+				"taskId":   task.ID,
+				"secretId": task.SecretID,
+			}).Error("Failed to get secret credentials from database")
+			return "", fmt.Errorf("failed to get secret credentials: %w", err)
+		}
+
+		m.logger.WithFields(logrus.Fields{
+			"taskId":         task.ID,
+			"secretId":       task.SecretID,
+			"registry":       secretCreds.Registry,
+			"passwordLen":    len(secretCreds.Password),
+			"passwordPrefix": secretCreds.Password[:min(len(secretCreds.Password), 5)],
+		}).Info("Fetched saved credentials for private registry authentication")
+
+		task.Username = secretCreds.Username
+		task.Password = secretCreds.Password
+		secretName, err := m.batchScheduler.jobCreator.CreateCredsSecret(ctx, task.ID, secretCreds.Username, secretCreds.Password)
+		if err != nil {
 			m.logger.WithFields(logrus.Fields{
-				"taskId":     task.ID,
-				"secretName": secretName,
-				"secretId":   req.SecretID,
-				"registry":   secretCreds.Registry,
-			}).Info("Created credentials secret for private registry authentication (from saved credentials)")
+				"taskId":   task.ID,
+				"secretId": task.SecretID,
+				"registry": secretCreds.Registry,
+				"error":    err,
+			}).Error("Failed to create credentials secret")
+			return "", fmt.Errorf("failed to create credentials secret: %w", err)
 		}
+		task.SecretName = secretName
+		m.logger.WithFields(logrus.Fields{
+			"taskId":     task.ID,
+			"secretName": secretName,
+			"secretId":   task.SecretID,
+			"registry":   secretCreds.Registry,
+		}).Info("Created credentials secret for private registry authentication (from saved credentials)")
+		return secretName, nil
+	}
 
-		// 如果创建了 Secret，在任务结束时清理
-		if secretName != "" {
-			defer func() {
-				if err := m.batchScheduler.jobCreator.DeleteSecret(context.Background(), secretName); err != nil {
-					m.logger.WithFields(logrus.Fields{
-						"taskId":     task.ID,
-						"secretName": secretName,
-						"error":      err,
-					}).Error("Failed to delete credentials secret during cleanup")
-				} else {
-					m.logger.WithFields(logrus.Fields{
-						"taskId":     task.ID,
-						"secretName": secretName,
-					}).Info("Deleted credentials secret during cleanup")
-				}
-			}()
+	return "", nil
+}
+
+// resumeTask 接管重启前已开始执行的任务，或执行出错后重试的任务
+// 为尚未创建 Job 的目标节点继续提交批次，然后重新挂载状态跟踪器
+func (m *TaskManager) resumeTask(ctx context.Context, task *models.Task) error {
+	// 节点筛选结果未落库（重启发生在筛选之前），从头执行
+	if len(task.TargetNodes) == 0 || task.Progress == nil {
+		return m.executeTask(ctx, task)
+	}
+
+	startTime := time.Now()
+	if task.StartedAt != nil {
+		startTime = *task.StartedAt
+	}
+
+	if task.Status != models.TaskRunning {
+		task.Status = models.TaskRunning
+		if err := m.repo.UpdateTask(ctx, task); err != nil {
+			return fmt.Errorf("failed to update task: %w", err)
 		}
+		m.events.publishStatus(task, "task resumed")
+	}
 
-		// 等待获取并发槽位
-		// Use the cancellable context so we can abort if the task is cancelled while waiting
-		if err := m.concurrencySem.Acquire(ctx, 1); err != nil {
-			m.logger.WithFields(logrus.Fields{
-				"taskId": task.ID,
-				"error":  err,
-			}).Warn("Failed to acquire concurrency slot (task cancelled or timeout)")
-			return
+	jobs, err := m.batchScheduler.jobCreator.ListJobsByTaskID(ctx, task.ID)
+	if err != nil {
+		return m.markTaskFailed(ctx, task, fmt.Errorf("failed to list existing jobs: %w", err), startTime)
+	}
+
+	submitted := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		submitted[job.Labels["node"]] = true
+	}
+
+	// Job 不存在的节点：没有尝试记录的尚未提交；已记录结果的 Job 被 TTL 清理，不重新拉取；
+	// 尝试未结束的 Job 在暂停或切主期间被 TTL 清理，结果未知，以相同的尝试序号重新提交
+	var remaining, expired []string
+	for _, nodeName := range task.TargetNodes {
+		if submitted[nodeName] {
+			continue
+		}
+		attempts := task.NodeAttempts[nodeName]
+		switch {
+		case len(attempts) == 0:
+			remaining = append(remaining, nodeName)
+		case attempts[len(attempts)-1].FinishedAt == nil:
+			expired = append(expired, nodeName)
 		}
-		defer m.concurrencySem.Release(1)
+	}
 
-		m.logger.WithFields(logrus.Fields{
-			"taskId":         task.ID,
-			"maxConcurrency": m.maxConcurrency,
-		}).Info("Task acquired execution slot")
+	m.logger.WithFields(logrus.Fields{
+		"taskId":         task.ID,
+		"existingJobs":   len(jobs),
+		"expiredJobs":    len(expired),
+		"remainingNodes": len(remaining),
+	}).Info("Resuming task execution")
+
+	if len(expired) > 0 {
+		if err := m.resubmitExpiredJobs(ctx, task, expired); err != nil {
+			return m.markTaskFailed(ctx, task, err, startTime)
+		}
+	}
 
-		// Check strictly if context is already cancelled (redundant with Acquire but safe)
-		if ctx.Err() != nil {
-			return
+	if len(remaining) > 0 {
+		// 已完整提交的批次数，续提交的批次号在此基础上累加
+		submittedBatches := resumedBatches(task, len(task.TargetNodes)-len(remaining))
+		task.Progress.CurrentBatch = submittedBatches
+
+		err = m.batchScheduler.ExecuteBatches(
+			ctx,
+			task,
+			remaining,
+			func(batchNum, succeeded, failed int) {
+				m.logger.WithFields(logrus.Fields{
+					"taskId":    task.ID,
+					"batchNum":  submittedBatches + batchNum,
+					"succeeded": succeeded,
+					"failed":    failed,
+				}).Info("Batch submitted")
+				task.Progress.CurrentBatch = submittedBatches + batchNum
+				m.refreshProgress(ctx, task)
+				m.events.publishBatch(task, submittedBatches+batchNum, succeeded, failed)
+			},
+		)
+		if errors.Is(err, ErrTaskPaused) {
+			return m.stopPausedTask(task)
+		}
+		if errors.Is(err, ErrCanaryFailed) {
+			return m.handleCanaryFailure(ctx, task, startTime)
 		}
+		if err != nil {
+			return m.markTaskFailed(ctx, task, fmt.Errorf("batch execution failed: %w", err), startTime)
+		}
+	}
 
-		if err := m.executeTask(ctx, task); err != nil {
-			m.logger.WithFields(logrus.Fields{
-				"taskId": task.ID,
-				"error":  err,
-			}).Error("Task execution failed")
+	if err := m.statusTracker.TrackTask(ctx, task.ID); err != nil {
+		if errors.Is(err, ErrTaskPaused) {
+			return m.stopPausedTask(task)
 		}
-	}()
+		return m.markTaskFailed(ctx, task, fmt.Errorf("status tracking failed: %w", err), startTime)
+	}
 
-	return task, nil
+	m.logger.WithField("taskId", task.ID).Info("Task execution context finished")
+	return nil
+}
+
+// resumedBatches 返回已提交 submitted 个节点时已完整提交的批次数
+// 金丝雀节点全部提交后计为一个批次
+func resumedBatches(task *models.Task, submitted int) int {
+	if task.Canary == nil {
+		return submitted / task.BatchSize
+	}
+	if submitted < len(task.Canary.Nodes) {
+		return 0
+	}
+	return 1 + (submitted-len(task.Canary.Nodes))/task.BatchSize
+}
+
+// batchSettings 返回请求的批次执行模式、pipelined 完成比例与窗口大小，未指定时使用默认值
+func batchSettings(req *models.CreateTaskRequest) (batchMode string, batchRatio float64, windowSize int) {
+	batchMode = req.BatchMode
+	if batchMode == "" {
+		batchMode = models.BatchModeImmediate
+	}
+
+	batchRatio = req.BatchRatio
+	if batchMode == models.BatchModePipelined && batchRatio == 0 {
+		batchRatio = 1 // 默认等待前一批次全部结束
+	}
+
+	windowSize = req.WindowSize
+	if batchMode == models.BatchModeWindow && windowSize == 0 {
+		windowSize = req.BatchSize
+	}
+	return batchMode, batchRatio, windowSize
 }
 
 // executeTask 执行任务
@@ -273,7 +759,7 @@ func (m *TaskManager) executeTask(ctx context.Context, task *models.Task) error
 	startTime := time.Now()
 
 	// 1. 获取符合条件的节点
-	nodes, err := m.nodeFilter.FilterNodes(ctx, task.NodeSelector)
+	nodes, err := m.nodeFilter.FilterNodes(ctx, task.NodeSelector, task.NodeSelection)
 	if err != nil {
 		return m.markTaskFailed(ctx, task, fmt.Errorf("failed to filter nodes: %w", err), startTime)
 	}
@@ -287,12 +773,31 @@ func (m *TaskManager) executeTask(ctx context.Context, task *models.Task) error
 		"nodeCount": len(nodes),
 	}).Info("Nodes filtered")
 
-	// 2. 初始化进度
-	totalBatches, err := m.batchScheduler.CalculateBatches(len(nodes), task.BatchSize)
+	if err := m.resolveRequiredNodes(ctx, task, nodes); err != nil {
+		return m.markTaskFailed(ctx, task, err, startTime)
+	}
+
+	// 1.5 可选：跳过已存在全部镜像的节点
+	var skipped []string
+	if task.SkipPresent {
+		nodes, skipped = m.planNodes(ctx, task, nodes)
+	}
+
+	if len(nodes) == 0 {
+		return m.completeWithoutJobs(ctx, task, skipped)
+	}
+
+	// 2. 初始化进度（设置了灰度策略时金丝雀节点单独作为第一批次）
+	batchedNodes := initCanary(task, nodes)
+	totalBatches, err := m.batchScheduler.CalculateBatches(batchedNodes, task.BatchSize)
 	if err != nil {
 		return m.markTaskFailed(ctx, task, err, startTime)
 	}
+	if task.Canary != nil {
+		totalBatches++
+	}
 
+	task.TargetNodes = nodes
 	task.Progress = &models.Progress{
 		TotalNodes:     len(nodes),
 		CompletedNodes: 0,
@@ -300,7 +805,9 @@ func (m *TaskManager) executeTask(ctx context.Context, task *models.Task) error
 		CurrentBatch:   0,
 		TotalBatches:   totalBatches,
 		Percentage:     0,
+		SkippedNodes:   len(skipped),
 	}
+	m.recordSkippedNodes(task, skipped)
 
 	// Check if context is cancelled before starting
 	if ctx.Err() != nil {
@@ -317,15 +824,14 @@ func (m *TaskManager) executeTask(ctx context.Context, task *models.Task) error
 
 	// 记录任务状态变更指标
 	metrics.TasksTotal.WithLabelValues(string(models.TaskRunning)).Inc()
+	m.events.publishStatus(task, fmt.Sprintf("prewarming %d nodes in %d batches", len(nodes), totalBatches))
 
 	// 3. 执行批次调度 (创建所有 Job)
+	// task.SecretName 已在 prepareCredentials 中设置，Job 会通过 secretKeyRef 读取凭据
 	err = m.batchScheduler.ExecuteBatches(
 		ctx,
-		task.ID,
+		task,
 		nodes,
-		task.Images,
-		task.BatchSize,
-		task.SecretName, // 传递 Secret 名称，Job 会通过 secretKeyRef 读取凭据
 		func(batchNum, succeeded, failed int) {
 			m.logger.WithFields(logrus.Fields{
 				"taskId":    task.ID,
@@ -335,16 +841,26 @@ func (m *TaskManager) executeTask(ctx context.Context, task *models.Task) error
 			}).Info("Batch submitted")
 			// 更新批次数进度
 			task.Progress.CurrentBatch = batchNum
-			m.repo.UpdateTask(ctx, task)
+			m.refreshProgress(ctx, task)
+			m.events.publishBatch(task, batchNum, succeeded, failed)
 		},
 	)
 
+	if errors.Is(err, ErrTaskPaused) {
+		return m.stopPausedTask(task)
+	}
+	if errors.Is(err, ErrCanaryFailed) {
+		return m.handleCanaryFailure(ctx, task, startTime)
+	}
 	if err != nil {
 		return m.markTaskFailed(ctx, task, fmt.Errorf("batch execution failed: %w", err), startTime)
 	}
 
 	// 4. 同步等待状态跟踪器完成 (它会观察 Job 状态并上报最终结果)
 	err = m.statusTracker.TrackTask(ctx, task.ID)
+	if errors.Is(err, ErrTaskPaused) {
+		return m.stopPausedTask(task)
+	}
 	if err != nil {
 		return m.markTaskFailed(ctx, task, fmt.Errorf("status tracking failed: %w", err), startTime)
 	}
@@ -353,93 +869,133 @@ func (m *TaskManager) executeTask(ctx context.Context, task *models.Task) error
 	return nil
 }
 
-// markTaskFailed 标记任务失败或触发重试
-func (m *TaskManager) markTaskFailed(ctx context.Context, task *models.Task, err error, startTime time.Time) error {
-	// If the context is cancelled, it means the task was manually cancelled.
-	// We should NOT overwrite the Cancelled status with Failed or Pending.
-	if ctx.Err() != nil {
+// planNodes 根据节点上报的镜像列表（Node.Status.Images）拆分出需要预热的节点与可跳过的节点
+// 只有镜像都固定为 digest 时才跳过（tag 可能已指向新的镜像）；查询节点失败时不跳过任何节点
+func (m *TaskManager) planNodes(ctx context.Context, task *models.Task, nodes []string) ([]string, []string) {
+	if !k8s.ImageRefsPinned(task.Images) {
+		m.logger.WithField("taskId", task.ID).Info("Images are referenced by tag, not skipping nodes with images present")
+		return nodes, nil
+	}
+
+	warmed, err := m.nodeFilter.NodesWithImages(ctx, task.Images)
+	if err != nil {
 		m.logger.WithFields(logrus.Fields{
 			"taskId": task.ID,
 			"error":  err,
-		}).Info("Task context cancelled, skipping failure handling")
-		return ctx.Err()
+		}).Warn("Failed to check images present on nodes, prewarming all nodes")
+		return nodes, nil
 	}
 
-	// 检查是否可以重试
-	if task.RetryCount < task.MaxRetries {
-		// 增加重试计数
-		task.RetryCount++
-
-		// 获取重试策略
-		retryStrategy := GetRetryStrategy(task.RetryStrategy, m.logger)
-		delay := retryStrategy.CalculateDelay(task.RetryCount, task.RetryDelay)
+	var pending, skipped []string
+	for _, nodeName := range nodes {
+		if warmed[nodeName] {
+			skipped = append(skipped, nodeName)
+		} else {
+			pending = append(pending, nodeName)
+		}
+	}
 
+	if len(skipped) > 0 {
 		m.logger.WithFields(logrus.Fields{
-			"taskId":     task.ID,
-			"retryCount": task.RetryCount,
-			"maxRetries": task.MaxRetries,
-			"delay":      delay.Seconds(),
-			"error":      err,
-		}).Info("Task failed, scheduling retry")
+			"taskId":       task.ID,
+			"skippedNodes": len(skipped),
+			"pendingNodes": len(pending),
+		}).Info("Skipping nodes with all images present")
+	}
+	return pending, skipped
+}
 
-		// 更新任务状态为 pending 以准备重试
-		task.Status = models.TaskPending
-		task.FinishedAt = nil // 清除完成时间
+// recordSkippedNodes 为跳过的节点记录结果，所有镜像标记为 already_present
+func (m *TaskManager) recordSkippedNodes(task *models.Task, skipped []string) {
+	if len(skipped) == 0 {
+		return
+	}
+	if task.NodeResults == nil {
+		task.NodeResults = make(map[string]*models.NodeResult)
+	}
 
-		if updateErr := m.repo.UpdateTask(ctx, task); updateErr != nil {
-			m.logger.WithFields(logrus.Fields{
-				"taskId": task.ID,
-				"error":  updateErr,
-			}).Error("Failed to update task for retry")
+	now := time.Now()
+	for _, nodeName := range skipped {
+		images := make([]models.ImagePullResult, 0, len(task.Images))
+		for _, image := range task.Images {
+			images = append(images, models.ImagePullResult{
+				Image:  image,
+				Status: models.ImagePullAlreadyPresent,
+			})
 		}
+		task.NodeResults[nodeName] = &models.NodeResult{
+			NodeName:  nodeName,
+			Status:    models.NodeResultSkipped,
+			Message:   "all images already present",
+			Images:    images,
+			UpdatedAt: now,
+		}
+	}
+}
 
-		// 等待重试延迟后重新执行任务
-		go func() {
-			time.Sleep(delay)
-
-			m.logger.WithFields(logrus.Fields{
-				"taskId":     task.ID,
-				"retryCount": task.RetryCount,
-			}).Info("Retrying task")
+// completeWithoutJobs 所有节点均已存在全部镜像，不创建 Job 直接完成任务
+func (m *TaskManager) completeWithoutJobs(ctx context.Context, task *models.Task, skipped []string) error {
+	now := time.Now()
+	task.Status = models.TaskCompleted
+	task.StartedAt = &now
+	task.FinishedAt = &now
+	task.Progress = &models.Progress{
+		SkippedNodes: len(skipped),
+		Percentage:   100,
+	}
+	m.recordSkippedNodes(task, skipped)
 
-			// 重新获取任务确保获取最新状态
-			latestTask, getErr := m.repo.GetTask(context.Background(), task.ID)
-			if getErr != nil {
-				m.logger.WithFields(logrus.Fields{
-					"taskId": task.ID,
-					"error":  getErr,
-				}).Error("Failed to get task for retry")
-				return
-			}
+	if err := m.repo.UpdateTask(ctx, task); err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
 
-			// 检查任务是否被取消
-			if latestTask.Status == models.TaskCancelled {
-				m.logger.WithField("taskId", task.ID).Info("Task was cancelled, skipping retry")
-				return
-			}
+	metrics.TasksTotal.WithLabelValues(string(models.TaskCompleted)).Inc()
+	metrics.TaskDuration.WithLabelValues(string(models.TaskCompleted)).Observe(time.Since(task.CreatedAt).Seconds())
+	metrics.ActiveTasks.Dec()
+	m.events.publishStatus(task, "all nodes already have the images")
 
-			// 重新执行任务
-			ctx, cancel := context.WithCancel(context.Background())
-			m.taskContexts.Store(task.ID, cancel)
-			defer m.taskContexts.Delete(task.ID)
+	m.logger.WithFields(logrus.Fields{
+		"taskId":       task.ID,
+		"skippedNodes": len(skipped),
+	}).Info("All nodes already have the images, task completed without jobs")
+	return nil
+}
 
-			if execErr := m.executeTask(ctx, latestTask); execErr != nil {
-				m.logger.WithFields(logrus.Fields{
-					"taskId": task.ID,
-					"error":  execErr,
-				}).Error("Task retry failed")
-			}
-		}()
+// refreshProgress 提交批次后保存进度
+// pipelined/window 模式下提交过程持续较长，同时汇总已结束的节点，避免进度长时间停留在 0
+// 最后一批次提交后由状态跟踪器接手，避免在此处结束任务
+// 执行期间写入的 paused 由仓库保留，不会被此处保存的 running 覆盖
+func (m *TaskManager) refreshProgress(ctx context.Context, task *models.Task) {
+	gated := task.BatchMode == models.BatchModePipelined || task.BatchMode == models.BatchModeWindow
+	if gated && task.Progress.CurrentBatch < task.Progress.TotalBatches {
+		// 汇总已结束的节点；没有 Job 时 updateTaskStatus 不会保存，统一在下方保存
+		if err := m.statusTracker.updateTaskStatus(ctx, task); err != nil {
+			m.logger.WithFields(logrus.Fields{
+				"taskId": task.ID,
+				"error":  err,
+			}).Warn("Failed to refresh task progress")
+		}
+	}
+	m.repo.UpdateTask(ctx, task)
+}
 
-		return err
+// markTaskFailed 标记任务失败
+// 重试只针对失败的节点（MaxRetries 为每个节点的重试次数），任务级错误不再整体重试
+func (m *TaskManager) markTaskFailed(ctx context.Context, task *models.Task, err error, startTime time.Time) error {
+	// If the context is cancelled, it means the task was manually cancelled.
+	// We should NOT overwrite the Cancelled status with Failed or Pending.
+	if ctx.Err() != nil {
+		m.logger.WithFields(logrus.Fields{
+			"taskId": task.ID,
+			"error":  err,
+		}).Info("Task context cancelled, skipping failure handling")
+		return ctx.Err()
 	}
 
-	// 达到最大重试次数，标记为失败
 	m.logger.WithFields(logrus.Fields{
-		"taskId":     task.ID,
-		"retryCount": task.RetryCount,
-		"error":      err,
-	}).Error("Task failed after max retries")
+		"taskId": task.ID,
+		"error":  err,
+	}).Error("Task failed")
 
 	task.Status = models.TaskFailed
 	now := time.Now()
@@ -451,6 +1007,7 @@ func (m *TaskManager) markTaskFailed(ctx context.Context, task *models.Task, err
 			"error":  updateErr,
 		}).Error("Failed to update task status to failed")
 	}
+	m.events.publishStatus(task, err.Error())
 
 	// 记录任务失败指标
 	duration := time.Since(startTime).Seconds()
@@ -470,8 +1027,135 @@ func (m *TaskManager) markTaskFailed(ctx context.Context, task *models.Task, err
 }
 
 // GetTask 获取任务
+// pending 状态的任务附带当前排队位置
 func (m *TaskManager) GetTask(ctx context.Context, id string) (*models.Task, error) {
-	return m.repo.GetTask(ctx, id)
+	task, err := m.repo.GetTask(ctx, id)
+	if err != nil || task.Status != models.TaskPending {
+		return task, err
+	}
+
+	result := *task
+	result.QueuePosition = m.queuePosition(ctx, id)
+	return &result, nil
+}
+
+// SetEventRetention 设置任务事件的保留时间
+func (m *TaskManager) SetEventRetention(retention time.Duration) {
+	if retention > 0 {
+		m.eventRetention = retention
+	}
+}
+
+// CleanupOldTaskEvents 清理超过保留时间的任务事件
+// 任务记录删除时其事件一并删除，这里限制长期保留的任务的事件数量
+func (m *TaskManager) CleanupOldTaskEvents(ctx context.Context) (int64, error) {
+	if m.eventRepo == nil {
+		return 0, nil
+	}
+
+	before := time.Now().Add(-m.eventRetention)
+	deleted, err := m.eventRepo.DeleteOldTaskEvents(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old task events: %w", err)
+	}
+	if deleted > 0 {
+		m.logger.WithFields(logrus.Fields{
+			"deleted": deleted,
+			"before":  before,
+		}).Info("Cleaned up old task events")
+	}
+	return deleted, nil
+}
+
+// GetTaskTimeline 获取任务的事件时间线
+func (m *TaskManager) GetTaskTimeline(ctx context.Context, taskID string) ([]*models.TaskEvent, error) {
+	if _, err := m.repo.GetTask(ctx, taskID); err != nil {
+		return nil, err
+	}
+	if m.eventRepo == nil {
+		return []*models.TaskEvent{}, nil
+	}
+
+	m.events.Flush()
+	events, err := m.eventRepo.ListTaskEvents(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task events: %w", err)
+	}
+	return events, nil
+}
+
+// GetNodeResult 获取任务在指定节点上的预热结果
+// 目标节点尚未产生结果时返回 pending 状态
+func (m *TaskManager) GetNodeResult(ctx context.Context, taskID, nodeName string) (*models.NodeResult, error) {
+	task, err := m.repo.GetTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if result, ok := task.NodeResults[nodeName]; ok {
+		return result, nil
+	}
+
+	for _, node := range task.TargetNodes {
+		if node == nodeName {
+			return &models.NodeResult{
+				NodeName: nodeName,
+				Status:   models.NodeResultPending,
+			}, nil
+		}
+	}
+
+	return nil, ErrNodeNotInTask
+}
+
+// GetNodeLogs 获取节点最近一次失败时采集的 puller 日志
+func (m *TaskManager) GetNodeLogs(ctx context.Context, taskID, nodeName string) (*models.NodeLog, error) {
+	task, err := m.repo.GetTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeLog, err := m.repo.GetNodeLog(ctx, taskID, nodeName)
+	if err == nil {
+		return nodeLog, nil
+	}
+	if !errors.Is(err, repository.ErrNodeLogNotFound) {
+		return nil, fmt.Errorf("failed to get node logs: %w", err)
+	}
+
+	for _, node := range task.TargetNodes {
+		if node == nodeName {
+			return nil, ErrNodeLogsNotFound
+		}
+	}
+	if _, ok := task.NodeResults[nodeName]; ok {
+		return nil, ErrNodeLogsNotFound
+	}
+
+	return nil, ErrNodeNotInTask
+}
+
+// queuePosition 返回任务的排队位置（从 1 开始），不在队列中时返回 0
+// Follower 没有本地队列，按数据库中的 pending 任务以相同规则排序估算
+func (m *TaskManager) queuePosition(ctx context.Context, id string) int {
+	if m.IsLeader() {
+		return m.queue.Position(id)
+	}
+
+	tasks, err := m.repo.ListTasksByStatus(ctx, models.TaskPending)
+	if err != nil {
+		m.logger.WithFields(logrus.Fields{
+			"taskId": id,
+			"error":  err,
+		}).Warn("Failed to list pending tasks for queue position")
+		return 0
+	}
+
+	pq := NewPriorityQueueWithAging(m.queue.agingInterval)
+	for _, task := range tasks {
+		pq.Enqueue(task)
+	}
+	return pq.Position(id)
 }
 
 // ListTasks 列出任务
@@ -496,6 +1180,8 @@ func (m *TaskManager) DeleteTask(ctx context.Context, id string) (string, error)
 	// 检查任务状态
 	// 如果是终止状态，直接删除记录
 	if task.Status == models.TaskCompleted || task.Status == models.TaskFailed || task.Status == models.TaskCancelled {
+		// 先写入尚在队列中的事件，随任务记录一起删除
+		m.events.Flush()
 		if err := m.repo.DeleteTask(ctx, id); err != nil {
 			return "", fmt.Errorf("failed to delete task record: %w", err)
 		}
@@ -504,7 +1190,8 @@ func (m *TaskManager) DeleteTask(ctx context.Context, id string) (string, error)
 	}
 
 	// 如果是运行状态，执行取消逻辑
-	// 取消任务的上下文
+	// 从队列中移除并取消任务的上下文
+	m.queue.Remove(id)
 	if cancelFunc, ok := m.taskContexts.Load(id); ok {
 		if cancel, ok := cancelFunc.(context.CancelFunc); ok {
 			cancel()
@@ -512,6 +1199,7 @@ func (m *TaskManager) DeleteTask(ctx context.Context, id string) (string, error)
 	}
 
 	// 更新任务状态
+	previousStatus := task.Status
 	task.Status = models.TaskCancelled
 	now := time.Now()
 	task.FinishedAt = &now
@@ -527,7 +1215,16 @@ func (m *TaskManager) DeleteTask(ctx context.Context, id string) (string, error)
 		metrics.TaskDuration.WithLabelValues(string(models.TaskCancelled)).Observe(duration)
 	}
 	metrics.TasksTotal.WithLabelValues(string(models.TaskCancelled)).Inc()
-	metrics.ActiveTasks.Dec()
+	if previousStatus != models.TaskPaused {
+		// 暂停时已从活跃任务中扣除
+		metrics.ActiveTasks.Dec()
+	}
+	m.events.publishStatus(task, "task cancelled")
+
+	// 暂停的任务没有执行器，执行中任务的执行器可能在状态写入前退出，由此处清理凭据 Secret
+	if secretName := credsSecretName(task); secretName != "" {
+		m.deleteCredsSecret(id, secretName)
+	}
 
 	// 发送 Webhook 通知
 	if webhookErr := m.webhookNotifier.NotifyTaskCancelled(ctx, task); webhookErr != nil {
@@ -0,0 +1,404 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kitsnail/ips/internal/k8s"
+	"github.com/kitsnail/ips/internal/repository"
+	"github.com/kitsnail/ips/pkg/models"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newReadyNode(name string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+}
+
+func setupTaskManager(t *testing.T, objects ...runtime.Object) (*TaskManager, *repository.MemoryRepository, *k8s.Client) {
+	t.Helper()
+
+	k8sClient := &k8s.Client{
+		Clientset: fake.NewSimpleClientset(objects...),
+		Namespace: "default",
+	}
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	repo := repository.NewMemoryRepository()
+	jobCreator := k8s.NewJobCreator(k8sClient, "", "", "")
+	taskManager := NewTaskManager(
+		repo,
+		repo,
+		repo,
+		repo,
+		NewNodeFilter(k8sClient),
+		NewBatchScheduler(jobCreator, logger),
+		NewStatusTracker(repo, jobCreator, logger),
+		logger,
+	)
+
+	return taskManager, repo, k8sClient
+}
+
+// waitForJobs 等待指定任务的 Job 数量达到期望值
+func waitForJobs(t *testing.T, k8sClient *k8s.Client, taskID string, want int) []batchv1.Job {
+	t.Helper()
+
+	var jobs []batchv1.Job
+	require.Eventually(t, func() bool {
+		list, err := k8sClient.Clientset.BatchV1().Jobs("default").List(context.Background(), metav1.ListOptions{
+			LabelSelector: "task-id=" + taskID,
+		})
+		if err != nil {
+			return false
+		}
+		jobs = list.Items
+		return len(jobs) == want
+	}, 5*time.Second, 50*time.Millisecond)
+
+	return jobs
+}
+
+func TestTaskManager_RecoverTasks_ResumesUnsubmittedNodes(t *testing.T) {
+	existingJob := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "prewarm-task-running-node-1",
+			Namespace: "default",
+			Labels: map[string]string{
+				"app":     "image-prewarm",
+				"task-id": "task-running",
+				"node":    "node-1",
+			},
+		},
+	}
+	manager, repo, k8sClient := setupTaskManager(t, newReadyNode("node-1"), newReadyNode("node-2"), existingJob)
+	ctx := context.Background()
+
+	startedAt := time.Now().Add(-time.Minute)
+	task := &models.Task{
+		ID:          "task-running",
+		Status:      models.TaskRunning,
+		Images:      []string{"nginx:latest"},
+		BatchSize:   1,
+		TargetNodes: []string{"node-1", "node-2"},
+		Progress: &models.Progress{
+			TotalNodes:   2,
+			CurrentBatch: 1,
+			TotalBatches: 2,
+		},
+		CreatedAt: startedAt,
+		StartedAt: &startedAt,
+	}
+	require.NoError(t, repo.CreateTask(ctx, task))
+
+	require.NoError(t, manager.RecoverTasks(ctx))
+
+	// 只为尚未提交的 node-2 创建 Job，node-1 的 Job 保持不变
+	jobs := waitForJobs(t, k8sClient, task.ID, 2)
+	nodes := map[string]bool{}
+	for _, job := range jobs {
+		nodes[job.Labels["node"]] = true
+	}
+	assert.True(t, nodes["node-1"])
+	assert.True(t, nodes["node-2"])
+
+	_, err := manager.DeleteTask(ctx, task.ID)
+	require.NoError(t, err)
+}
+
+func TestTaskManager_RecoverTasks_RestartsPendingTask(t *testing.T) {
+	manager, repo, k8sClient := setupTaskManager(t, newReadyNode("node-1"), newReadyNode("node-2"))
+	ctx := context.Background()
+
+	task := &models.Task{
+		ID:        "task-pending",
+		Status:    models.TaskPending,
+		Images:    []string{"nginx:latest"},
+		BatchSize: 10,
+		CreatedAt: time.Now(),
+	}
+	require.NoError(t, repo.CreateTask(ctx, task))
+
+	require.NoError(t, manager.RecoverTasks(ctx))
+
+	waitForJobs(t, k8sClient, task.ID, 2)
+
+	recovered, err := repo.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"node-1", "node-2"}, recovered.TargetNodes)
+
+	_, err = manager.DeleteTask(ctx, task.ID)
+	require.NoError(t, err)
+}
+
+func TestTaskManager_RecoverTasks_SkipsFinishedTasks(t *testing.T) {
+	manager, repo, k8sClient := setupTaskManager(t, newReadyNode("node-1"))
+	ctx := context.Background()
+
+	task := &models.Task{
+		ID:        "task-done",
+		Status:    models.TaskCompleted,
+		Images:    []string{"nginx:latest"},
+		BatchSize: 10,
+		CreatedAt: time.Now(),
+	}
+	require.NoError(t, repo.CreateTask(ctx, task))
+
+	require.NoError(t, manager.RecoverTasks(ctx))
+
+	time.Sleep(100 * time.Millisecond)
+	list, err := k8sClient.Clientset.BatchV1().Jobs("default").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, list.Items)
+}
+
+func TestTaskManager_FollowerQueuesTaskForLeader(t *testing.T) {
+	taskManager, repo, k8sClient := setupTaskManager(t, newReadyNode("node-1"))
+	taskManager.reconcileInterval = 50 * time.Millisecond
+
+	task, err := taskManager.CreateTask(context.Background(), &models.CreateTaskRequest{
+		Images:    []string{"nginx:latest"},
+		BatchSize: 10,
+	})
+	require.NoError(t, err)
+
+	// Follower 只落库，不创建 Job
+	time.Sleep(200 * time.Millisecond)
+	jobs, err := k8sClient.Clientset.BatchV1().Jobs("default").List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, jobs.Items)
+
+	stored, err := repo.GetTask(context.Background(), task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TaskPending, stored.Status)
+
+	// 成为 Leader 后接管排队的任务
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	taskManager.Start(ctx)
+	defer taskManager.Stop()
+
+	waitForJobs(t, k8sClient, task.ID, 1)
+	assert.True(t, taskManager.IsLeader())
+}
+
+func TestTaskManager_StopReleasesLeadership(t *testing.T) {
+	taskManager, _, _ := setupTaskManager(t)
+
+	taskManager.Start(context.Background())
+	assert.True(t, taskManager.IsLeader())
+
+	taskManager.Stop()
+	assert.False(t, taskManager.IsLeader())
+}
+
+func TestTaskManager_DispatchByPriority(t *testing.T) {
+	taskManager, _, k8sClient := setupTaskManager(t, newReadyNode("node-1"))
+	taskManager.leader.Store(true)
+	// 暂不分配槽位，任务全部排队
+	taskManager.limits.Global = 0
+
+	ctx := context.Background()
+	low, err := taskManager.CreateTask(ctx, &models.CreateTaskRequest{Images: []string{"nginx:latest"}, BatchSize: 10, Priority: 2})
+	require.NoError(t, err)
+	high, err := taskManager.CreateTask(ctx, &models.CreateTaskRequest{Images: []string{"nginx:latest"}, BatchSize: 10, Priority: 9})
+	require.NoError(t, err)
+	medium, err := taskManager.CreateTask(ctx, &models.CreateTaskRequest{Images: []string{"nginx:latest"}, BatchSize: 10, Priority: 5})
+	require.NoError(t, err)
+
+	for id, want := range map[string]int{high.ID: 1, medium.ID: 2, low.ID: 3} {
+		task, err := taskManager.GetTask(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, want, task.QueuePosition, "task %s", id)
+	}
+
+	// 释放一个槽位，最高优先级的任务先执行
+	taskManager.mu.Lock()
+	taskManager.limits.Global = 1
+	taskManager.mu.Unlock()
+	taskManager.dispatch()
+
+	waitForJobs(t, k8sClient, high.ID, 1)
+	task, err := taskManager.GetTask(ctx, medium.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, task.QueuePosition)
+
+	// 取消排队中的任务后从队列移除
+	_, err = taskManager.DeleteTask(ctx, medium.ID)
+	require.NoError(t, err)
+	task, err = taskManager.GetTask(ctx, low.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, task.QueuePosition)
+}
+
+func TestTaskManager_ConcurrencyLimitsPerCreator(t *testing.T) {
+	taskManager, repo, k8sClient := setupTaskManager(t, newReadyNode("node-1"))
+	taskManager.leader.Store(true)
+
+	ctx := context.Background()
+	require.NoError(t, taskManager.UpdateConcurrencyLimits(ctx, models.ConcurrencyLimits{
+		Global:           3,
+		PerCreator:       1,
+		CreatorOverrides: map[string]int{"vip": 2},
+	}))
+
+	// 已持久化，新实例加载同样的限制
+	stored, err := repo.GetSetting(ctx, concurrencyLimitsKey)
+	require.NoError(t, err)
+	assert.Contains(t, stored, `"perCreator":1`)
+
+	teamA1, err := taskManager.CreateTask(ctx, &models.CreateTaskRequest{Images: []string{"nginx:latest"}, BatchSize: 10, CreatedBy: "team-a"})
+	require.NoError(t, err)
+	teamA2, err := taskManager.CreateTask(ctx, &models.CreateTaskRequest{Images: []string{"nginx:latest"}, BatchSize: 10, Priority: 9, CreatedBy: "team-a"})
+	require.NoError(t, err)
+	teamB, err := taskManager.CreateTask(ctx, &models.CreateTaskRequest{Images: []string{"nginx:latest"}, BatchSize: 10, CreatedBy: "team-b"})
+	require.NoError(t, err)
+
+	waitForJobs(t, k8sClient, teamA1.ID, 1)
+	waitForJobs(t, k8sClient, teamB.ID, 1)
+
+	// team-a 已占满自己的配额，高优先级任务也需排队，且不阻塞 team-b
+	task, err := taskManager.GetTask(ctx, teamA2.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TaskPending, task.Status)
+	assert.Equal(t, 1, task.QueuePosition)
+
+	stats, err := taskManager.ConcurrencyStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Limits.Global)
+	assert.Equal(t, 1, stats.Queued)
+	assert.Equal(t, 2, stats.Running)
+	assert.Equal(t, map[string]int{"team-a": 1, "team-b": 1}, stats.RunningByCreator)
+	assert.Equal(t, map[string]int{models.AllNodesGroup: 2}, stats.RunningByNodeSelectorGroup)
+
+	summary := taskManager.ConcurrencySummary(ctx)
+	require.NotNil(t, summary)
+	assert.Equal(t, models.ConcurrencySummary{GlobalLimit: 3, Running: stats.Running, Queued: 1}, *summary)
+
+	// 调高 team-a 的限制后立即调度
+	require.NoError(t, taskManager.UpdateConcurrencyLimits(ctx, models.ConcurrencyLimits{
+		Global:           3,
+		PerCreator:       1,
+		CreatorOverrides: map[string]int{"team-a": 2},
+	}))
+	waitForJobs(t, k8sClient, teamA2.ID, 1)
+
+	assert.Error(t, taskManager.UpdateConcurrencyLimits(ctx, models.ConcurrencyLimits{Global: 0}))
+}
+
+func TestTaskManager_SkipPresentNodes(t *testing.T) {
+	nginxDigest := "sha256:" + strings.Repeat("a", 64)
+	appDigest := "sha256:" + strings.Repeat("c", 64)
+	warmed := newReadyNode("node-warm")
+	warmed.Status.Images = []corev1.ContainerImage{
+		{Names: []string{"docker.io/library/nginx@" + nginxDigest, "docker.io/library/nginx:latest"}},
+		{Names: []string{"registry.example.com/team/app@" + appDigest, "registry.example.com/team/app:v1"}},
+	}
+	partial := newReadyNode("node-partial")
+	partial.Status.Images = []corev1.ContainerImage{
+		{Names: []string{"docker.io/library/nginx@" + nginxDigest}},
+	}
+	taskManager, repo, k8sClient := setupTaskManager(t, warmed, partial, newReadyNode("node-cold"))
+	taskManager.leader.Store(true)
+
+	ctx := context.Background()
+	task, err := taskManager.CreateTask(ctx, &models.CreateTaskRequest{
+		Images:      []string{"nginx@" + nginxDigest, "registry.example.com/team/app:v1@" + appDigest},
+		BatchSize:   10,
+		SkipPresent: true,
+	})
+	require.NoError(t, err)
+
+	// 已完整预热的节点不创建 Job
+	jobs := waitForJobs(t, k8sClient, task.ID, 2)
+	for _, job := range jobs {
+		assert.NotEqual(t, "node-warm", job.Labels["node"])
+	}
+
+	require.Eventually(t, func() bool {
+		stored, err := repo.GetTask(ctx, task.ID)
+		return err == nil && stored.Progress != nil && stored.Progress.TotalNodes == 2
+	}, 5*time.Second, 50*time.Millisecond)
+
+	stored, err := taskManager.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.Progress.SkippedNodes)
+	assert.ElementsMatch(t, []string{"node-partial", "node-cold"}, stored.TargetNodes)
+	require.Contains(t, stored.NodeResults, "node-warm")
+	assert.Equal(t, models.NodeResultSkipped, stored.NodeResults["node-warm"].Status)
+	assert.Equal(t, models.ImagePullAlreadyPresent, stored.NodeResults["node-warm"].Images[0].Status)
+
+	_, err = taskManager.DeleteTask(ctx, task.ID)
+	require.NoError(t, err)
+}
+
+func TestTaskManager_SkipPresentNodes_AllWarmed(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	warmed := newReadyNode("node-1")
+	warmed.Status.Images = []corev1.ContainerImage{
+		{Names: []string{"docker.io/library/nginx@" + digest}},
+	}
+	taskManager, repo, k8sClient := setupTaskManager(t, warmed)
+	taskManager.leader.Store(true)
+
+	ctx := context.Background()
+	task, err := taskManager.CreateTask(ctx, &models.CreateTaskRequest{
+		Images:      []string{"nginx@" + digest},
+		BatchSize:   10,
+		SkipPresent: true,
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		stored, err := repo.GetTask(ctx, task.ID)
+		return err == nil && stored.Status == models.TaskCompleted
+	}, 5*time.Second, 50*time.Millisecond)
+
+	stored, err := repo.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.Progress.SkippedNodes)
+	assert.Equal(t, float64(100), stored.Progress.Percentage)
+
+	jobs, err := k8sClient.Clientset.BatchV1().Jobs("default").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, jobs.Items)
+}
+
+func TestTaskManager_SkipPresentNodes_TagNotSkipped(t *testing.T) {
+	warmed := newReadyNode("node-1")
+	warmed.Status.Images = []corev1.ContainerImage{
+		{Names: []string{"docker.io/library/nginx:latest"}},
+	}
+	taskManager, _, k8sClient := setupTaskManager(t, warmed)
+	taskManager.leader.Store(true)
+
+	// tag 可能已指向新的镜像，节点上存在同名 tag 时仍然预热
+	ctx := context.Background()
+	task, err := taskManager.CreateTask(ctx, &models.CreateTaskRequest{
+		Images:      []string{"nginx:latest"},
+		BatchSize:   10,
+		SkipPresent: true,
+	})
+	require.NoError(t, err)
+	waitForJobs(t, k8sClient, task.ID, 1)
+
+	_, err = taskManager.DeleteTask(ctx, task.ID)
+	require.NoError(t, err)
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kitsnail/ips/pkg/metrics"
+	"github.com/kitsnail/ips/pkg/models"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// PauseTask 暂停排队中或执行中的任务
+// 执行中的任务在当前批次提交完成后停止创建新的 Job（包括节点重试），已创建的 Job 继续执行
+// 暂停状态持久化在数据库中，服务重启后不会自动恢复执行
+// 暂停通过条件更新写入状态，执行器随后保存的 pending/running 不会覆盖 paused
+// 私有仓库凭据 Secret 保留到任务结束，暂停期间仍在运行的 Job 可以继续读取
+func (m *TaskManager) PauseTask(ctx context.Context, id string) (*models.Task, error) {
+	task, err := m.repo.GetTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	previousStatus := task.Status
+
+	paused, err := m.repo.UpdateTaskStatus(ctx, id, models.TaskPaused, models.TaskPending, models.TaskRunning)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update task status to paused: %w", err)
+	}
+	if !paused {
+		// 读取后状态可能已被执行器或其他请求改变，返回最新状态
+		if latest, err := m.repo.GetTask(ctx, id); err == nil {
+			task = latest
+		}
+		return nil, fmt.Errorf("%w: task is %s", ErrTaskNotPausable, task.Status)
+	}
+	task.Status = models.TaskPaused
+
+	// 排队中的任务直接移出队列；执行中的任务由批次调度器在下一批次前检查暂停状态
+	m.queue.Remove(id)
+
+	metrics.ActiveTasks.Dec()
+	m.events.publishStatus(task, "task paused")
+
+	m.logger.WithFields(logrus.Fields{
+		"taskId":         id,
+		"previousStatus": previousStatus,
+		"currentBatch":   submittedBatches(task),
+	}).Info("Task paused")
+	return task, nil
+}
+
+// ResumeTask 恢复已暂停的任务
+// 任务重新排队，获得执行槽位后为尚未提交的节点从 Progress.CurrentBatch 继续提交批次
+// 暂停期间被 TTL 清理且尚未记录结果的 Job 以原尝试序号重新提交
+func (m *TaskManager) ResumeTask(ctx context.Context, id string) (*models.Task, error) {
+	resumed, err := m.repo.UpdateTaskStatus(ctx, id, models.TaskPending, models.TaskPaused)
+	if err != nil {
+		return nil, err
+	}
+	task, err := m.repo.GetTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !resumed {
+		return nil, fmt.Errorf("%w: task is %s", ErrTaskNotPaused, task.Status)
+	}
+
+	metrics.ActiveTasks.Inc()
+	m.events.publishStatus(task, "task resumed, waiting for execution slot")
+
+	m.logger.WithFields(logrus.Fields{
+		"taskId":       id,
+		"currentBatch": submittedBatches(task),
+	}).Info("Task resume requested")
+
+	// Follower 上恢复的任务由 Leader 接管；执行器尚未退出的任务由其自行继续
+	if m.IsLeader() && !m.isTaskTracked(id) {
+		m.enqueueTask(task.Clone())
+	}
+	return task, nil
+}
+
+// checkPaused 检查数据库中的任务是否已被暂停（可能由其他副本写入）
+// 不修改执行器持有的任务对象，保存进度时由仓库保证不覆盖 paused
+func (m *TaskManager) checkPaused(ctx context.Context, task *models.Task) bool {
+	latest, err := m.repo.GetTask(ctx, task.ID)
+	return err == nil && latest.Status == models.TaskPaused
+}
+
+// resubmitExpiredJobs 为 Job 已被 TTL 清理但尝试尚未结束的节点重新创建 Job
+// 使用原尝试序号（Job 名称相同），不占用节点的重试次数，并从重新提交时开始计算 Job 不存在的宽限期
+func (m *TaskManager) resubmitExpiredJobs(ctx context.Context, task *models.Task, nodes []string) error {
+	opts := jobOptions(task)
+	opts.SecretName = credsSecretName(task)
+	now := time.Now()
+	for _, nodeName := range nodes {
+		attempts := task.NodeAttempts[nodeName]
+		attempt := &attempts[len(attempts)-1]
+		opts.Attempt = attempt.Attempt
+		if err := m.batchScheduler.jobCreator.CreateJob(ctx, task.ID, nodeName, task.Images, opts); err != nil && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to resubmit expired job for node %s: %w", nodeName, err)
+		}
+		attempt.StartedAt = now
+	}
+
+	m.logger.WithFields(logrus.Fields{
+		"taskId": task.ID,
+		"nodes":  nodes,
+	}).Info("Resubmitted jobs expired while the task was paused")
+	return m.repo.UpdateTask(ctx, task)
+}
+
+// stopPausedTask 暂停后结束本地执行，释放执行槽位
+// 不写入任务状态，避免覆盖执行器退出前已提交的恢复请求
+func (m *TaskManager) stopPausedTask(task *models.Task) error {
+	m.logger.WithFields(logrus.Fields{
+		"taskId":       task.ID,
+		"currentBatch": submittedBatches(task),
+	}).Info("Task paused, execution stopped")
+	return nil
+}
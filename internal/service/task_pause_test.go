@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kitsnail/ips/internal/k8s"
+	"github.com/kitsnail/ips/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTaskManager_PauseAndResume(t *testing.T) {
+	manager, repo, k8sClient := setupTaskManager(t, newReadyNode("node-1"), newReadyNode("node-2"), newReadyNode("node-3"))
+	manager.batchScheduler.pollInterval = 20 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	manager.Start(ctx)
+	defer manager.Stop()
+
+	task, err := manager.CreateTask(ctx, &models.CreateTaskRequest{
+		Images:    []string{"nginx:latest"},
+		BatchSize: 1,
+		BatchMode: models.BatchModePipelined,
+	})
+	require.NoError(t, err)
+	jobs := waitForJobs(t, k8sClient, task.ID, 1)
+
+	paused, err := manager.PauseTask(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TaskPaused, paused.Status)
+
+	_, err = manager.PauseTask(ctx, task.ID)
+	assert.ErrorIs(t, err, ErrTaskNotPausable)
+
+	// 当前批次结束后不再提交下一批次，执行器退出
+	markJobFinished(t, k8sClient, jobs[0], true)
+	require.Eventually(t, func() bool {
+		return !manager.isTaskTracked(task.ID)
+	}, 5*time.Second, 20*time.Millisecond)
+	waitForJobs(t, k8sClient, task.ID, 1)
+
+	// 暂停状态已持久化，重启恢复时不会继续执行
+	require.NoError(t, manager.RecoverTasks(ctx))
+	time.Sleep(100 * time.Millisecond)
+	waitForJobs(t, k8sClient, task.ID, 1)
+	stored, err := repo.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TaskPaused, stored.Status)
+	assert.Equal(t, 1, stored.Progress.CurrentBatch)
+
+	// 恢复后从已提交的批次继续
+	resumed, err := manager.ResumeTask(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TaskPending, resumed.Status)
+	waitForJobs(t, k8sClient, task.ID, 2)
+
+	_, err = manager.ResumeTask(ctx, task.ID)
+	assert.ErrorIs(t, err, ErrTaskNotPaused)
+
+	_, err = manager.DeleteTask(ctx, task.ID)
+	require.NoError(t, err)
+}
+
+func TestTaskManager_PauseKeepsSecretAndResubmitsExpiredJobs(t *testing.T) {
+	manager, repo, k8sClient := setupTaskManager(t, newReadyNode("node-1"), newReadyNode("node-2"), newReadyNode("node-3"))
+	manager.batchScheduler.pollInterval = 20 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	manager.Start(ctx)
+	defer manager.Stop()
+
+	task, err := manager.CreateTask(ctx, &models.CreateTaskRequest{
+		Images:    []string{"registry.example.com/app:v1"},
+		BatchSize: 1,
+		BatchMode: models.BatchModePipelined,
+		Registry:  "registry.example.com",
+		Username:  "user",
+		Password:  "secret",
+	})
+	require.NoError(t, err)
+	jobs := waitForJobs(t, k8sClient, task.ID, 1)
+
+	_, err = manager.PauseTask(ctx, task.ID)
+	require.NoError(t, err)
+	markJobFinished(t, k8sClient, jobs[0], true)
+	require.Eventually(t, func() bool {
+		return !manager.isTaskTracked(task.ID)
+	}, 5*time.Second, 20*time.Millisecond)
+
+	// 执行器退出后 Secret 保留，已创建的 Job 仍可读取凭据
+	secretName := k8s.CredsSecretName(task.ID)
+	secrets := k8sClient.Clientset.CoreV1().Secrets("default")
+	_, err = secrets.Get(ctx, secretName, metav1.GetOptions{})
+	require.NoError(t, err)
+
+	// 模拟暂停期间 Job 被 TTL 清理：一个节点已记录结果，另一个节点的尝试尚未结束
+	finishedNode := jobs[0].Labels["node"]
+	var expiredNode string
+	for _, nodeName := range []string{"node-1", "node-2", "node-3"} {
+		if nodeName != finishedNode {
+			expiredNode = nodeName
+			break
+		}
+	}
+	require.NoError(t, k8sClient.Clientset.BatchV1().Jobs("default").Delete(ctx, jobs[0].Name, metav1.DeleteOptions{}))
+	stored, err := repo.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	finishedAt := time.Now()
+	stored.NodeAttempts = map[string][]models.NodeAttempt{
+		finishedNode: {{Attempt: 1, JobName: jobs[0].Name, Status: models.NodeResultSucceeded, StartedAt: finishedAt, FinishedAt: &finishedAt}},
+		expiredNode:  {{Attempt: 1, JobName: k8s.JobName(task.ID, expiredNode, 1), Status: models.NodeResultPending, StartedAt: finishedAt}},
+	}
+	require.NoError(t, repo.UpdateTask(ctx, stored))
+
+	// 恢复后以原尝试序号重新提交尝试未结束的节点，已记录结果的节点不重复拉取
+	_, err = manager.ResumeTask(ctx, task.ID)
+	require.NoError(t, err)
+	jobs = waitForJobs(t, k8sClient, task.ID, 2)
+	names := []string{jobs[0].Name, jobs[1].Name}
+	assert.Contains(t, names, k8s.JobName(task.ID, expiredNode, 1))
+	assert.NotContains(t, names, k8s.JobName(task.ID, finishedNode, 1))
+
+	_, err = manager.PauseTask(ctx, task.ID)
+	require.NoError(t, err)
+	for _, job := range jobs {
+		markJobFinished(t, k8sClient, job, true)
+	}
+	require.Eventually(t, func() bool {
+		return !manager.isTaskTracked(task.ID)
+	}, 5*time.Second, 20*time.Millisecond)
+
+	// 取消暂停的任务时清理 Secret
+	_, err = manager.DeleteTask(ctx, task.ID)
+	require.NoError(t, err)
+	_, err = secrets.Get(ctx, secretName, metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/kitsnail/ips/internal/k8s"
+	"github.com/kitsnail/ips/pkg/models"
+)
+
+// planHistorySize 估算耗时时参考的最近完成任务数
+const planHistorySize = 20
+
+// PlanTask 生成任务执行计划（dry-run）
+// 按执行任务时相同的规则筛选节点、划分批次并解析凭据，不创建 Job 与 Secret
+func (m *TaskManager) PlanTask(ctx context.Context, req *models.CreateTaskRequest) (*models.TaskPlan, error) {
+	req, err := m.resolveWorkloads(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.Images) == 0 {
+		return nil, fmt.Errorf("%w: no images specified", ErrInvalidImage)
+	}
+	if len(req.Images) > 50 {
+		return nil, fmt.Errorf("too many images: max 50 images allowed per task")
+	}
+
+	if _, err := parseImages(req.Images); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateRollout(req.Rollout); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateSuccessPolicy(req.SuccessPolicy); err != nil {
+		return nil, err
+	}
+
+	matched, excluded, err := m.nodeFilter.PlanNodes(ctx, req.NodeSelector, req.NodeSelection)
+	if err != nil {
+		return nil, err
+	}
+
+	batchMode, _, windowSize := batchSettings(req)
+	plan := &models.TaskPlan{
+		Images:        workloadImages(req),
+		MatchedNodes:  matched,
+		ExcludedNodes: excluded,
+		PresentNodes:  []string{},
+		TargetNodes:   []string{},
+		BatchMode:     batchMode,
+		WindowSize:    windowSize,
+		Batches:       []models.PlanBatch{},
+	}
+
+	warmed, err := m.nodeFilter.NodesWithImages(ctx, req.Images)
+	if err != nil {
+		warmed = nil
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("failed to check images present on nodes: %v", err))
+	}
+	// 只有镜像都固定为 digest（或创建时解析为 digest）时才跳过已存在镜像的节点
+	skipPresent := req.SkipPresent && (req.ResolveDigests || k8s.ImageRefsPinned(req.Images))
+	if req.SkipPresent && !skipPresent {
+		plan.Warnings = append(plan.Warnings, "skipPresentNodes only applies to digest-pinned images; nodes with tagged images present will still be prewarmed")
+	}
+	for _, nodeName := range matched {
+		if warmed[nodeName] {
+			plan.PresentNodes = append(plan.PresentNodes, nodeName)
+			if skipPresent {
+				continue
+			}
+		}
+		plan.TargetNodes = append(plan.TargetNodes, nodeName)
+	}
+	plan.TotalNodes = len(plan.TargetNodes)
+	plan.SkippedNodes = len(matched) - len(plan.TargetNodes)
+
+	// 设置了灰度策略时金丝雀节点单独作为第一批次
+	canary, rest := splitCanary(req.Rollout, plan.TargetNodes)
+	if len(canary) > 0 {
+		plan.Batches = append(plan.Batches, models.PlanBatch{Batch: 1, Nodes: canary, Canary: true})
+	}
+	for _, batch := range m.batchScheduler.splitBatches(rest, req.BatchSize) {
+		plan.Batches = append(plan.Batches, models.PlanBatch{Batch: len(plan.Batches) + 1, Nodes: batch})
+	}
+	plan.TotalBatches = len(plan.Batches)
+
+	switch {
+	case len(matched) == 0:
+		plan.Warnings = append(plan.Warnings, "no nodes match the selection, the task would fail")
+	case plan.TotalNodes == 0:
+		plan.Warnings = append(plan.Warnings, "all matched nodes already have the images, no jobs would be created")
+	}
+
+	plan.Credentials = m.planCredentials(ctx, req)
+	if plan.Credentials.Error != "" {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("saved credentials %d cannot be used: %s", req.SecretID, plan.Credentials.Error))
+	}
+
+	plan.Estimate = m.estimateDuration(ctx, plan.TotalBatches)
+	return plan, nil
+}
+
+// planCredentials 按 prepareCredentials 相同的优先级解析凭据，只读取不创建 Secret
+func (m *TaskManager) planCredentials(ctx context.Context, req *models.CreateTaskRequest) *models.PlanCredentials {
+	if req.Registry != "" && req.Username != "" && req.Password != "" {
+		return &models.PlanCredentials{
+			Source:   models.CredentialSourceManual,
+			Registry: req.Registry,
+			Username: req.Username,
+		}
+	}
+
+	if req.SecretID > 0 {
+		creds := &models.PlanCredentials{
+			Source:   models.CredentialSourceSecret,
+			SecretID: req.SecretID,
+		}
+		secret, err := m.secretRepo.GetSecretCredentials(ctx, req.SecretID)
+		if err != nil {
+			creds.Error = err.Error()
+			return creds
+		}
+		creds.Registry = secret.Registry
+		creds.Username = secret.Username
+		return creds
+	}
+
+	return &models.PlanCredentials{Source: models.CredentialSourceNone}
+}
+
+// estimateDuration 根据最近完成任务的平均每批次耗时估算任务耗时
+// 没有可参考的历史任务时返回 nil
+func (m *TaskManager) estimateDuration(ctx context.Context, totalBatches int) *models.PlanEstimate {
+	if totalBatches == 0 {
+		return nil
+	}
+
+	tasks, err := m.repo.ListTasksByStatus(ctx, models.TaskCompleted)
+	if err != nil {
+		return nil
+	}
+
+	var history []*models.Task
+	for _, task := range tasks {
+		if task.StartedAt != nil && task.FinishedAt != nil && task.Progress != nil && task.Progress.TotalBatches > 0 {
+			history = append(history, task)
+		}
+	}
+	if len(history) == 0 {
+		return nil
+	}
+
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].FinishedAt.After(*history[j].FinishedAt)
+	})
+	if len(history) > planHistorySize {
+		history = history[:planHistorySize]
+	}
+
+	var seconds float64
+	var batches int
+	for _, task := range history {
+		seconds += task.FinishedAt.Sub(*task.StartedAt).Seconds()
+		batches += task.Progress.TotalBatches
+	}
+	perBatch := seconds / float64(batches)
+
+	return &models.PlanEstimate{
+		DurationSeconds: int(math.Ceil(perBatch * float64(totalBatches))),
+		SecondsPerBatch: math.Round(perBatch*10) / 10,
+		SampleTasks:     len(history),
+	}
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kitsnail/ips/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTaskManager_PlanTask(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	warm := newReadyNode("node-2")
+	warm.Status.Images = []corev1.ContainerImage{{Names: []string{"docker.io/library/nginx@" + digest, "docker.io/library/nginx:latest"}}}
+	cordoned := newReadyNode("node-4")
+	cordoned.Spec.Unschedulable = true
+
+	taskManager, repo, k8sClient := setupTaskManager(t,
+		newReadyNode("node-1"), warm, newReadyNode("node-3"), cordoned,
+	)
+	ctx := context.Background()
+
+	secret := &models.RegistrySecret{Name: "harbor", Registry: "harbor.example.com", Username: "robot", Password: "secret"}
+	require.NoError(t, repo.CreateSecret(ctx, secret))
+
+	// 历史任务：2 个批次耗时 60 秒
+	startedAt := time.Now().Add(-time.Hour)
+	finishedAt := startedAt.Add(time.Minute)
+	require.NoError(t, repo.CreateTask(ctx, &models.Task{
+		ID:         "task-history",
+		Status:     models.TaskCompleted,
+		Progress:   &models.Progress{TotalBatches: 2},
+		StartedAt:  &startedAt,
+		FinishedAt: &finishedAt,
+	}))
+
+	plan, err := taskManager.PlanTask(ctx, &models.CreateTaskRequest{
+		Images:      []string{"nginx@" + digest},
+		BatchSize:   1,
+		SkipPresent: true,
+		SecretID:    secret.ID,
+	})
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"node-1", "node-2", "node-3"}, plan.MatchedNodes)
+	assert.Equal(t, []models.ExcludedNode{{NodeName: "node-4", Reason: models.ExclusionCordoned}}, plan.ExcludedNodes)
+	assert.Equal(t, []string{"node-2"}, plan.PresentNodes)
+	assert.ElementsMatch(t, []string{"node-1", "node-3"}, plan.TargetNodes)
+	assert.Equal(t, 1, plan.SkippedNodes)
+	assert.Equal(t, models.BatchModeImmediate, plan.BatchMode)
+	assert.Equal(t, 2, plan.TotalBatches)
+	require.Len(t, plan.Batches, 2)
+	assert.Equal(t, 2, plan.Batches[1].Batch)
+
+	assert.Equal(t, models.CredentialSourceSecret, plan.Credentials.Source)
+	assert.Equal(t, "harbor.example.com", plan.Credentials.Registry)
+	assert.Equal(t, "robot", plan.Credentials.Username)
+
+	require.NotNil(t, plan.Estimate)
+	assert.Equal(t, 60, plan.Estimate.DurationSeconds)
+	assert.Equal(t, 1, plan.Estimate.SampleTasks)
+	assert.Empty(t, plan.Warnings)
+
+	// tag 引用不跳过已存在镜像的节点
+	plan, err = taskManager.PlanTask(ctx, &models.CreateTaskRequest{
+		Images:      []string{"nginx:latest"},
+		BatchSize:   1,
+		SkipPresent: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node-2"}, plan.PresentNodes)
+	assert.ElementsMatch(t, []string{"node-1", "node-2", "node-3"}, plan.TargetNodes)
+	assert.Equal(t, 0, plan.SkippedNodes)
+	assert.Len(t, plan.Warnings, 1)
+
+	// 不创建 Job 与 Secret
+	jobs, err := k8sClient.Clientset.BatchV1().Jobs("default").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, jobs.Items)
+	secrets, err := k8sClient.Clientset.CoreV1().Secrets("default").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, secrets.Items)
+}
+
+func TestTaskManager_PlanTask_Warnings(t *testing.T) {
+	taskManager, _, _ := setupTaskManager(t, newReadyNode("node-1"))
+	ctx := context.Background()
+
+	plan, err := taskManager.PlanTask(ctx, &models.CreateTaskRequest{
+		Images:        []string{"nginx:latest"},
+		BatchSize:     10,
+		NodeSelection: &models.NodeSelection{ExcludeNodes: []string{"node-1"}},
+		SecretID:      42,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, plan.MatchedNodes)
+	assert.Empty(t, plan.Batches)
+	assert.Nil(t, plan.Estimate)
+	assert.NotEmpty(t, plan.Credentials.Error)
+	assert.Len(t, plan.Warnings, 2)
+
+	_, err = taskManager.PlanTask(ctx, &models.CreateTaskRequest{
+		Images:        []string{"nginx:latest"},
+		BatchSize:     10,
+		NodeSelection: &models.NodeSelection{MatchExpressions: []models.NodeSelectorRequirement{{Key: "zone", Operator: "Gt"}}},
+	})
+	assert.ErrorIs(t, err, ErrInvalidNodeSelection)
+}
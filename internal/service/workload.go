@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kitsnail/ips/internal/k8s"
+	"github.com/kitsnail/ips/pkg/models"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ErrInvalidWorkloadSource 工作负载来源无效或未匹配到工作负载
+var ErrInvalidWorkloadSource = errors.New("invalid workload source")
+
+// workloadKinds 支持的工作负载类型，按标签选择时默认全部查询
+var workloadKinds = []string{k8s.KindDeployment, k8s.KindStatefulSet, k8s.KindDaemonSet}
+
+// ValidateWorkloadSource 校验工作负载来源，创建任务前调用
+func ValidateWorkloadSource(source *models.WorkloadSource) error {
+	if err := source.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWorkloadSource, err)
+	}
+	if source == nil {
+		return nil
+	}
+
+	for i, ref := range source.Workloads {
+		if !containsString(workloadKinds, ref.Kind) {
+			return fmt.Errorf("%w: workloads[%d]: unsupported kind %q", ErrInvalidWorkloadSource, i, ref.Kind)
+		}
+	}
+	for _, kind := range source.Kinds {
+		if !containsString(workloadKinds, kind) {
+			return fmt.Errorf("%w: unsupported kind %q", ErrInvalidWorkloadSource, kind)
+		}
+	}
+	if _, err := labels.ValidatedSelectorFromSet(source.LabelSelector); err != nil {
+		return fmt.Errorf("%w: labelSelector: %v", ErrInvalidWorkloadSource, err)
+	}
+	return nil
+}
+
+// resolveWorkloads 查询请求中的工作负载，返回合并了工作负载镜像的请求副本
+// 设置 matchNodes 时将目标节点限制为工作负载可调度的节点（与 includeNodes 取交集）
+// 未指定工作负载来源时原样返回请求
+func (m *TaskManager) resolveWorkloads(ctx context.Context, req *models.CreateTaskRequest) (*models.CreateTaskRequest, error) {
+	source := req.Workloads
+	if source == nil {
+		return req, nil
+	}
+	if err := ValidateWorkloadSource(source); err != nil {
+		return nil, err
+	}
+
+	workloads, err := m.listWorkloads(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	if len(workloads) == 0 {
+		return nil, fmt.Errorf("%w: no workloads found in namespace %s", ErrInvalidWorkloadSource, source.Namespace)
+	}
+
+	resolved := *req
+	resolved.Images = append([]string{}, req.Images...)
+	for i := range workloads {
+		for _, image := range k8s.PodImages(&workloads[i].PodSpec) {
+			if !containsString(resolved.Images, image) {
+				resolved.Images = append(resolved.Images, image)
+			}
+		}
+	}
+
+	if source.MatchNodes {
+		nodes, err := m.workloadNodes(ctx, workloads)
+		if err != nil {
+			return nil, err
+		}
+
+		// 复制筛选条件，避免修改定时任务配置中共享的对象
+		selection := models.NodeSelection{}
+		if req.NodeSelection != nil {
+			selection = *req.NodeSelection
+		}
+		if len(selection.IncludeNodes) > 0 {
+			var included []string
+			for _, nodeName := range nodes {
+				if containsString(selection.IncludeNodes, nodeName) {
+					included = append(included, nodeName)
+				}
+			}
+			nodes = included
+		}
+		if len(nodes) == 0 {
+			return nil, fmt.Errorf("%w: no nodes satisfy the scheduling constraints of the workloads", ErrInvalidWorkloadSource)
+		}
+		selection.IncludeNodes = nodes
+		resolved.NodeSelection = &selection
+	}
+
+	m.logger.WithFields(logrus.Fields{
+		"workloads":  len(workloads),
+		"images":     resolved.Images,
+		"matchNodes": source.MatchNodes,
+	}).Info("Resolved workload images")
+	return &resolved, nil
+}
+
+// listWorkloads 获取指定的工作负载，或列出命名空间中匹配标签的工作负载
+func (m *TaskManager) listWorkloads(ctx context.Context, source *models.WorkloadSource) ([]k8s.Workload, error) {
+	client := m.nodeFilter.k8sClient
+
+	if len(source.Workloads) > 0 {
+		workloads := make([]k8s.Workload, 0, len(source.Workloads))
+		for _, ref := range source.Workloads {
+			namespace := ref.Namespace
+			if namespace == "" {
+				namespace = source.Namespace
+			}
+			workload, err := client.GetWorkload(ctx, ref.Kind, namespace, ref.Name)
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					return nil, fmt.Errorf("%w: %s %s/%s not found", ErrInvalidWorkloadSource, ref.Kind, namespace, ref.Name)
+				}
+				return nil, err
+			}
+			workloads = append(workloads, *workload)
+		}
+		return workloads, nil
+	}
+
+	kinds := source.Kinds
+	if len(kinds) == 0 {
+		kinds = workloadKinds
+	}
+	selector := labels.SelectorFromSet(source.LabelSelector)
+	var workloads []k8s.Workload
+	for _, kind := range kinds {
+		items, err := client.ListWorkloads(ctx, kind, source.Namespace, selector)
+		if err != nil {
+			return nil, err
+		}
+		workloads = append(workloads, items...)
+	}
+	return workloads, nil
+}
+
+// workloadNodes 返回任一工作负载可调度的节点
+func (m *TaskManager) workloadNodes(ctx context.Context, workloads []k8s.Workload) ([]string, error) {
+	nodeList, err := m.nodeFilter.k8sClient.GetNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all nodes: %w", err)
+	}
+
+	var nodes []string
+	for i := range nodeList {
+		for j := range workloads {
+			if k8s.PodFitsNode(&workloads[j].PodSpec, &nodeList[i]) {
+				nodes = append(nodes, nodeList[i].Name)
+				break
+			}
+		}
+	}
+	return nodes, nil
+}
+
+// workloadImages 指定了工作负载来源时返回解析后的镜像，用于执行计划
+func workloadImages(req *models.CreateTaskRequest) []string {
+	if req.Workloads == nil {
+		return nil
+	}
+	return req.Images
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kitsnail/ips/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTaskManager_CreateTask_Workloads(t *testing.T) {
+	podTemplate := func(nodeSelector map[string]string, images ...string) corev1.PodTemplateSpec {
+		spec := corev1.PodSpec{
+			NodeSelector:   nodeSelector,
+			InitContainers: []corev1.Container{{Name: "init", Image: "busybox:1.36"}},
+		}
+		for _, image := range images {
+			spec.Containers = append(spec.Containers, corev1.Container{Name: "app", Image: image})
+		}
+		return corev1.PodTemplateSpec{Spec: spec}
+	}
+	objectMeta := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: "ml", Labels: map[string]string{"team": "ml"}}
+	}
+
+	taskManager, _, _ := setupTaskManager(t,
+		&appsv1.Deployment{ObjectMeta: objectMeta("trainer"), Spec: appsv1.DeploymentSpec{Template: podTemplate(map[string]string{"pool": "gpu"}, "ml/trainer:v3")}},
+		&appsv1.DaemonSet{ObjectMeta: objectMeta("exporter"), Spec: appsv1.DaemonSetSpec{Template: podTemplate(nil, "ml/exporter:v1", "ml/trainer:v3")}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "gpu-1", Labels: map[string]string{"pool": "gpu"}}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "cpu-1", Labels: map[string]string{"pool": "cpu"}}},
+	)
+	ctx := context.Background()
+
+	// 指定工作负载，只预热其可调度的节点
+	task, err := taskManager.CreateTask(ctx, &models.CreateTaskRequest{
+		Images:    []string{"nginx:latest"},
+		BatchSize: 10,
+		Workloads: &models.WorkloadSource{
+			Workloads:  []models.WorkloadRef{{Kind: "Deployment", Namespace: "ml", Name: "trainer"}},
+			MatchNodes: true,
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nginx:latest", "busybox:1.36", "ml/trainer:v3"}, task.Images)
+	require.NotNil(t, task.NodeSelection)
+	assert.Equal(t, []string{"gpu-1"}, task.NodeSelection.IncludeNodes)
+
+	// 按命名空间与标签选择全部类型的工作负载
+	task, err = taskManager.CreateTask(ctx, &models.CreateTaskRequest{
+		BatchSize: 10,
+		Workloads: &models.WorkloadSource{Namespace: "ml", LabelSelector: map[string]string{"team": "ml"}},
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"busybox:1.36", "ml/trainer:v3", "ml/exporter:v1"}, task.Images)
+	assert.Nil(t, task.NodeSelection)
+
+	for _, source := range []*models.WorkloadSource{
+		{Workloads: []models.WorkloadRef{{Kind: "Deployment", Namespace: "ml", Name: "missing"}}},
+		{Workloads: []models.WorkloadRef{{Kind: "CronJob", Namespace: "ml", Name: "trainer"}}},
+		{Namespace: "ml", LabelSelector: map[string]string{"team": "web"}},
+		{Namespace: "ml", Kinds: []string{"StatefulSet"}},
+	} {
+		_, err := taskManager.CreateTask(ctx, &models.CreateTaskRequest{BatchSize: 10, Workloads: source})
+		assert.ErrorIs(t, err, ErrInvalidWorkloadSource)
+	}
+}
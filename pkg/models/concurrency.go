@@ -0,0 +1,126 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AllNodesGroup 未指定节点选择器的任务所属的分组
+const AllNodesGroup = "*"
+
+// ConcurrencyLimits 任务并发限制
+// 各项限制为 0 表示不限制（全局限制除外）
+type ConcurrencyLimits struct {
+	Global                     int            `json:"global" binding:"required,min=1,max=100"`        // 全局最大并发任务数
+	PerCreator                 int            `json:"perCreator" binding:"omitempty,min=0"`           // 每个创建者的默认最大并发任务数
+	PerNodeSelectorGroup       int            `json:"perNodeSelectorGroup" binding:"omitempty,min=0"` // 每个节点选择器分组的默认最大并发任务数
+	CreatorOverrides           map[string]int `json:"creatorOverrides,omitempty"`                     // 指定创建者的并发限制
+	NodeSelectorGroupOverrides map[string]int `json:"nodeSelectorGroupOverrides,omitempty"`           // 指定节点选择器分组的并发限制（key 见 NodeSelectorGroup）
+}
+
+// Validate 校验并发限制
+func (l *ConcurrencyLimits) Validate() error {
+	if l.Global < 1 {
+		return fmt.Errorf("global limit must be at least 1")
+	}
+	if l.PerCreator < 0 || l.PerNodeSelectorGroup < 0 {
+		return fmt.Errorf("limits must not be negative")
+	}
+	for creator, limit := range l.CreatorOverrides {
+		if limit < 0 {
+			return fmt.Errorf("limit for creator %q must not be negative", creator)
+		}
+	}
+	for group, limit := range l.NodeSelectorGroupOverrides {
+		if limit < 0 {
+			return fmt.Errorf("limit for node selector group %q must not be negative", group)
+		}
+	}
+	return nil
+}
+
+// CreatorLimit 返回指定创建者的并发限制，0 表示不限制
+func (l *ConcurrencyLimits) CreatorLimit(creator string) int {
+	if limit, ok := l.CreatorOverrides[creator]; ok {
+		return limit
+	}
+	return l.PerCreator
+}
+
+// NodeSelectorGroupLimit 返回指定节点选择器分组的并发限制，0 表示不限制
+func (l *ConcurrencyLimits) NodeSelectorGroupLimit(group string) int {
+	if limit, ok := l.NodeSelectorGroupOverrides[group]; ok {
+		return limit
+	}
+	return l.PerNodeSelectorGroup
+}
+
+// ConcurrencyStats 并发使用情况（仅管理员可见）
+type ConcurrencyStats struct {
+	Limits                     ConcurrencyLimits `json:"limits"`
+	Running                    int               `json:"running"`
+	Queued                     int               `json:"queued"`
+	RunningByCreator           map[string]int    `json:"runningByCreator"`
+	RunningByNodeSelectorGroup map[string]int    `json:"runningByNodeSelectorGroup"`
+}
+
+// ConcurrencySummary 并发使用情况汇总，不含创建者等明细，用于无需认证的统计接口
+type ConcurrencySummary struct {
+	GlobalLimit int `json:"globalLimit"`
+	Running     int `json:"running"`
+	Queued      int `json:"queued"`
+}
+
+// NodeSelectorGroup 返回节点选择器的分组标识
+// 格式为按 key 排序的 "k1=v1,k2=v2"，未指定选择器时为 AllNodesGroup
+func NodeSelectorGroup(selector map[string]string) string {
+	if len(selector) == 0 {
+		return AllNodesGroup
+	}
+
+	pairs := make([]string, 0, len(selector))
+	for k, v := range selector {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// TaskNodeSelectorGroup 返回任务实际节点选择条件的分组标识
+// 在 NodeSelectorGroup 的基础上加入标签选择表达式（格式同 Kubernetes 标签选择器，如 "zone in (a,b)"、"!gpu"）
+// 与指定节点列表（"@nodes in (n1,n2)"），各项排序后逗号拼接；排除节点、污点及就绪状态等条件只缩小范围，不影响分组
+func TaskNodeSelectorGroup(selector map[string]string, selection *NodeSelection) string {
+	if selection == nil || (len(selection.MatchExpressions) == 0 && len(selection.IncludeNodes) == 0) {
+		return NodeSelectorGroup(selector)
+	}
+
+	parts := make([]string, 0, len(selector)+len(selection.MatchExpressions)+1)
+	for k, v := range selector {
+		parts = append(parts, k+"="+v)
+	}
+	for _, expr := range selection.MatchExpressions {
+		switch expr.Operator {
+		case NodeSelectorOpIn:
+			parts = append(parts, expr.Key+" in ("+sortedJoin(expr.Values)+")")
+		case NodeSelectorOpNotIn:
+			parts = append(parts, expr.Key+" notin ("+sortedJoin(expr.Values)+")")
+		case NodeSelectorOpExists:
+			parts = append(parts, expr.Key)
+		case NodeSelectorOpDoesNotExist:
+			parts = append(parts, "!"+expr.Key)
+		}
+	}
+	if len(selection.IncludeNodes) > 0 {
+		parts = append(parts, "@nodes in ("+sortedJoin(selection.IncludeNodes)+")")
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+// sortedJoin 排序后逗号拼接，不修改原切片
+func sortedJoin(values []string) string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
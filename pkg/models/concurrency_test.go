@@ -0,0 +1,54 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskNodeSelectorGroup(t *testing.T) {
+	tests := []struct {
+		name      string
+		selector  map[string]string
+		selection *NodeSelection
+		expected  string
+	}{
+		{
+			name:     "未指定选择条件",
+			expected: AllNodesGroup,
+		},
+		{
+			name:     "只有 nodeSelector",
+			selector: map[string]string{"zone": "a", "node-role": "gpu"},
+			expected: "node-role=gpu,zone=a",
+		},
+		{
+			name:      "不影响分组的筛选条件",
+			selector:  map[string]string{"node-role": "gpu"},
+			selection: &NodeSelection{ExcludeNodes: []string{"node-1"}, IncludeNotReady: true},
+			expected:  "node-role=gpu",
+		},
+		{
+			name:     "标签选择表达式",
+			selector: map[string]string{"node-role": "gpu"},
+			selection: &NodeSelection{MatchExpressions: []NodeSelectorRequirement{
+				{Key: "zone", Operator: NodeSelectorOpIn, Values: []string{"b", "a"}},
+				{Key: "spot", Operator: NodeSelectorOpDoesNotExist},
+				{Key: "arch", Operator: NodeSelectorOpExists},
+				{Key: "pool", Operator: NodeSelectorOpNotIn, Values: []string{"batch"}},
+			}},
+			expected: "!spot,arch,node-role=gpu,pool notin (batch),zone in (a,b)",
+		},
+		{
+			name:      "指定节点",
+			selection: &NodeSelection{IncludeNodes: []string{"node-2", "node-1"}},
+			expected:  "@nodes in (node-1,node-2)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, TaskNodeSelectorGroup(tt.selector, tt.selection))
+		})
+	}
+}
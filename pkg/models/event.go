@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// TaskEventType 任务事件类型
+type TaskEventType string
+
+const (
+	TaskEventStatus          TaskEventType = "status"            // 任务状态变更
+	TaskEventBatchStarted    TaskEventType = "batch_started"     // 开始提交批次 Job
+	TaskEventBatchSubmitted  TaskEventType = "batch_submitted"   // 批次 Job 提交完成
+	TaskEventJobCreateFailed TaskEventType = "job_create_failed" // 节点 Job 创建失败
+	TaskEventNodeResult      TaskEventType = "node_result"       // 节点的一次尝试结束
+	TaskEventNodeRetry       TaskEventType = "node_retry"        // 为失败节点创建重试 Job
+	TaskEventCanary          TaskEventType = "canary"            // 金丝雀节点全部结束，评估成功率
+)
+
+// TaskEvent 任务执行过程中的事件
+type TaskEvent struct {
+	ID        int64         `json:"id"` // 单调递增的事件序号：事件流中为 SSE 的 Last-Event-ID，时间线中为存储序号
+	TaskID    string        `json:"taskId"`
+	Type      TaskEventType `json:"type"`
+	Timestamp time.Time     `json:"timestamp"`
+
+	Status   TaskStatus       `json:"status,omitempty"`     // status：变更后的任务状态
+	Batch    int              `json:"batch,omitempty"`      // batch_started / batch_submitted：批次号
+	NodeName string           `json:"nodeName,omitempty"`   // job_create_failed / node_result / node_retry
+	Result   NodeResultStatus `json:"nodeStatus,omitempty"` // node_result：节点结果
+	Attempt  int              `json:"attempt,omitempty"`    // node_result / node_retry：尝试序号
+	Reason   string           `json:"reason,omitempty"`     // node_result：失败原因
+	Message  string           `json:"message,omitempty"`
+	Progress *Progress        `json:"progress,omitempty"` // 事件发生时的任务进度
+}
+
+// IsTerminal 是否为任务结束事件
+func (e *TaskEvent) IsTerminal() bool {
+	if e.Type != TaskEventStatus {
+		return false
+	}
+	return e.Status == TaskCompleted || e.Status == TaskFailed || e.Status == TaskCancelled
+}
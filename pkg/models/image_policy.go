@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// ImagePolicy 镜像常驻策略：匹配节点选择条件的节点上必须始终存在指定镜像
+// 控制器监听节点新增、就绪及镜像列表变化，为缺少镜像的节点创建预热任务
+type ImagePolicy struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Enabled     bool             `json:"enabled"`
+	TaskConfig  TaskConfig       `json:"taskConfig"` // 镜像、节点选择条件及预热任务的执行参数
+	State       ImagePolicyState `json:"state"`
+	CreatedBy   string           `json:"createdBy"`
+	CreatedAt   time.Time        `json:"createdAt"`
+	UpdatedAt   time.Time        `json:"updatedAt"`
+}
+
+// ImagePolicyState 策略的协调状态，由控制器维护
+type ImagePolicyState struct {
+	ActiveTaskID    string                  `json:"activeTaskId,omitempty"`    // 尚未结束的修复任务，结束前不创建新任务
+	LastTaskID      string                  `json:"lastTaskId,omitempty"`      // 最近一次结束的修复任务
+	LastReconcileAt *time.Time              `json:"lastReconcileAt,omitempty"` // 最近一次协调时间
+	RemediatedAt    map[string]time.Time    `json:"remediatedAt,omitempty"`    // nodeName -> 最近一次为该节点创建修复任务的时间
+	VerifiedNodes   map[string]VerifiedNode `json:"verifiedNodes,omitempty"`   // nodeName -> 镜像列表被截断、经修复任务确认已存在镜像的节点
+}
+
+// VerifiedNode 修复任务在节点上确认（已存在或拉取成功）的镜像
+// 节点上报的镜像列表被截断时无法从节点状态判断，确认后不再重复创建修复任务，节点重建或策略镜像变化后失效
+type VerifiedNode struct {
+	UID    string   `json:"uid"`
+	Images []string `json:"images"`
+}
+
+// CreateImagePolicyRequest 创建镜像常驻策略请求
+type CreateImagePolicyRequest struct {
+	Name        string     `json:"name" binding:"required"`
+	Description string     `json:"description"`
+	Enabled     *bool      `json:"enabled,omitempty"` // 默认启用
+	TaskConfig  TaskConfig `json:"taskConfig" binding:"required"`
+}
+
+// UpdateImagePolicyRequest 更新镜像常驻策略请求
+type UpdateImagePolicyRequest struct {
+	Name        *string     `json:"name,omitempty"`
+	Description *string     `json:"description,omitempty"`
+	Enabled     *bool       `json:"enabled,omitempty"`
+	TaskConfig  *TaskConfig `json:"taskConfig,omitempty"`
+}
+
+// ImagePolicyCompliance 策略的合规报告
+type ImagePolicyCompliance struct {
+	PolicyID          string           `json:"policyId"`
+	Images            []string         `json:"images"`
+	MatchedNodes      int              `json:"matchedNodes"`      // 匹配节点选择条件的节点数
+	CompliantNodes    int              `json:"compliantNodes"`    // 已存在全部镜像的节点数
+	UnknownNodes      int              `json:"unknownNodes"`      // 镜像列表被截断、无法确认是否缺少镜像的节点数
+	CompliancePercent float64          `json:"compliancePercent"` // 合规节点占匹配节点的百分比，没有匹配节点时为 100
+	Nodes             []NodeCompliance `json:"nodes"`
+	ActiveTaskID      string           `json:"activeTaskId,omitempty"`
+	LastTaskID        string           `json:"lastTaskId,omitempty"`
+	LastReconcileAt   *time.Time       `json:"lastReconcileAt,omitempty"`
+	GeneratedAt       time.Time        `json:"generatedAt"`
+}
+
+// NodeCompliance 单个节点的合规情况（根据 Node.Status.Images 判断）
+type NodeCompliance struct {
+	NodeName      string     `json:"nodeName"`
+	Compliant     bool       `json:"compliant"`
+	MissingImages []string   `json:"missingImages,omitempty"`
+	Unknown       bool       `json:"unknown,omitempty"`      // 上报的镜像列表达到 kubelet 上限，缺少的镜像可能只是未上报
+	RemediatedAt  *time.Time `json:"remediatedAt,omitempty"` // 最近一次为该节点创建修复任务的时间
+}
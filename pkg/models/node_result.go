@@ -0,0 +1,97 @@
+package models
+
+import "time"
+
+// ImagePullStatus 镜像拉取状态
+type ImagePullStatus string
+
+const (
+	ImagePullSucceeded      ImagePullStatus = "succeeded"
+	ImagePullFailed         ImagePullStatus = "failed"
+	ImagePullAlreadyPresent ImagePullStatus = "already_present" // 节点上已存在，未重新拉取
+	ImagePullTimeout        ImagePullStatus = "timeout"         // 超过单镜像拉取超时
+)
+
+// ImagePullResult 单个镜像在单个节点上的拉取结果（由 puller 写入 termination log）
+type ImagePullResult struct {
+	Image      string          `json:"image"`
+	Status     ImagePullStatus `json:"status"`
+	Error      string          `json:"error,omitempty"`
+	DurationMs int64           `json:"durationMs"`          // 拉取耗时（毫秒）
+	Digest     string          `json:"digest,omitempty"`    // 镜像 digest（repo digest）
+	SizeBytes  uint64          `json:"sizeBytes,omitempty"` // 镜像大小（字节）
+}
+
+// Succeeded 镜像在节点上是否可用
+func (r *ImagePullResult) Succeeded() bool {
+	return r.Status == ImagePullSucceeded || r.Status == ImagePullAlreadyPresent
+}
+
+// NodeResultStatus 节点预热状态
+type NodeResultStatus string
+
+const (
+	NodeResultPending   NodeResultStatus = "pending"   // 尚未产生结果
+	NodeResultSucceeded NodeResultStatus = "succeeded" // 所有镜像均已就绪
+	NodeResultFailed    NodeResultStatus = "failed"    // Job 失败或有镜像拉取失败
+	NodeResultSkipped   NodeResultStatus = "skipped"   // 节点已存在全部镜像，未创建 Job
+)
+
+// NodeResult 节点预热结果
+type NodeResult struct {
+	NodeName  string            `json:"nodeName"`
+	Status    NodeResultStatus  `json:"status"`
+	Message   string            `json:"message,omitempty"` // Job 失败原因
+	Images    []ImagePullResult `json:"images,omitempty"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+}
+
+// NewNodeResult 根据镜像拉取结果生成节点结果
+func NewNodeResult(nodeName string, images []ImagePullResult) *NodeResult {
+	result := &NodeResult{
+		NodeName:  nodeName,
+		Status:    NodeResultSucceeded,
+		Images:    images,
+		UpdatedAt: time.Now(),
+	}
+	for i := range images {
+		if !images[i].Succeeded() {
+			result.Status = NodeResultFailed
+			break
+		}
+	}
+	return result
+}
+
+// ImageResult 返回指定镜像的拉取结果
+func (r *NodeResult) ImageResult(image string) *ImagePullResult {
+	for i := range r.Images {
+		if r.Images[i].Image == image {
+			return &r.Images[i]
+		}
+	}
+	return nil
+}
+
+// NodeAttempt 节点的一次预热尝试，对应一个 Job
+type NodeAttempt struct {
+	Attempt    int              `json:"attempt"` // 尝试序号，从 1 开始
+	JobName    string           `json:"jobName"`
+	Status     NodeResultStatus `json:"status"` // pending 表示 Job 仍在运行
+	Reason     string           `json:"reason,omitempty"`
+	Message    string           `json:"message,omitempty"`
+	StartedAt  time.Time        `json:"startedAt"`
+	FinishedAt *time.Time       `json:"finishedAt,omitempty"`
+}
+
+// NodeLog 节点失败时采集的 puller 容器日志
+// Job 被 TTL 清理后 Pod 日志随之消失，因此在节点失败时保存
+type NodeLog struct {
+	NodeName   string    `json:"nodeName"`
+	Attempt    int       `json:"attempt"`
+	JobName    string    `json:"jobName"`
+	PodName    string    `json:"podName"`
+	Logs       string    `json:"logs"`
+	Truncated  bool      `json:"truncated"` // 超过长度限制，只保留末尾部分
+	CapturedAt time.Time `json:"capturedAt"`
+}
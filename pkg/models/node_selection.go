@@ -0,0 +1,88 @@
+package models
+
+import "fmt"
+
+// NodeSelectorOperator 标签选择表达式的运算符
+type NodeSelectorOperator string
+
+const (
+	NodeSelectorOpIn           NodeSelectorOperator = "In"
+	NodeSelectorOpNotIn        NodeSelectorOperator = "NotIn"
+	NodeSelectorOpExists       NodeSelectorOperator = "Exists"
+	NodeSelectorOpDoesNotExist NodeSelectorOperator = "DoesNotExist"
+)
+
+// 污点效果（TaintSelector.Effect）
+const (
+	TaintEffectNoSchedule       = "NoSchedule"
+	TaintEffectPreferNoSchedule = "PreferNoSchedule"
+	TaintEffectNoExecute        = "NoExecute"
+)
+
+// NodeSelection nodeSelector 之外的节点筛选条件，所有条件同时满足
+// 默认只选择就绪且可调度的节点
+type NodeSelection struct {
+	MatchExpressions []NodeSelectorRequirement `json:"matchExpressions,omitempty"` // 基于集合的标签选择表达式
+	IncludeNodes     []string                  `json:"includeNodes,omitempty"`     // 只在这些节点中选择
+	ExcludeNodes     []string                  `json:"excludeNodes,omitempty"`     // 排除的节点
+	ExcludeTaints    []TaintSelector           `json:"excludeTaints,omitempty"`    // 排除带有任一匹配污点的节点
+	IncludeNotReady  bool                      `json:"includeNotReady,omitempty"`  // 包含未就绪的节点
+	IncludeCordoned  bool                      `json:"includeCordoned,omitempty"`  // 包含已封锁（不可调度）的节点
+}
+
+// NodeSelectorRequirement 标签选择表达式
+type NodeSelectorRequirement struct {
+	Key      string               `json:"key"`
+	Operator NodeSelectorOperator `json:"operator"`
+	Values   []string             `json:"values,omitempty"` // In/NotIn 时必填，Exists/DoesNotExist 时必须为空
+}
+
+// TaintSelector 污点匹配条件，Value 与 Effect 为空时匹配任意值
+type TaintSelector struct {
+	Key    string `json:"key"`
+	Value  string `json:"value,omitempty"`
+	Effect string `json:"effect,omitempty"`
+}
+
+// Validate 校验节点筛选条件的结构，标签键值的语法由构建选择器时校验
+func (s *NodeSelection) Validate() error {
+	if s == nil {
+		return nil
+	}
+
+	for i, expr := range s.MatchExpressions {
+		if expr.Key == "" {
+			return fmt.Errorf("matchExpressions[%d]: key is required", i)
+		}
+		switch expr.Operator {
+		case NodeSelectorOpIn, NodeSelectorOpNotIn:
+			if len(expr.Values) == 0 {
+				return fmt.Errorf("matchExpressions[%d]: values are required for operator %s", i, expr.Operator)
+			}
+		case NodeSelectorOpExists, NodeSelectorOpDoesNotExist:
+			if len(expr.Values) > 0 {
+				return fmt.Errorf("matchExpressions[%d]: values must be empty for operator %s", i, expr.Operator)
+			}
+		default:
+			return fmt.Errorf("matchExpressions[%d]: unsupported operator %q", i, expr.Operator)
+		}
+	}
+
+	for _, name := range append(append([]string{}, s.IncludeNodes...), s.ExcludeNodes...) {
+		if name == "" {
+			return fmt.Errorf("node names must not be empty")
+		}
+	}
+
+	for i, taint := range s.ExcludeTaints {
+		if taint.Key == "" {
+			return fmt.Errorf("excludeTaints[%d]: key is required", i)
+		}
+		switch taint.Effect {
+		case "", TaintEffectNoSchedule, TaintEffectPreferNoSchedule, TaintEffectNoExecute:
+		default:
+			return fmt.Errorf("excludeTaints[%d]: unsupported effect %q", i, taint.Effect)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultNodeWarmupBatchSize 镜像库预热任务的默认批次大小
+	DefaultNodeWarmupBatchSize = 10
+	// DefaultNodeWarmupBatchWindow 节点就绪后默认等待的时间，窗口内就绪的节点合并为一个任务
+	DefaultNodeWarmupBatchWindow = 30 * time.Second
+	// DefaultNodeWarmupMaxNodeAge 默认只预热创建时间在 30 分钟内的节点
+	DefaultNodeWarmupMaxNodeAge = 30 * time.Minute
+	// DefaultNodeWarmupMaxTasksPerMinute 默认每分钟最多创建的预热任务数
+	DefaultNodeWarmupMaxTasksPerMinute = 5
+)
+
+// NodeWarmupConfig 新节点自动预热配置
+// 节点加入集群并变为就绪后，为其创建镜像库条目及匹配的定时任务配置的预热任务
+// 数值项为 0 时使用默认值
+type NodeWarmupConfig struct {
+	Enabled             bool              `json:"enabled"`
+	LibraryImageIDs     []int64           `json:"libraryImageIds,omitempty"`     // 预热的镜像库条目
+	LibraryNodeSelector map[string]string `json:"libraryNodeSelector,omitempty"` // 镜像库条目只预热匹配的节点，为空时预热所有新节点
+	ScheduledTaskIDs    []string          `json:"scheduledTaskIds,omitempty"`    // 按定时任务的配置预热其节点选择条件匹配的新节点
+	BatchSize           int               `json:"batchSize,omitempty"`           // 镜像库预热任务的批次大小
+	BatchWindowSeconds  int               `json:"batchWindowSeconds,omitempty"`  // 节点就绪后等待的时间，窗口内就绪的节点合并为一个任务
+	MaxNodeAgeSeconds   int               `json:"maxNodeAgeSeconds,omitempty"`   // 只预热创建时间在该范围内的节点，避免重启或恢复就绪的旧节点触发预热
+	MaxTasksPerMinute   int               `json:"maxTasksPerMinute,omitempty"`   // 每分钟最多创建的预热任务数，超出的节点延后处理
+}
+
+// Validate 校验新节点自动预热配置
+func (c *NodeWarmupConfig) Validate() error {
+	if c.BatchSize < 0 || c.BatchWindowSeconds < 0 || c.MaxNodeAgeSeconds < 0 || c.MaxTasksPerMinute < 0 {
+		return fmt.Errorf("values must not be negative")
+	}
+	if c.BatchSize > 1000 {
+		return fmt.Errorf("batchSize must not exceed 1000")
+	}
+	if c.Enabled && len(c.LibraryImageIDs) == 0 && len(c.ScheduledTaskIDs) == 0 {
+		return fmt.Errorf("libraryImageIds or scheduledTaskIds is required when enabled")
+	}
+	return nil
+}
+
+// LibraryBatchSize 返回镜像库预热任务的批次大小
+func (c *NodeWarmupConfig) LibraryBatchSize() int {
+	if c.BatchSize > 0 {
+		return c.BatchSize
+	}
+	return DefaultNodeWarmupBatchSize
+}
+
+// BatchWindow 返回节点就绪后等待合并的时间
+func (c *NodeWarmupConfig) BatchWindow() time.Duration {
+	if c.BatchWindowSeconds > 0 {
+		return time.Duration(c.BatchWindowSeconds) * time.Second
+	}
+	return DefaultNodeWarmupBatchWindow
+}
+
+// MaxNodeAge 返回需要预热的节点的最大创建时长
+func (c *NodeWarmupConfig) MaxNodeAge() time.Duration {
+	if c.MaxNodeAgeSeconds > 0 {
+		return time.Duration(c.MaxNodeAgeSeconds) * time.Second
+	}
+	return DefaultNodeWarmupMaxNodeAge
+}
+
+// TaskRateLimit 返回每分钟最多创建的预热任务数
+func (c *NodeWarmupConfig) TaskRateLimit() int {
+	if c.MaxTasksPerMinute > 0 {
+		return c.MaxTasksPerMinute
+	}
+	return DefaultNodeWarmupMaxTasksPerMinute
+}
+
+// NodeWarmupStatus 新节点自动预热配置及等待预热的节点
+type NodeWarmupStatus struct {
+	Config       NodeWarmupConfig `json:"config"`
+	PendingNodes []string         `json:"pendingNodes"` // 已就绪、等待创建预热任务的节点（Leader 上有效）
+}
@@ -0,0 +1,65 @@
+package models
+
+// 节点未被选中的原因（ExcludedNode.Reason）
+const (
+	ExclusionLabelMismatch = "LabelSelectorMismatch" // 不满足 nodeSelector 或 matchExpressions
+	ExclusionNotIncluded   = "NotIncluded"           // 不在 includeNodes 中
+	ExclusionExcluded      = "Excluded"              // 在 excludeNodes 中
+	ExclusionTainted       = "Tainted"               // 带有 excludeTaints 中的污点
+	ExclusionNotReady      = "NotReady"
+	ExclusionCordoned      = "Cordoned"
+)
+
+// 凭据来源（PlanCredentials.Source）
+const (
+	CredentialSourceNone   = "none"
+	CredentialSourceManual = "manual" // 请求中直接提供的用户名密码
+	CredentialSourceSecret = "secret" // 已保存的仓库认证（secretId）
+)
+
+// TaskPlan 任务执行计划，由 dry-run 生成，不创建 Job 与 Secret
+type TaskPlan struct {
+	Images        []string         `json:"images,omitempty"`       // 从工作负载解析并合并后的镜像
+	MatchedNodes  []string         `json:"matchedNodes"`           // 满足筛选条件的节点
+	ExcludedNodes []ExcludedNode   `json:"excludedNodes"`          // 未被选中的节点及原因
+	PresentNodes  []string         `json:"presentNodes"`           // 已存在全部镜像的匹配节点（根据 Node.Status.Images 判断）
+	TargetNodes   []string         `json:"targetNodes"`            // 将创建 Job 的节点（skipPresentNodes 时不含 presentNodes）
+	TotalNodes    int              `json:"totalNodes"`             // 将创建 Job 的节点数
+	SkippedNodes  int              `json:"skippedNodes,omitempty"` // 将被跳过的节点数
+	BatchMode     string           `json:"batchMode"`
+	WindowSize    int              `json:"windowSize,omitempty"` // window 模式的窗口大小
+	TotalBatches  int              `json:"totalBatches"`
+	Batches       []PlanBatch      `json:"batches"`            // 批次划分
+	Credentials   *PlanCredentials `json:"credentials"`        // 镜像仓库凭据的解析结果
+	Estimate      *PlanEstimate    `json:"estimate,omitempty"` // 根据历史任务估算的耗时，没有历史数据时为空
+	Warnings      []string         `json:"warnings,omitempty"` // 按此计划执行时可能出现的问题
+}
+
+// ExcludedNode 未被选中的节点
+type ExcludedNode struct {
+	NodeName string `json:"nodeName"`
+	Reason   string `json:"reason"`
+}
+
+// PlanBatch 计划中的一个批次
+type PlanBatch struct {
+	Batch  int      `json:"batch"` // 批次号（从 1 开始）
+	Nodes  []string `json:"nodes"`
+	Canary bool     `json:"canary,omitempty"` // 金丝雀批次，结束后评估成功率再继续
+}
+
+// PlanCredentials 镜像仓库凭据的解析结果，不包含密码
+type PlanCredentials struct {
+	Source   string `json:"source"` // none/manual/secret
+	SecretID int64  `json:"secretId,omitempty"`
+	Registry string `json:"registry,omitempty"`
+	Username string `json:"username,omitempty"`
+	Error    string `json:"error,omitempty"` // 已保存的认证无法读取时的错误
+}
+
+// PlanEstimate 根据最近完成的任务估算的耗时
+type PlanEstimate struct {
+	DurationSeconds int     `json:"durationSeconds"` // 预计耗时（秒）
+	SecondsPerBatch float64 `json:"secondsPerBatch"` // 历史任务平均每批次耗时（秒）
+	SampleTasks     int     `json:"sampleTasks"`     // 参与估算的历史任务数
+}
@@ -2,19 +2,32 @@ package models
 
 // CreateTaskRequest 创建任务请求
 type CreateTaskRequest struct {
-	Images        []string          `json:"images" binding:"required,min=1"`
-	BatchSize     int               `json:"batchSize" binding:"required,min=1,max=100"`
-	Priority      int               `json:"priority" binding:"omitempty,min=1,max=10"` // 优先级 1-10，默认 5
-	NodeSelector  map[string]string `json:"nodeSelector,omitempty"`
-	MaxRetries    int               `json:"maxRetries" binding:"omitempty,min=0,max=5"`                 // 最大重试次数，默认 0（不重试）
-	RetryStrategy string            `json:"retryStrategy" binding:"omitempty,oneof=linear exponential"` // 重试策略，默认 linear
-	RetryDelay    int               `json:"retryDelay" binding:"omitempty,min=1,max=300"`               // 重试延迟（秒），默认 30
-	WebhookURL    string            `json:"webhookUrl" binding:"omitempty,url"`                         // Webhook 通知 URL
-	Registry      string            `json:"registry,omitempty" binding:"omitempty"`                     // 镜像仓库地址（如 harbor.example.com）
-	Username      string            `json:"username,omitempty" binding:"omitempty"`                     // 镜像仓库用户名
-	Password      string            `json:"password" binding:"omitempty"`                               // 镜像仓库密码（不包含在 API 响应中）
-	SecretID      int64             `json:"secretId,omitempty" binding:"omitempty"`                     // 已保存的仓库认证 ID（二选一：使用 secretId 或手动输入凭证）
-	ID            string            `json:"id,omitempty"`                                               // 可选，预热任务的 ID（定时触发时使用 sched- 前缀）
+	Images           []string          `json:"images" binding:"required_without=Workloads"` // 与 workloads 至少指定一项
+	BatchSize        int               `json:"batchSize" binding:"required,min=1,max=100"`
+	BatchMode        string            `json:"batchMode" binding:"omitempty,oneof=immediate pipelined window"` // 批次执行模式，默认 immediate
+	BatchRatio       float64           `json:"batchCompletionRatio" binding:"omitempty,gt=0,lte=1"`            // pipelined 模式下一批次启动所需的完成比例，默认 1
+	WindowSize       int               `json:"windowSize" binding:"omitempty,min=1,max=1000"`                  // window 模式的窗口大小，默认等于 batchSize
+	Priority         int               `json:"priority" binding:"omitempty,min=1,max=10"`                      // 优先级 1-10，默认 5
+	PullParallelism  int               `json:"pullParallelism" binding:"omitempty,min=1,max=10"`               // 单个节点上并行拉取的镜像数，默认 1
+	ImagePullTimeout int               `json:"imagePullTimeoutSeconds" binding:"omitempty,min=10,max=3600"`    // 单个镜像的拉取超时（秒），默认不限制
+	TimeoutSeconds   int               `json:"timeoutSeconds" binding:"omitempty,min=60,max=86400"`            // 任务超时（秒），默认不限制
+	NodeSelector     map[string]string `json:"nodeSelector,omitempty"`
+	NodeSelection    *NodeSelection    `json:"nodeSelection,omitempty"`                                    // 表达式、节点名单、污点及就绪状态等筛选条件
+	SkipPresent      bool              `json:"skipPresentNodes,omitempty"`                                 // 跳过已存在全部镜像的节点（仅对 digest 引用生效），默认 false
+	ResolveDigests   bool              `json:"resolveDigests,omitempty"`                                   // 创建任务时查询仓库将 tag 固定为 digest，默认 false
+	Rollout          *RolloutStrategy  `json:"rollout,omitempty"`                                          // 灰度策略，默认一次性预热所有节点
+	SuccessPolicy    *SuccessPolicy    `json:"successPolicy,omitempty"`                                    // 任务成功条件，默认至少 90% 的节点成功
+	Workloads        *WorkloadSource   `json:"workloads,omitempty"`                                        // 从工作负载解析镜像（及可调度节点）
+	MaxRetries       int               `json:"maxRetries" binding:"omitempty,min=0,max=5"`                 // 每个失败节点的最大重试次数，默认 0（不重试）
+	RetryStrategy    string            `json:"retryStrategy" binding:"omitempty,oneof=linear exponential"` // 重试策略，默认 linear
+	RetryDelay       int               `json:"retryDelay" binding:"omitempty,min=1,max=300"`               // 重试延迟（秒），默认 30
+	WebhookURL       string            `json:"webhookUrl" binding:"omitempty,url"`                         // Webhook 通知 URL
+	Registry         string            `json:"registry,omitempty" binding:"omitempty"`                     // 镜像仓库地址（如 harbor.example.com）
+	Username         string            `json:"username,omitempty" binding:"omitempty"`                     // 镜像仓库用户名
+	Password         string            `json:"password" binding:"omitempty"`                               // 镜像仓库密码（不包含在 API 响应中）
+	SecretID         int64             `json:"secretId,omitempty" binding:"omitempty"`                     // 已保存的仓库认证 ID（二选一：使用 secretId 或手动输入凭证）
+	ID               string            `json:"id,omitempty"`                                               // 可选，预热任务的 ID（定时触发时使用 sched- 前缀）
+	CreatedBy        string            `json:"-"`                                                          // 创建者用户名，由服务端根据登录用户填充
 }
 
 // ListTasksRequest 列表查询请求
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// 金丝雀未达标时的动作（RolloutStrategy.OnFailure）
+const (
+	CanaryActionAbort = "abort" // 任务失败，不再提交其余节点（默认）
+	CanaryActionPause = "pause" // 任务暂停，人工确认后可恢复继续
+)
+
+// DefaultCanarySuccessThreshold 未指定阈值时金丝雀节点需要达到的成功率（百分比）
+const DefaultCanarySuccessThreshold = 90
+
+// RolloutStrategy 灰度发布策略：先预热一小部分节点（金丝雀），成功率达标后再提交其余节点
+type RolloutStrategy struct {
+	CanaryNodes      int     `json:"canaryNodes,omitempty"`      // 金丝雀节点数
+	CanaryPercent    float64 `json:"canaryPercent,omitempty"`    // 金丝雀节点占目标节点的百分比 (0,100]，与 canaryNodes 二选一
+	SuccessThreshold float64 `json:"successThreshold,omitempty"` // 金丝雀节点需要达到的成功率（百分比），默认 90
+	OnFailure        string  `json:"onFailure,omitempty"`        // 未达标时的动作：abort（默认）或 pause
+}
+
+// Validate 校验灰度策略
+func (r *RolloutStrategy) Validate() error {
+	if r == nil {
+		return nil
+	}
+
+	switch {
+	case r.CanaryNodes < 0:
+		return fmt.Errorf("canaryNodes must not be negative")
+	case r.CanaryPercent < 0 || r.CanaryPercent > 100:
+		return fmt.Errorf("canaryPercent must be between 0 and 100")
+	case r.CanaryNodes > 0 && r.CanaryPercent > 0:
+		return fmt.Errorf("only one of canaryNodes and canaryPercent can be set")
+	case r.CanaryNodes == 0 && r.CanaryPercent == 0:
+		return fmt.Errorf("one of canaryNodes and canaryPercent is required")
+	case r.SuccessThreshold < 0 || r.SuccessThreshold > 100:
+		return fmt.Errorf("successThreshold must be between 0 and 100")
+	}
+
+	switch r.OnFailure {
+	case "", CanaryActionAbort, CanaryActionPause:
+	default:
+		return fmt.Errorf("unsupported onFailure action %q", r.OnFailure)
+	}
+	return nil
+}
+
+// CanarySize 返回 total 个目标节点中的金丝雀节点数（按百分比计算时向上取整）
+func (r *RolloutStrategy) CanarySize(total int) int {
+	if r == nil {
+		return 0
+	}
+	if r.CanaryNodes > 0 {
+		return min(r.CanaryNodes, total)
+	}
+	return min(int(math.Ceil(r.CanaryPercent*float64(total)/100)), total)
+}
+
+// Threshold 返回金丝雀节点需要达到的成功率（百分比）
+func (r *RolloutStrategy) Threshold() float64 {
+	if r.SuccessThreshold == 0 {
+		return DefaultCanarySuccessThreshold
+	}
+	return r.SuccessThreshold
+}
+
+// Action 返回金丝雀未达标时的动作
+func (r *RolloutStrategy) Action() string {
+	if r.OnFailure == "" {
+		return CanaryActionAbort
+	}
+	return r.OnFailure
+}
+
+// CanaryPhase 金丝雀阶段状态
+type CanaryPhase string
+
+const (
+	CanaryPending CanaryPhase = "pending" // 金丝雀节点尚未全部结束
+	CanaryPassed  CanaryPhase = "passed"  // 成功率达标，继续提交其余节点
+	CanaryFailed  CanaryPhase = "failed"  // 成功率未达标
+)
+
+// CanaryStatus 金丝雀阶段的节点与评估结果
+type CanaryStatus struct {
+	Phase       CanaryPhase `json:"phase"`
+	Nodes       []string    `json:"nodes"`                 // 金丝雀节点（目标节点的前 N 个）
+	Succeeded   int         `json:"succeeded"`             // 成功的金丝雀节点数
+	Failed      int         `json:"failed"`                // 失败的金丝雀节点数
+	SuccessRate float64     `json:"successRate"`           // 成功率（百分比）
+	Threshold   float64     `json:"threshold"`             // 评估时使用的阈值（百分比）
+	FailedNodes []string    `json:"failedNodes,omitempty"` // 失败的金丝雀节点
+	EvaluatedAt *time.Time  `json:"evaluatedAt,omitempty"`
+}
@@ -27,15 +27,52 @@ const (
 
 // TaskConfig 定时任务执行时的任务配置（复用 CreateTaskRequest）
 type TaskConfig struct {
-	Images        []string          `json:"images"`
-	BatchSize     int               `json:"batchSize"`
-	Priority      int               `json:"priority"`
-	NodeSelector  map[string]string `json:"nodeSelector,omitempty"`
-	MaxRetries    int               `json:"maxRetries"`
-	RetryStrategy string            `json:"retryStrategy"`
-	RetryDelay    int               `json:"retryDelay"`
-	WebhookURL    string            `json:"webhookUrl,omitempty"`
-	SecretID      int64             `json:"secretId,omitempty"`
+	Images           []string          `json:"images"`
+	BatchSize        int               `json:"batchSize"`
+	BatchMode        string            `json:"batchMode,omitempty"`
+	BatchRatio       float64           `json:"batchCompletionRatio,omitempty"`
+	WindowSize       int               `json:"windowSize,omitempty"`
+	PullParallelism  int               `json:"pullParallelism,omitempty"`
+	ImagePullTimeout int               `json:"imagePullTimeoutSeconds,omitempty"`
+	Priority         int               `json:"priority"`
+	NodeSelector     map[string]string `json:"nodeSelector,omitempty"`
+	NodeSelection    *NodeSelection    `json:"nodeSelection,omitempty"`
+	SkipPresent      bool              `json:"skipPresentNodes,omitempty"`
+	ResolveDigests   bool              `json:"resolveDigests,omitempty"`
+	Rollout          *RolloutStrategy  `json:"rollout,omitempty"`
+	SuccessPolicy    *SuccessPolicy    `json:"successPolicy,omitempty"`
+	Workloads        *WorkloadSource   `json:"workloads,omitempty"` // 每次触发时重新解析工作负载的镜像
+	MaxRetries       int               `json:"maxRetries"`
+	RetryStrategy    string            `json:"retryStrategy"`
+	RetryDelay       int               `json:"retryDelay"`
+	WebhookURL       string            `json:"webhookUrl,omitempty"`
+	SecretID         int64             `json:"secretId,omitempty"`
+}
+
+// CreateTaskRequest 根据任务配置生成创建任务请求，ID、超时与创建者由调用方设置
+func (c *TaskConfig) CreateTaskRequest() *CreateTaskRequest {
+	return &CreateTaskRequest{
+		Images:           c.Images,
+		BatchSize:        c.BatchSize,
+		BatchMode:        c.BatchMode,
+		BatchRatio:       c.BatchRatio,
+		WindowSize:       c.WindowSize,
+		PullParallelism:  c.PullParallelism,
+		ImagePullTimeout: c.ImagePullTimeout,
+		Priority:         c.Priority,
+		NodeSelector:     c.NodeSelector,
+		NodeSelection:    c.NodeSelection,
+		SkipPresent:      c.SkipPresent,
+		ResolveDigests:   c.ResolveDigests,
+		Rollout:          c.Rollout,
+		SuccessPolicy:    c.SuccessPolicy,
+		Workloads:        c.Workloads,
+		MaxRetries:       c.MaxRetries,
+		RetryStrategy:    c.RetryStrategy,
+		RetryDelay:       c.RetryDelay,
+		WebhookURL:       c.WebhookURL,
+		SecretID:         c.SecretID,
+	}
 }
 
 // ScheduledTask 定时任务模型
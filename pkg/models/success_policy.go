@@ -0,0 +1,43 @@
+package models
+
+import "fmt"
+
+// DefaultMinSuccessPercent 未指定成功条件时任务完成所需的成功节点百分比
+const DefaultMinSuccessPercent = 90
+
+// SuccessPolicy 任务成功判定条件，所有条件同时满足时任务为 completed，否则为 failed
+type SuccessPolicy struct {
+	MinSuccessPercent     float64           `json:"minSuccessPercent,omitempty"`     // 成功节点占目标节点的最低百分比 (0,100]，默认 90
+	RequiredNodes         []string          `json:"requiredNodes,omitempty"`         // 必须成功的节点
+	RequiredNodeSelector  map[string]string `json:"requiredNodeSelector,omitempty"`  // 匹配该标签的目标节点必须成功
+	ImageFailureFailsNode bool              `json:"imageFailureFailsNode,omitempty"` // 任一镜像拉取失败时节点记为失败（Job 正常退出也是如此）
+}
+
+// Validate 校验成功条件的结构，标签键值的语法由构建选择器时校验
+func (p *SuccessPolicy) Validate() error {
+	if p == nil {
+		return nil
+	}
+	if p.MinSuccessPercent < 0 || p.MinSuccessPercent > 100 {
+		return fmt.Errorf("minSuccessPercent must be between 0 and 100")
+	}
+	for _, name := range p.RequiredNodes {
+		if name == "" {
+			return fmt.Errorf("required node names must not be empty")
+		}
+	}
+	return nil
+}
+
+// MinPercent 返回任务完成所需的成功节点百分比
+func (p *SuccessPolicy) MinPercent() float64 {
+	if p == nil || p.MinSuccessPercent == 0 {
+		return DefaultMinSuccessPercent
+	}
+	return p.MinSuccessPercent
+}
+
+// FailsOnImageFailure 镜像拉取失败时是否将节点记为失败
+func (p *SuccessPolicy) FailsOnImageFailure() bool {
+	return p != nil && p.ImageFailureFailsNode
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // TaskStatus 任务状态
 type TaskStatus string
@@ -11,34 +14,69 @@ const (
 	TaskCompleted TaskStatus = "completed"
 	TaskFailed    TaskStatus = "failed"
 	TaskCancelled TaskStatus = "cancelled"
+	// TaskPaused 已暂停：不再创建新的 Job，恢复后从已提交的批次继续
+	TaskPaused TaskStatus = "paused"
+)
+
+// IsTerminal 是否为结束状态（completed/failed/cancelled），结束的任务不会再回到 pending/running
+func (s TaskStatus) IsTerminal() bool {
+	return s == TaskCompleted || s == TaskFailed || s == TaskCancelled
+}
+
+// 批次执行模式
+const (
+	// BatchModeImmediate 依次提交所有批次，不等待前一批次完成（默认）
+	BatchModeImmediate = "immediate"
+	// BatchModePipelined 前一批次达到完成比例后再提交下一批次
+	BatchModePipelined = "pipelined"
+	// BatchModeWindow 滑动窗口，同时拉取的节点数不超过窗口大小
+	BatchModeWindow = "window"
 )
 
 // Task 代表一个镜像预热任务
 type Task struct {
-	ID            string                    `json:"taskId"`
-	Status        TaskStatus                `json:"status"`
-	Priority      int                       `json:"priority"` // 优先级 1-10，数字越大优先级越高
-	Images        []string                  `json:"images"`
-	BatchSize     int                       `json:"batchSize"`
-	NodeSelector  map[string]string         `json:"nodeSelector,omitempty"`
-	Progress      *Progress                 `json:"progress,omitempty"`
-	FailedNodes   []FailedNode              `json:"failedNodeDetails,omitempty"`
-	MaxRetries    int                       `json:"maxRetries"`           // 最大重试次数
-	RetryCount    int                       `json:"retryCount"`           // 当前重试次数
-	RetryStrategy string                    `json:"retryStrategy"`        // 重试策略: "linear" 或 "exponential"
-	RetryDelay    int                       `json:"retryDelay,omitempty"` // 重试延迟（秒）
-	WebhookURL    string                    `json:"webhookUrl,omitempty"` // Webhook 通知 URL
-	SecretName    string                    `json:"secretName,omitempty"` // 用于私有仓库认证的 Secret 名称（临时值）
-	SecretID      int64                     `json:"secretId,omitempty"`   // 已保存的 secret ID（优先级高于手动凭证）
-	Registry      string                    `json:"registry,omitempty"`   // 镜像仓库地址（手动输入）
-	Username      string                    `json:"username,omitempty"`   // 用户名（手动输入）
-	Password      string                    `json:"-"`                    // 密码（手动输入，不返回）
-	CreatedAt     time.Time                 `json:"createdAt"`
-	StartedAt     *time.Time                `json:"startedAt,omitempty"`
-	FinishedAt    *time.Time                `json:"finishedAt,omitempty"`
-	EstimatedEnd  *time.Time                `json:"estimatedCompletion,omitempty"`
-	ErrorMessage  string                    `json:"errorMessage,omitempty"`
-	NodeStatuses  map[string]map[string]int `json:"nodeStatuses,omitempty"` // nodeName -> imageName -> status (1:success, 0:fail)
+	ID               string                   `json:"taskId"`
+	Status           TaskStatus               `json:"status"`
+	Priority         int                      `json:"priority"`                // 优先级 1-10，数字越大优先级越高
+	QueuePosition    int                      `json:"queuePosition,omitempty"` // 排队位置（仅 pending 状态，从 1 开始，不持久化）
+	Images           []string                 `json:"images"`
+	ImageDigests     map[string]string        `json:"imageDigests,omitempty"` // 请求中的镜像 -> 固定到 digest 的镜像（resolveDigests 时）
+	BatchSize        int                      `json:"batchSize"`
+	BatchMode        string                   `json:"batchMode,omitempty"`               // 批次执行模式: immediate/pipelined/window
+	BatchRatio       float64                  `json:"batchCompletionRatio,omitempty"`    // pipelined 模式下启动下一批次所需的完成比例 (0,1]
+	WindowSize       int                      `json:"windowSize,omitempty"`              // window 模式下同时拉取的最大节点数
+	PullParallelism  int                      `json:"pullParallelism,omitempty"`         // 单个节点上并行拉取的镜像数，0 表示逐个拉取
+	ImagePullTimeout int                      `json:"imagePullTimeoutSeconds,omitempty"` // 单个镜像的拉取超时（秒），0 表示不限制
+	TimeoutSeconds   int                      `json:"timeoutSeconds,omitempty"`          // 任务超时（秒，从开始执行时计算），0 表示不限制
+	NodeSelector     map[string]string        `json:"nodeSelector,omitempty"`
+	NodeSelection    *NodeSelection           `json:"nodeSelection,omitempty"`    // nodeSelector 之外的节点筛选条件
+	SkipPresent      bool                     `json:"skipPresentNodes,omitempty"` // 跳过已存在全部镜像的节点（根据 Node.Status.Images 判断，仅对 digest 引用生效）
+	Rollout          *RolloutStrategy         `json:"rollout,omitempty"`          // 灰度策略：先预热金丝雀节点，达标后继续
+	Canary           *CanaryStatus            `json:"canary,omitempty"`           // 金丝雀阶段的节点与评估结果
+	SuccessPolicy    *SuccessPolicy           `json:"successPolicy,omitempty"`    // 任务成功条件，默认至少 90% 的节点成功
+	RequiredNodes    []string                 `json:"requiredNodes,omitempty"`    // 根据成功条件解析出的必须成功的节点
+	Workloads        *WorkloadSource          `json:"workloads,omitempty"`        // 镜像来源的工作负载，镜像已合并到 images
+	CreatedBy        string                   `json:"createdBy,omitempty"`        // 创建者用户名（用于按创建者限制并发）
+	TargetNodes      []string                 `json:"targetNodes,omitempty"`      // 节点筛选后确定的目标节点（用于重启后恢复）
+	Progress         *Progress                `json:"progress,omitempty"`
+	FailedNodes      []FailedNode             `json:"failedNodeDetails,omitempty"`
+	MaxRetries       int                      `json:"maxRetries"`           // 每个失败节点的最大重试次数
+	RetryCount       int                      `json:"retryCount"`           // 已废弃：任务不再整体重试，始终为 0，节点重试见 nodeAttempts
+	RetryStrategy    string                   `json:"retryStrategy"`        // 重试策略: "linear" 或 "exponential"
+	RetryDelay       int                      `json:"retryDelay,omitempty"` // 重试延迟（秒）
+	WebhookURL       string                   `json:"webhookUrl,omitempty"` // Webhook 通知 URL
+	SecretName       string                   `json:"secretName,omitempty"` // 用于私有仓库认证的 Secret 名称（临时值）
+	SecretID         int64                    `json:"secretId,omitempty"`   // 已保存的 secret ID（优先级高于手动凭证）
+	Registry         string                   `json:"registry,omitempty"`   // 镜像仓库地址（手动输入）
+	Username         string                   `json:"username,omitempty"`   // 用户名（手动输入）
+	Password         string                   `json:"-"`                    // 密码（手动输入，不返回）
+	CreatedAt        time.Time                `json:"createdAt"`
+	StartedAt        *time.Time               `json:"startedAt,omitempty"`
+	FinishedAt       *time.Time               `json:"finishedAt,omitempty"`
+	EstimatedEnd     *time.Time               `json:"estimatedCompletion,omitempty"`
+	ErrorMessage     string                   `json:"errorMessage,omitempty"`
+	NodeResults      map[string]*NodeResult   `json:"nodeResults,omitempty"`  // nodeName -> 节点预热结果
+	NodeAttempts     map[string][]NodeAttempt `json:"nodeAttempts,omitempty"` // nodeName -> 节点的尝试历史（按尝试序号递增）
 }
 
 // Progress 任务进度
@@ -49,6 +87,7 @@ type Progress struct {
 	CurrentBatch   int     `json:"currentBatch"`
 	TotalBatches   int     `json:"totalBatches"`
 	Percentage     float64 `json:"percentage"`
+	SkippedNodes   int     `json:"skippedNodes,omitempty"` // 已存在全部镜像而跳过的节点数（不计入 TotalNodes）
 }
 
 // FailedNode 失败节点详情
@@ -57,9 +96,92 @@ type FailedNode struct {
 	Image     string    `json:"image"`
 	Reason    string    `json:"reason"`
 	Message   string    `json:"message,omitempty"`
+	Attempts  int       `json:"attempts,omitempty"` // 节点已尝试的次数
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// Deadline 返回任务的截止时间，未设置超时或尚未开始执行时返回 false
+func (t *Task) Deadline() (time.Time, bool) {
+	if t.TimeoutSeconds <= 0 || t.StartedAt == nil {
+		return time.Time{}, false
+	}
+	return t.StartedAt.Add(time.Duration(t.TimeoutSeconds) * time.Second), true
+}
+
+// DeadlineExceeded 任务是否已超过截止时间
+func (t *Task) DeadlineExceeded(now time.Time) bool {
+	deadline, ok := t.Deadline()
+	return ok && !now.Before(deadline)
+}
+
+// Clone 返回任务的深拷贝，用于在执行器与其他调用方之间隔离任务对象
+func (t *Task) Clone() *Task {
+	// 任务中只有基本类型、切片、map 与时间，序列化不会失败
+	data, _ := json.Marshal(t)
+	clone := &Task{}
+	_ = json.Unmarshal(data, clone)
+
+	// 不参与 JSON 序列化的字段单独复制
+	clone.Password = t.Password
+	return clone
+}
+
+// MarshalJSON 除 nodeResults 外额外输出旧版本的 nodeStatuses 字段（nodeName -> imageName -> 1/0）
+// 已废弃：保留一个版本供旧客户端迁移到 nodeResults，下个版本移除
+func (t Task) MarshalJSON() ([]byte, error) {
+	type task Task
+	return json.Marshal(struct {
+		task
+		NodeStatuses map[string]map[string]int `json:"nodeStatuses,omitempty"`
+	}{task(t), t.legacyNodeStatuses()})
+}
+
+// legacyNodeStatuses 将节点结果转换为旧版本的 nodeStatuses 格式
+// 旧格式只包含已产生结果的节点，Job 失败且没有镜像结果时为空 map
+func (t *Task) legacyNodeStatuses() map[string]map[string]int {
+	if len(t.NodeResults) == 0 {
+		return nil
+	}
+
+	statuses := make(map[string]map[string]int, len(t.NodeResults))
+	for nodeName, result := range t.NodeResults {
+		if result == nil || result.Status == NodeResultPending {
+			continue
+		}
+		images := make(map[string]int, len(result.Images))
+		for i := range result.Images {
+			images[result.Images[i].Image] = 0
+			if result.Images[i].Succeeded() {
+				images[result.Images[i].Image] = 1
+			}
+		}
+		// 跳过的节点已存在全部镜像
+		if result.Status == NodeResultSkipped && len(result.Images) == 0 {
+			for _, image := range t.Images {
+				images[image] = 1
+			}
+		}
+		statuses[nodeName] = images
+	}
+	return statuses
+}
+
+// NodeRetryCount 返回节点的重试次数（首次尝试不计入）
+func (t *Task) NodeRetryCount(nodeName string) int {
+	return max(len(t.NodeAttempts[nodeName])-1, 0)
+}
+
+// FindNodeAttempt 返回节点指定序号的尝试记录，不存在时返回 nil
+func (t *Task) FindNodeAttempt(nodeName string, attempt int) *NodeAttempt {
+	attempts := t.NodeAttempts[nodeName]
+	for i := range attempts {
+		if attempts[i].Attempt == attempt {
+			return &attempts[i]
+		}
+	}
+	return nil
+}
+
 // CalculateProgress 计算任务进度
 func (t *Task) CalculateProgress() {
 	if t.Progress == nil {
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTask_MarshalJSON_LegacyNodeStatuses(t *testing.T) {
+	task := &Task{
+		ID:     "task-1",
+		Images: []string{"nginx:latest", "redis:7"},
+		NodeResults: map[string]*NodeResult{
+			"node-1": NewNodeResult("node-1", []ImagePullResult{
+				{Image: "nginx:latest", Status: ImagePullSucceeded},
+				{Image: "redis:7", Status: ImagePullTimeout},
+			}),
+			"node-2":  {NodeName: "node-2", Status: NodeResultFailed, Message: "BackoffLimitExceeded"},
+			"node-3":  {NodeName: "node-3", Status: NodeResultSkipped},
+			"pending": {NodeName: "pending", Status: NodeResultPending},
+		},
+		Password: "secret",
+	}
+
+	data, err := json.Marshal(task)
+	require.NoError(t, err)
+
+	var resp struct {
+		TaskID       string                    `json:"taskId"`
+		NodeResults  map[string]*NodeResult    `json:"nodeResults"`
+		NodeStatuses map[string]map[string]int `json:"nodeStatuses"`
+	}
+	require.NoError(t, json.Unmarshal(data, &resp))
+	assert.Equal(t, "task-1", resp.TaskID)
+	assert.Len(t, resp.NodeResults, 4)
+	assert.Equal(t, map[string]map[string]int{
+		"node-1": {"nginx:latest": 1, "redis:7": 0},
+		"node-2": {},
+		"node-3": {"nginx:latest": 1, "redis:7": 1},
+	}, resp.NodeStatuses)
+	assert.NotContains(t, string(data), "secret")
+
+	// 值类型同样输出兼容字段，Clone 忽略该字段
+	data, err = json.Marshal(*task)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"nodeStatuses"`)
+	clone := task.Clone()
+	assert.Equal(t, task.NodeResults["node-1"].Images, clone.NodeResults["node-1"].Images)
+	assert.Equal(t, "secret", clone.Password)
+}
@@ -0,0 +1,42 @@
+package models
+
+import "fmt"
+
+// WorkloadRef 工作负载引用
+type WorkloadRef struct {
+	Kind      string `json:"kind"`                // Deployment、StatefulSet 或 DaemonSet
+	Namespace string `json:"namespace,omitempty"` // 默认使用 WorkloadSource.Namespace
+	Name      string `json:"name"`
+}
+
+// WorkloadSource 从工作负载解析预热镜像：指定工作负载列表，或按命名空间与标签选择工作负载
+// 解析 initContainers 与 containers 使用的镜像，与请求中的 images 合并
+type WorkloadSource struct {
+	Workloads     []WorkloadRef     `json:"workloads,omitempty"`     // 指定的工作负载
+	Namespace     string            `json:"namespace,omitempty"`     // 按标签选择时的命名空间，也是 workloads 的默认命名空间
+	LabelSelector map[string]string `json:"labelSelector,omitempty"` // 按标签选择命名空间中的工作负载，为空时选择全部
+	Kinds         []string          `json:"kinds,omitempty"`         // 按标签选择的工作负载类型，默认全部类型
+	MatchNodes    bool              `json:"matchNodes,omitempty"`    // 只预热工作负载可调度的节点（nodeSelector、必需的节点亲和性与容忍度）
+}
+
+// Validate 校验工作负载来源的结构，工作负载类型与标签语法由解析时校验
+func (s *WorkloadSource) Validate() error {
+	if s == nil {
+		return nil
+	}
+	if len(s.Workloads) == 0 && s.Namespace == "" {
+		return fmt.Errorf("either workloads or namespace is required")
+	}
+	if len(s.Workloads) > 0 && (len(s.LabelSelector) > 0 || len(s.Kinds) > 0) {
+		return fmt.Errorf("labelSelector and kinds cannot be used with workloads")
+	}
+	for i, ref := range s.Workloads {
+		if ref.Kind == "" || ref.Name == "" {
+			return fmt.Errorf("workloads[%d]: kind and name are required", i)
+		}
+		if ref.Namespace == "" && s.Namespace == "" {
+			return fmt.Errorf("workloads[%d]: namespace is required", i)
+		}
+	}
+	return nil
+}